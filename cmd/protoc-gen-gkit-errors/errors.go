@@ -0,0 +1,111 @@
+package main
+
+import (
+	"strings"
+
+	"github.com/songzhibin97/gkit/errors"
+	"google.golang.org/protobuf/compiler/protogen"
+	"google.golang.org/protobuf/proto"
+)
+
+const (
+	fmtPackage    = protogen.GoImportPath("fmt")
+	errorsPackage = protogen.GoImportPath("github.com/songzhibin97/gkit/errors")
+)
+
+// generateFile 生成 _errors.pb.go, 文件中没有需要生成的枚举时返回 nil
+func generateFile(gen *protogen.Plugin, file *protogen.File) *protogen.GeneratedFile {
+	var enums []*protogen.Enum
+	for _, enum := range file.Enums {
+		if hasCode(enum) {
+			enums = append(enums, enum)
+		}
+	}
+	if len(enums) == 0 {
+		return nil
+	}
+	g := gen.NewGeneratedFile(file.GeneratedFilenamePrefix+"_errors.pb.go", file.GoImportPath)
+	g.P("// Code generated by protoc-gen-gkit-errors. DO NOT EDIT.")
+	g.P("// versions:")
+	g.P("// - protoc-gen-gkit-errors ", release)
+	g.P("// source: ", file.Desc.Path())
+	g.P()
+	g.P("package ", file.GoPackageName)
+	g.P()
+	for _, enum := range enums {
+		generateEnum(g, enum)
+	}
+	return g
+}
+
+// hasCode 枚举设置了 default_code 或任一枚举值设置了 code
+func hasCode(enum *protogen.Enum) bool {
+	if defaultCode(enum) != 0 {
+		return true
+	}
+	for _, v := range enum.Values {
+		if valueCode(v) != 0 {
+			return true
+		}
+	}
+	return false
+}
+
+func generateEnum(g *protogen.GeneratedFile, enum *protogen.Enum) {
+	def := defaultCode(enum)
+	for _, v := range enum.Values {
+		code := valueCode(v)
+		if code == 0 {
+			code = def
+		}
+		// 没有可用的状态码, 不生成
+		if code == 0 {
+			continue
+		}
+		name := camelCase(string(v.Desc.Name()))
+		comment := strings.TrimSpace(string(v.Comments.Leading))
+
+		g.P("// Is", name, " 判断 err 是否为 ", v.GoIdent, ", 同时比较 reason 与 code")
+		g.P("func Is", name, "(err error) bool {")
+		g.P("if err == nil {")
+		g.P("return false")
+		g.P("}")
+		g.P("return ", g.QualifiedGoIdent(errorsPackage.Ident("Reason")), "(err) == ", v.GoIdent, ".String() && ",
+			g.QualifiedGoIdent(errorsPackage.Ident("Code")), "(err) == ", code)
+		g.P("}")
+		g.P()
+		g.P("// Error", name, " 实例化 ", v.GoIdent, " 错误, HTTP 状态码 ", code)
+		if comment != "" {
+			g.P("//")
+			for _, line := range strings.Split(comment, "\n") {
+				g.P("// ", strings.TrimSpace(line))
+			}
+		}
+		g.P("func Error", name, "(format string, args ...interface{}) *", errorsPackage.Ident("Error"), " {")
+		g.P("return ", errorsPackage.Ident("New"), "(", code, ", ", v.GoIdent, ".String(), ",
+			fmtPackage.Ident("Sprintf"), "(format, args...))")
+		g.P("}")
+		g.P()
+	}
+}
+
+func defaultCode(enum *protogen.Enum) int32 {
+	return proto.GetExtension(enum.Desc.Options(), errors.E_DefaultCode).(int32)
+}
+
+func valueCode(v *protogen.EnumValue) int32 {
+	return proto.GetExtension(v.Desc.Options(), errors.E_Code).(int32)
+}
+
+// camelCase USER_NOT_FOUND -> UserNotFound
+func camelCase(name string) string {
+	var b strings.Builder
+	for _, part := range strings.Split(strings.ToLower(name), "_") {
+		if part == "" {
+			continue
+		}
+		b.WriteString(strings.ToUpper(part[:1]))
+		b.WriteString(part[1:])
+	}
+	return b.String()
+}
@@ -0,0 +1,198 @@
+package main
+
+import (
+	"bytes"
+	"flag"
+	"go/parser"
+	"go/token"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+
+	protoparser "github.com/emicklei/proto"
+	"github.com/songzhibin97/gkit/errors"
+	"google.golang.org/protobuf/compiler/protogen"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/descriptorpb"
+	"google.golang.org/protobuf/types/known/anypb"
+	"google.golang.org/protobuf/types/pluginpb"
+)
+
+var update = flag.Bool("update", false, "update golden files")
+
+// loadProto 解析 testdata 下的 proto 文件, 构造与 protoc 一致的文件描述,
+// 仅支持本插件关心的 package、import、go_package 以及枚举上的 gkit.errors 选项
+func loadProto(t *testing.T, name string) *descriptorpb.FileDescriptorProto {
+	t.Helper()
+	f, err := os.Open(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	definition, err := protoparser.NewParser(f).Parse()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	file := &descriptorpb.FileDescriptorProto{
+		Name:           proto.String(filepath.ToSlash(name)),
+		Options:        &descriptorpb.FileOptions{},
+		SourceCodeInfo: &descriptorpb.SourceCodeInfo{},
+	}
+	for _, element := range definition.Elements {
+		switch e := element.(type) {
+		case *protoparser.Syntax:
+			file.Syntax = proto.String(e.Value)
+		case *protoparser.Package:
+			file.Package = proto.String(e.Name)
+		case *protoparser.Import:
+			file.Dependency = append(file.Dependency, e.Filename)
+		case *protoparser.Option:
+			if e.Name != "go_package" {
+				t.Fatalf("%s: unsupported file option %s", e.Position, e.Name)
+			}
+			file.Options.GoPackage = proto.String(e.Constant.Source)
+		case *protoparser.Enum:
+			file.EnumType = append(file.EnumType, enumProto(t, e, int32(len(file.EnumType)), file.SourceCodeInfo))
+		case *protoparser.Comment:
+		default:
+			t.Fatalf("unsupported proto element %T", e)
+		}
+	}
+	return file
+}
+
+// enumProto 转换枚举, 枚举值的前置注释记录到 info 中
+func enumProto(t *testing.T, enum *protoparser.Enum, index int32, info *descriptorpb.SourceCodeInfo) *descriptorpb.EnumDescriptorProto {
+	t.Helper()
+	desc := &descriptorpb.EnumDescriptorProto{Name: proto.String(enum.Name)}
+	for _, element := range enum.Elements {
+		switch e := element.(type) {
+		case *protoparser.Option:
+			if desc.Options == nil {
+				desc.Options = &descriptorpb.EnumOptions{}
+			}
+			setCodeOption(t, desc.Options, e, "(gkit.errors.default_code)", errors.E_DefaultCode)
+		case *protoparser.EnumField:
+			value := &descriptorpb.EnumValueDescriptorProto{
+				Name:   proto.String(e.Name),
+				Number: proto.Int32(int32(e.Integer)),
+			}
+			for _, ve := range e.Elements {
+				option, ok := ve.(*protoparser.Option)
+				if !ok {
+					continue
+				}
+				if value.Options == nil {
+					value.Options = &descriptorpb.EnumValueOptions{}
+				}
+				setCodeOption(t, value.Options, option, "(gkit.errors.code)", errors.E_Code)
+			}
+			if e.Comment != nil {
+				info.Location = append(info.Location, &descriptorpb.SourceCodeInfo_Location{
+					Path:            []int32{5, index, 2, int32(len(desc.Value))},
+					Span:            []int32{int32(e.Position.Line - 1), int32(e.Position.Column - 1), int32(e.Position.Column - 1)},
+					LeadingComments: proto.String(strings.Join(e.Comment.Lines, "\n") + "\n"),
+				})
+			}
+			desc.Value = append(desc.Value, value)
+		case *protoparser.Comment:
+		default:
+			t.Fatalf("unsupported enum element %T", e)
+		}
+	}
+	return desc
+}
+
+func setCodeOption(t *testing.T, options proto.Message, option *protoparser.Option, name string, xt protoreflect.ExtensionType) {
+	t.Helper()
+	if option.Name != name {
+		t.Fatalf("%s: unsupported option %s", option.Position, option.Name)
+	}
+	code, err := strconv.ParseInt(option.Constant.Source, 10, 32)
+	if err != nil {
+		t.Fatalf("%s: invalid code %s", option.Position, option.Constant.Source)
+	}
+	proto.SetExtension(options, xt, int32(code))
+}
+
+func runPlugin(t *testing.T, file *descriptorpb.FileDescriptorProto) *pluginpb.CodeGeneratorResponse {
+	t.Helper()
+	req := &pluginpb.CodeGeneratorRequest{
+		FileToGenerate: []string{file.GetName()},
+		Parameter:      proto.String("paths=source_relative"),
+		ProtoFile: []*descriptorpb.FileDescriptorProto{
+			protodesc.ToFileDescriptorProto(anypb.File_google_protobuf_any_proto),
+			protodesc.ToFileDescriptorProto(descriptorpb.File_google_protobuf_descriptor_proto),
+			protodesc.ToFileDescriptorProto(errors.File_errors_proto),
+			file,
+		},
+	}
+	gen, err := protogen.Options{}.New(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, f := range gen.Files {
+		if f.Generate {
+			generateFile(gen, f)
+		}
+	}
+	resp := gen.Response()
+	if resp.Error != nil {
+		t.Fatal(resp.GetError())
+	}
+	return resp
+}
+
+func TestGenerateGolden(t *testing.T) {
+	resp := runPlugin(t, loadProto(t, filepath.Join("testdata", "reason.proto")))
+	if len(resp.File) != 1 {
+		t.Fatalf("generated %d files, want 1", len(resp.File))
+	}
+	got := resp.File[0]
+	if got.GetName() != "testdata/reason_errors.pb.go" {
+		t.Fatalf("file name = %s", got.GetName())
+	}
+	content := []byte(got.GetContent())
+	if _, err := parser.ParseFile(token.NewFileSet(), got.GetName(), content, 0); err != nil {
+		t.Fatalf("generated code does not parse: %v\n%s", err, content)
+	}
+
+	golden := filepath.Join("testdata", "reason_errors.pb.go.golden")
+	if *update {
+		if err := os.WriteFile(golden, content, 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	want, err := os.ReadFile(golden)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(content, want) {
+		t.Fatalf("generated code differs from %s, run go test -update\n%s", golden, content)
+	}
+}
+
+func TestGenerateSkipsFileWithoutCodes(t *testing.T) {
+	file := loadProto(t, filepath.Join("testdata", "reason.proto"))
+	file.EnumType = file.EnumType[1:]
+	if resp := runPlugin(t, file); len(resp.File) != 0 {
+		t.Fatalf("generated %d files, want none", len(resp.File))
+	}
+}
+
+func TestCamelCase(t *testing.T) {
+	for in, want := range map[string]string{
+		"USER_NOT_FOUND": "UserNotFound",
+		"user__name":     "UserName",
+		"V2_ERROR":       "V2Error",
+	} {
+		if got := camelCase(in); got != want {
+			t.Errorf("camelCase(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
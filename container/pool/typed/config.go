@@ -0,0 +1,137 @@
+package typed
+
+import (
+	"context"
+	"time"
+
+	"github.com/songzhibin97/gkit/options"
+)
+
+// config Pool 选项
+type config struct {
+	// maxActive: 最大资源数, 如果 <= 0 则无限制
+	maxActive int
+
+	// maxIdle: 最大空闲数
+	maxIdle int
+
+	// maxLifetime: 资源最大存活时间, 如果 <= 0 则不限制
+	maxLifetime time.Duration
+
+	// maxIdleTime: 资源最大空闲时间, 如果 <= 0 则不限制
+	maxIdleTime time.Duration
+
+	// reapInterval: 后台回收空闲资源的间隔, 如果 <= 0 则不回收
+	reapInterval time.Duration
+
+	// wait: 资源耗尽时是否等待
+	wait bool
+
+	// waitTimeout: 等待的最长时间, 如果 <= 0 则以 ctx 为准
+	waitTimeout time.Duration
+
+	// metrics: 指标上报
+	metrics *Metrics
+}
+
+// hookConfig 携带泛型校验钩子的配置
+type hookConfig[T any] struct {
+	config
+
+	// onBorrow: 借出空闲资源前校验, 返回错误时销毁该资源
+	onBorrow func(ctx context.Context, v T) error
+
+	// onReturn: 归还资源前校验, 返回错误时销毁该资源
+	onReturn func(ctx context.Context, v T) error
+}
+
+// base 返回公共配置
+func (c *hookConfig[T]) base() *config {
+	return &c.config
+}
+
+// configurable 非泛型 Option 通过 base 修改公共配置
+type configurable interface {
+	base() *config
+}
+
+// defaultConfig 默认配置
+func defaultConfig() *config {
+	return &config{
+		maxActive:    20,
+		maxIdle:      10,
+		maxIdleTime:  90 * time.Second,
+		reapInterval: 30 * time.Second,
+	}
+}
+
+// Option选项
+
+// SetMaxActive 设置最大资源数, 如果 <= 0 则无限制
+func SetMaxActive(maxActive int) options.Option {
+	return func(c interface{}) {
+		c.(configurable).base().maxActive = maxActive
+	}
+}
+
+// SetMaxIdle 设置最大空闲数
+func SetMaxIdle(maxIdle int) options.Option {
+	return func(c interface{}) {
+		c.(configurable).base().maxIdle = maxIdle
+	}
+}
+
+// SetMaxLifetime 设置资源最大存活时间
+func SetMaxLifetime(maxLifetime time.Duration) options.Option {
+	return func(c interface{}) {
+		c.(configurable).base().maxLifetime = maxLifetime
+	}
+}
+
+// SetMaxIdleTime 设置资源最大空闲时间
+func SetMaxIdleTime(maxIdleTime time.Duration) options.Option {
+	return func(c interface{}) {
+		c.(configurable).base().maxIdleTime = maxIdleTime
+	}
+}
+
+// SetReapInterval 设置后台回收空闲资源的间隔
+func SetReapInterval(reapInterval time.Duration) options.Option {
+	return func(c interface{}) {
+		c.(configurable).base().reapInterval = reapInterval
+	}
+}
+
+// SetWait 设置资源耗尽时等待, waitTimeout <= 0 时以 ctx 为准
+func SetWait(wait bool, waitTimeout time.Duration) options.Option {
+	return func(c interface{}) {
+		conf := c.(configurable).base()
+		conf.wait = wait
+		conf.waitTimeout = waitTimeout
+	}
+}
+
+// SetMetrics 设置指标上报
+func SetMetrics(metrics *Metrics) options.Option {
+	return func(c interface{}) {
+		c.(configurable).base().metrics = metrics
+	}
+}
+
+// SetOnBorrow 设置借出空闲资源前的校验, T 与 Pool 的类型不一致时忽略
+func SetOnBorrow[T any](onBorrow func(ctx context.Context, v T) error) options.Option {
+	return func(c interface{}) {
+		if conf, ok := c.(*hookConfig[T]); ok {
+			conf.onBorrow = onBorrow
+		}
+	}
+}
+
+// SetOnReturn 设置归还资源前的校验, T 与 Pool 的类型不一致时忽略
+func SetOnReturn[T any](onReturn func(ctx context.Context, v T) error) options.Option {
+	return func(c interface{}) {
+		if conf, ok := c.(*hookConfig[T]); ok {
+			conf.onReturn = onReturn
+		}
+	}
+}
@@ -0,0 +1,58 @@
+package typed
+
+import (
+	"context"
+	"net"
+	"time"
+)
+
+func ExampleNew() {
+	// 可供选择配置选项
+
+	// 设置最大资源数, 如果 <= 0 则无限制
+	// SetMaxActive(100)
+
+	// 设置最大空闲数
+	// SetMaxIdle(20)
+
+	// 设置资源最大存活时间与最大空闲时间
+	// SetMaxLifetime(time.Hour)
+	// SetMaxIdleTime(time.Minute)
+
+	// 设置期望等待, 等待者按先来后到获取资源
+	// SetWait(true, time.Second)
+
+	// 设置指标上报
+	// SetMetrics(&Metrics{InUse: inUse, Idle: idle})
+	p := New(
+		func(ctx context.Context) (net.Conn, error) {
+			var d net.Dialer
+			return d.DialContext(ctx, "tcp", "127.0.0.1:6379")
+		},
+		func(c net.Conn) error {
+			return c.Close()
+		},
+		SetMaxActive(100),
+		SetMaxIdleTime(time.Minute),
+		// 借出空闲资源前校验
+		SetOnBorrow(func(_ context.Context, c net.Conn) error {
+			return c.SetDeadline(time.Time{})
+		}),
+	)
+
+	r, err := p.Get(context.TODO())
+	if err != nil {
+		// 处理错误
+		return
+	}
+	// r.Value() 即为 net.Conn, 无需类型断言
+	_ = r.Value()
+
+	// forceClose: true 直接销毁资源, 否则归还到空闲队列
+	_ = p.Put(context.TODO(), r, false)
+
+	// 统计信息
+	_ = p.Stats()
+
+	_ = p.Shutdown()
+}
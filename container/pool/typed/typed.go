@@ -0,0 +1,470 @@
+package typed
+
+import (
+	"container/list"
+	"context"
+	"sync"
+	"time"
+
+	"github.com/songzhibin97/gkit/container/pool"
+	"github.com/songzhibin97/gkit/metrics"
+	"github.com/songzhibin97/gkit/options"
+	"github.com/songzhibin97/gkit/timeout"
+)
+
+// package typed: 泛型资源池
+// 借出/归还校验, 最大存活时间与最大空闲时间, 后台回收空闲资源,
+// 等待者按 FIFO 顺序获得资源, 并提供可导出到 metrics 的统计信息
+
+// nowFunc: 返回当前时间
+var nowFunc = time.Now
+
+// Resource 池中的资源
+type Resource[T any] struct {
+	value T
+
+	// createdAt: 创建时间
+	createdAt time.Time
+
+	// idleAt: 最近一次归还时间
+	idleAt time.Time
+}
+
+// Value 返回资源
+func (r *Resource[T]) Value() T {
+	return r.value
+}
+
+// CreatedAt 返回资源的创建时间
+func (r *Resource[T]) CreatedAt() time.Time {
+	return r.createdAt
+}
+
+// Stats 资源池统计信息
+type Stats struct {
+	// MaxActive: 最大资源数, 0 表示无限制
+	MaxActive int
+
+	// Active: 已创建(包含创建中)的资源数
+	Active int
+
+	// InUse: 借出的资源数
+	InUse int
+
+	// Idle: 空闲资源数
+	Idle int
+
+	// Waiting: 正在等待的调用方数量
+	Waiting int
+
+	// WaitCount: 累计等待次数
+	WaitCount int64
+
+	// WaitDuration: 累计等待时间
+	WaitDuration time.Duration
+
+	// MaxIdleTimeClosed: 因超过最大空闲时间关闭的资源数
+	MaxIdleTimeClosed int64
+
+	// MaxLifetimeClosed: 因超过最大存活时间关闭的资源数
+	MaxLifetimeClosed int64
+}
+
+// Metrics 资源池指标, 字段为 nil 时不上报
+type Metrics struct {
+	// InUse: 借出的资源数
+	InUse metrics.Gauge
+
+	// Idle: 空闲资源数
+	Idle metrics.Gauge
+
+	// WaitCount: 等待次数
+	WaitCount metrics.Counter
+
+	// WaitDuration: 每次等待的时间, 单位秒
+	WaitDuration metrics.Observer
+}
+
+// Pool 泛型资源池
+type Pool[T any] struct {
+	// create: 创建资源
+	create func(ctx context.Context) (T, error)
+
+	// destroy: 销毁资源
+	destroy func(T) error
+
+	// mu: 互斥锁, 保护以下字段
+	mu sync.Mutex
+
+	conf *hookConfig[T]
+
+	// active: 已创建(包含创建中)的资源数
+	active int
+
+	// idles: 空闲资源, 尾部为最近归还的资源
+	idles []*Resource[T]
+
+	// waiters: 等待队列, 元素为 chan *Resource[T]
+	// 收到 nil 表示获得一个创建资源的名额
+	waiters list.List
+
+	closed bool
+
+	// stop: 关闭时 close, 唤醒等待者并停止后台回收
+	stop chan struct{}
+
+	// done: 后台回收退出后 close
+	done chan struct{}
+
+	waitCount         int64
+	waitDuration      time.Duration
+	maxIdleTimeClosed int64
+	maxLifetimeClosed int64
+}
+
+// Get 借出资源
+// 优先复用最近归还的空闲资源, 资源耗尽时根据配置等待或返回 pool.ErrPoolExhausted
+func (p *Pool[T]) Get(ctx context.Context) (*Resource[T], error) {
+	for {
+		r, err := p.acquire(ctx)
+		if err != nil {
+			return nil, err
+		}
+		if r == nil {
+			return p.createResource(ctx)
+		}
+		if p.conf.onBorrow != nil {
+			if err = p.conf.onBorrow(ctx, r.value); err != nil {
+				// 校验失败的资源直接销毁, 重新获取
+				p.mu.Lock()
+				p.releaseLocked()
+				p.mu.Unlock()
+				_ = p.destroy(r.value)
+				continue
+			}
+		}
+		p.report()
+		return r, nil
+	}
+}
+
+// acquire 取出空闲资源, 返回 nil 表示调用方获得了创建资源的名额
+func (p *Pool[T]) acquire(ctx context.Context) (*Resource[T], error) {
+	p.mu.Lock()
+	if p.closed {
+		p.mu.Unlock()
+		return nil, pool.ErrPoolClosed
+	}
+	var expired []*Resource[T]
+	defer func() {
+		for _, r := range expired {
+			_ = p.destroy(r.value)
+		}
+	}()
+
+	now := nowFunc()
+	for n := len(p.idles); n > 0; n = len(p.idles) {
+		r := p.idles[n-1]
+		p.idles[n-1] = nil
+		p.idles = p.idles[:n-1]
+		if p.expiredLocked(r, now) {
+			p.active--
+			expired = append(expired, r)
+			continue
+		}
+		p.mu.Unlock()
+		return r, nil
+	}
+	if p.conf.maxActive <= 0 || p.active < p.conf.maxActive {
+		p.active++
+		p.mu.Unlock()
+		return nil, nil
+	}
+	if !p.conf.wait {
+		p.mu.Unlock()
+		return nil, pool.ErrPoolExhausted
+	}
+
+	// 加入等待队列, 由 Put 或资源销毁按 FIFO 顺序直接移交
+	ch := make(chan *Resource[T], 1)
+	e := p.waiters.PushBack(ch)
+	p.waitCount++
+	waitTimeout := p.conf.waitTimeout
+	p.mu.Unlock()
+	if m := p.conf.metrics; m != nil && m.WaitCount != nil {
+		m.WaitCount.Inc()
+	}
+
+	start := nowFunc()
+	waitCtx, cancel := ctx, context.CancelFunc(func() {})
+	if waitTimeout > 0 {
+		_, waitCtx, cancel = timeout.Shrink(ctx, waitTimeout)
+	}
+	defer cancel()
+
+	var err error
+	select {
+	case r := <-ch:
+		p.observeWait(start)
+		return r, nil
+	case <-waitCtx.Done():
+		err = waitCtx.Err()
+	case <-p.stop:
+		err = pool.ErrPoolClosed
+	}
+
+	p.mu.Lock()
+	if e.Value != nil {
+		// 仍在等待队列中, 直接离开
+		p.waiters.Remove(e)
+		e.Value = nil
+		p.mu.Unlock()
+		p.observeWait(start)
+		return nil, err
+	}
+	p.mu.Unlock()
+	// 已被移交, 归还移交的资源或名额, ctx 已结束, 不能用于归还
+	if r := <-ch; r != nil {
+		_ = p.Put(context.Background(), r, false)
+	} else {
+		p.mu.Lock()
+		p.releaseLocked()
+		p.mu.Unlock()
+	}
+	p.observeWait(start)
+	return nil, err
+}
+
+// createResource 使用名额创建资源
+func (p *Pool[T]) createResource(ctx context.Context) (*Resource[T], error) {
+	if p.create == nil {
+		p.mu.Lock()
+		p.releaseLocked()
+		p.mu.Unlock()
+		return nil, pool.ErrPoolNewFuncIsNull
+	}
+	v, err := p.create(ctx)
+	if err != nil {
+		p.mu.Lock()
+		p.releaseLocked()
+		p.mu.Unlock()
+		return nil, err
+	}
+	p.report()
+	return &Resource[T]{value: v, createdAt: nowFunc()}, nil
+}
+
+// Put 归还资源
+// forceClose 为 true、归还校验失败或超过最大存活时间时销毁资源
+func (p *Pool[T]) Put(ctx context.Context, r *Resource[T], forceClose bool) error {
+	if r == nil {
+		return nil
+	}
+	destroy := forceClose
+	if !destroy && p.conf.onReturn != nil && p.conf.onReturn(ctx, r.value) != nil {
+		destroy = true
+	}
+
+	p.mu.Lock()
+	now := nowFunc()
+	if !destroy && p.conf.maxLifetime > 0 && now.Sub(r.createdAt) >= p.conf.maxLifetime {
+		p.maxLifetimeClosed++
+		destroy = true
+	}
+	if !destroy && !p.closed {
+		r.idleAt = now
+		if ch := p.popWaiterLocked(); ch != nil {
+			ch <- r
+			p.mu.Unlock()
+			return nil
+		}
+		if len(p.idles) < p.conf.maxIdle {
+			p.idles = append(p.idles, r)
+			p.mu.Unlock()
+			p.report()
+			return nil
+		}
+	}
+	p.releaseLocked()
+	p.mu.Unlock()
+	p.report()
+	return p.destroy(r.value)
+}
+
+// Stats 返回统计信息
+func (p *Pool[T]) Stats() Stats {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return Stats{
+		MaxActive:         p.conf.maxActive,
+		Active:            p.active,
+		InUse:             p.active - len(p.idles),
+		Idle:              len(p.idles),
+		Waiting:           p.waiters.Len(),
+		WaitCount:         p.waitCount,
+		WaitDuration:      p.waitDuration,
+		MaxIdleTimeClosed: p.maxIdleTimeClosed,
+		MaxLifetimeClosed: p.maxLifetimeClosed,
+	}
+}
+
+// Shutdown 关闭资源池, 销毁所有空闲资源并唤醒等待者
+// 借出的资源在 Put 时销毁
+func (p *Pool[T]) Shutdown() error {
+	p.mu.Lock()
+	if p.closed {
+		p.mu.Unlock()
+		return pool.ErrPoolClosed
+	}
+	p.closed = true
+	close(p.stop)
+	idles := p.idles
+	p.idles = nil
+	p.active -= len(idles)
+	p.mu.Unlock()
+
+	var err error
+	for _, r := range idles {
+		if dErr := p.destroy(r.value); dErr != nil && err == nil {
+			err = dErr
+		}
+	}
+	<-p.done
+	p.report()
+	return err
+}
+
+// expiredLocked 判断空闲资源是否超过最大存活时间或最大空闲时间
+func (p *Pool[T]) expiredLocked(r *Resource[T], now time.Time) bool {
+	if p.conf.maxLifetime > 0 && now.Sub(r.createdAt) >= p.conf.maxLifetime {
+		p.maxLifetimeClosed++
+		return true
+	}
+	if p.conf.maxIdleTime > 0 && now.Sub(r.idleAt) >= p.conf.maxIdleTime {
+		p.maxIdleTimeClosed++
+		return true
+	}
+	return false
+}
+
+// popWaiterLocked 弹出最早的等待者
+func (p *Pool[T]) popWaiterLocked() chan *Resource[T] {
+	e := p.waiters.Front()
+	if e == nil {
+		return nil
+	}
+	ch := p.waiters.Remove(e).(chan *Resource[T])
+	e.Value = nil
+	return ch
+}
+
+// releaseLocked 释放一个资源名额, 有等待者时直接移交
+func (p *Pool[T]) releaseLocked() {
+	if !p.closed {
+		if ch := p.popWaiterLocked(); ch != nil {
+			ch <- nil
+			return
+		}
+	}
+	p.active--
+}
+
+// observeWait 记录等待时间
+func (p *Pool[T]) observeWait(start time.Time) {
+	d := nowFunc().Sub(start)
+	p.mu.Lock()
+	p.waitDuration += d
+	p.mu.Unlock()
+	if m := p.conf.metrics; m != nil && m.WaitDuration != nil {
+		m.WaitDuration.Observe(d.Seconds())
+	}
+}
+
+// report 上报 InUse 与 Idle 指标
+func (p *Pool[T]) report() {
+	m := p.conf.metrics
+	if m == nil || (m.InUse == nil && m.Idle == nil) {
+		return
+	}
+	stats := p.Stats()
+	if m.InUse != nil {
+		m.InUse.Set(float64(stats.InUse))
+	}
+	if m.Idle != nil {
+		m.Idle.Set(float64(stats.Idle))
+	}
+}
+
+// reaper 后台定时回收过期的空闲资源
+func (p *Pool[T]) reaper() {
+	defer close(p.done)
+	interval := p.conf.reapInterval
+	if interval <= 0 {
+		<-p.stop
+		return
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			p.reap()
+		case <-p.stop:
+			return
+		}
+	}
+}
+
+// reap 回收过期的空闲资源
+func (p *Pool[T]) reap() {
+	p.mu.Lock()
+	now := nowFunc()
+	var expired []*Resource[T]
+	idles := p.idles[:0]
+	for _, r := range p.idles {
+		if p.expiredLocked(r, now) {
+			expired = append(expired, r)
+			continue
+		}
+		idles = append(idles, r)
+	}
+	for i := len(idles); i < len(p.idles); i++ {
+		p.idles[i] = nil
+	}
+	p.idles = idles
+	for range expired {
+		p.releaseLocked()
+	}
+	p.mu.Unlock()
+
+	for _, r := range expired {
+		_ = p.destroy(r.value)
+	}
+	if len(expired) > 0 {
+		p.report()
+	}
+}
+
+// New 实例化资源池
+// create 创建资源, destroy 销毁资源(可以为 nil)
+func New[T any](create func(ctx context.Context) (T, error), destroy func(T) error, options ...options.Option) *Pool[T] {
+	if destroy == nil {
+		destroy = func(T) error { return nil }
+	}
+	conf := &hookConfig[T]{config: *defaultConfig()}
+	for _, option := range options {
+		option(conf)
+	}
+	if conf.maxIdle < 0 {
+		conf.maxIdle = 0
+	}
+	p := &Pool[T]{
+		create:  create,
+		destroy: destroy,
+		conf:    conf,
+		stop:    make(chan struct{}),
+		done:    make(chan struct{}),
+	}
+	go p.reaper()
+	return p
+}
@@ -0,0 +1,237 @@
+package typed
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/songzhibin97/gkit/container/pool"
+	"github.com/songzhibin97/gkit/metrics"
+	"github.com/stretchr/testify/assert"
+)
+
+type conn struct {
+	id     int64
+	closed int32
+}
+
+type factory struct {
+	created   int64
+	destroyed int64
+}
+
+func (f *factory) create(context.Context) (*conn, error) {
+	return &conn{id: atomic.AddInt64(&f.created, 1)}, nil
+}
+
+func (f *factory) destroy(c *conn) error {
+	atomic.StoreInt32(&c.closed, 1)
+	atomic.AddInt64(&f.destroyed, 1)
+	return nil
+}
+
+type gauge struct {
+	sync.Mutex
+	value float64
+}
+
+func (g *gauge) With(...string) metrics.Gauge { return g }
+func (g *gauge) Set(value float64)            { g.Lock(); g.value = value; g.Unlock() }
+func (g *gauge) Add(delta float64)            { g.Lock(); g.value += delta; g.Unlock() }
+func (g *gauge) Sub(delta float64)            { g.Lock(); g.value -= delta; g.Unlock() }
+func (g *gauge) get() float64                 { g.Lock(); defer g.Unlock(); return g.value }
+
+type counter struct{ value int64 }
+
+func (c *counter) With(...string) metrics.Counter { return c }
+func (c *counter) Inc()                           { atomic.AddInt64(&c.value, 1) }
+func (c *counter) Add(delta float64)              { atomic.AddInt64(&c.value, int64(delta)) }
+
+func TestPoolReuse(t *testing.T) {
+	f := &factory{}
+	p := New(f.create, f.destroy, SetMaxActive(2), SetMaxIdle(1))
+	defer p.Shutdown()
+
+	r1, err := p.Get(context.TODO())
+	assert.NoError(t, err)
+	r2, err := p.Get(context.TODO())
+	assert.NoError(t, err)
+	_, err = p.Get(context.TODO())
+	assert.Equal(t, pool.ErrPoolExhausted, err)
+
+	assert.NoError(t, p.Put(context.TODO(), r1, false))
+	// 超过最大空闲数的资源被销毁
+	assert.NoError(t, p.Put(context.TODO(), r2, false))
+	assert.Equal(t, int32(1), atomic.LoadInt32(&r2.Value().closed))
+	assert.Equal(t, Stats{MaxActive: 2, Active: 1, Idle: 1}, p.Stats())
+
+	r3, err := p.Get(context.TODO())
+	assert.NoError(t, err)
+	assert.Same(t, r1.Value(), r3.Value())
+	assert.Equal(t, 1, p.Stats().InUse)
+}
+
+func TestPoolValidation(t *testing.T) {
+	f := &factory{}
+	errInvalid := errors.New("invalid")
+	p := New(f.create, f.destroy,
+		SetOnBorrow(func(_ context.Context, c *conn) error {
+			if c.id == 1 {
+				return errInvalid
+			}
+			return nil
+		}),
+		SetOnReturn(func(_ context.Context, c *conn) error {
+			if c.id == 3 {
+				return errInvalid
+			}
+			return nil
+		}),
+	)
+	defer p.Shutdown()
+
+	r1, err := p.Get(context.TODO())
+	assert.NoError(t, err)
+	assert.NoError(t, p.Put(context.TODO(), r1, false))
+
+	// 空闲资源 1 借出校验失败被销毁, 重新创建资源 2
+	r2, err := p.Get(context.TODO())
+	assert.NoError(t, err)
+	assert.Equal(t, int64(2), r2.Value().id)
+	assert.Equal(t, int32(1), atomic.LoadInt32(&r1.Value().closed))
+
+	// 资源 3 归还校验失败被销毁
+	r3, err := p.Get(context.TODO())
+	assert.NoError(t, err)
+	assert.Equal(t, int64(3), r3.Value().id)
+	assert.NoError(t, p.Put(context.TODO(), r3, false))
+	assert.Equal(t, int32(1), atomic.LoadInt32(&r3.Value().closed))
+
+	assert.NoError(t, p.Put(context.TODO(), r2, false))
+	assert.Equal(t, Stats{MaxActive: 20, Active: 1, Idle: 1}, p.Stats())
+}
+
+func TestPoolHookTypeMismatch(t *testing.T) {
+	f := &factory{}
+	// 钩子的类型与 Pool 不一致时被忽略
+	p := New(f.create, f.destroy,
+		SetOnBorrow(func(context.Context, string) error { return errors.New("invalid") }),
+		SetOnReturn(func(context.Context, int) error { return errors.New("invalid") }),
+	)
+	defer p.Shutdown()
+
+	r1, err := p.Get(context.TODO())
+	assert.NoError(t, err)
+	assert.NoError(t, p.Put(context.TODO(), r1, false))
+	r2, err := p.Get(context.TODO())
+	assert.NoError(t, err)
+	assert.Same(t, r1.Value(), r2.Value())
+}
+
+func TestPoolLifetime(t *testing.T) {
+	defer func() { nowFunc = time.Now }()
+	now := time.Now()
+	nowFunc = func() time.Time { return now }
+
+	f := &factory{}
+	p := New(f.create, f.destroy, SetMaxLifetime(time.Minute), SetMaxIdleTime(time.Second), SetReapInterval(0))
+	defer p.Shutdown()
+
+	r1, _ := p.Get(context.TODO())
+	r2, _ := p.Get(context.TODO())
+	assert.NoError(t, p.Put(context.TODO(), r1, false))
+	now = now.Add(time.Second)
+	r3, err := p.Get(context.TODO())
+	assert.NoError(t, err)
+	assert.Equal(t, int64(3), r3.Value().id)
+	assert.Equal(t, int64(1), p.Stats().MaxIdleTimeClosed)
+
+	now = now.Add(time.Minute)
+	assert.NoError(t, p.Put(context.TODO(), r2, false))
+	assert.Equal(t, int32(1), atomic.LoadInt32(&r2.Value().closed))
+	assert.Equal(t, int64(1), p.Stats().MaxLifetimeClosed)
+}
+
+func TestPoolReap(t *testing.T) {
+	f := &factory{}
+	p := New(f.create, f.destroy, SetMaxIdleTime(time.Millisecond), SetReapInterval(time.Millisecond*5))
+	defer p.Shutdown()
+
+	r, _ := p.Get(context.TODO())
+	assert.NoError(t, p.Put(context.TODO(), r, false))
+	assert.Eventually(t, func() bool {
+		return atomic.LoadInt32(&r.Value().closed) == 1
+	}, time.Second, time.Millisecond*5)
+	assert.Equal(t, Stats{MaxActive: 20, MaxIdleTimeClosed: 1}, p.Stats())
+}
+
+func TestPoolWaitFIFO(t *testing.T) {
+	f := &factory{}
+	waitCount := &counter{}
+	inUse := &gauge{}
+	p := New(f.create, f.destroy, SetMaxActive(1), SetWait(true, 0),
+		SetMetrics(&Metrics{InUse: inUse, WaitCount: waitCount}))
+	defer p.Shutdown()
+
+	r, err := p.Get(context.TODO())
+	assert.NoError(t, err)
+	assert.Equal(t, float64(1), inUse.get())
+
+	order := make(chan int, 3)
+	for i := 0; i < 3; i++ {
+		i := i
+		go func() {
+			r, err := p.Get(context.TODO())
+			if err != nil {
+				return
+			}
+			order <- i
+			_ = p.Put(context.TODO(), r, false)
+		}()
+		assert.Eventually(t, func() bool { return p.Stats().Waiting == i+1 }, time.Second, time.Millisecond)
+	}
+	assert.NoError(t, p.Put(context.TODO(), r, false))
+	for i := 0; i < 3; i++ {
+		assert.Equal(t, i, <-order)
+	}
+	stats := p.Stats()
+	assert.Equal(t, int64(3), stats.WaitCount)
+	assert.Equal(t, int64(3), atomic.LoadInt64(&waitCount.value))
+	assert.Equal(t, int64(1), atomic.LoadInt64(&f.created))
+}
+
+func TestPoolWaitTimeout(t *testing.T) {
+	f := &factory{}
+	p := New(f.create, f.destroy, SetMaxActive(1), SetWait(true, time.Millisecond*10))
+
+	r, err := p.Get(context.TODO())
+	assert.NoError(t, err)
+	_, err = p.Get(context.TODO())
+	assert.Equal(t, context.DeadlineExceeded, err)
+	assert.Equal(t, 0, p.Stats().Waiting)
+	assert.NoError(t, p.Put(context.TODO(), r, false))
+	assert.NoError(t, p.Shutdown())
+}
+
+func TestPoolShutdownWakesWaiters(t *testing.T) {
+	f := &factory{}
+	p := New(f.create, f.destroy, SetMaxActive(1), SetWait(true, 0))
+
+	r, err := p.Get(context.TODO())
+	assert.NoError(t, err)
+	result := make(chan error, 1)
+	go func() {
+		_, err := p.Get(context.Background())
+		result <- err
+	}()
+	assert.Eventually(t, func() bool { return p.Stats().Waiting == 1 }, time.Second, time.Millisecond)
+	assert.NoError(t, p.Shutdown())
+	assert.Equal(t, pool.ErrPoolClosed, <-result)
+
+	assert.NoError(t, p.Put(context.TODO(), r, false))
+	assert.Equal(t, int32(1), atomic.LoadInt32(&r.Value().closed))
+	assert.Equal(t, 0, p.Stats().Active)
+}
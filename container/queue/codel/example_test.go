@@ -45,3 +45,21 @@ func ExampleQueue_Pop() {
 	// 出队,没有请求则会阻塞
 	queue.Pop()
 }
+
+func ExampleNewPriorityQueue() {
+	// 可供选择配置选项
+	// 设置缓冲区容量
+	// SetCapacity(1024)
+	// 关闭过载时的后进先出
+	// SetLIFO(false)
+	priorityQueue := NewPriorityQueue(SetTarget(40), SetInternal(1000))
+
+	// 关键请求不会被丢弃, 未设置优先级时为 PriorityNormal
+	ctx := WithPriority(context.TODO(), PriorityCritical)
+	if err := priorityQueue.Push(ctx); err != nil {
+		// todo 处理过载保护错误
+	}
+
+	// 各优先级状态信息
+	_ = priorityQueue.Stat().Priorities[PriorityCritical]
+}
@@ -0,0 +1,223 @@
+package codel
+
+import (
+	"container/list"
+	"context"
+	"sync"
+
+	"github.com/songzhibin97/gkit/options"
+	"github.com/songzhibin97/gkit/overload/bbr"
+)
+
+// Priority 请求优先级, 数值越小优先级越高
+type Priority uint8
+
+const (
+	// PriorityCritical 关键请求, 最先出队且不会被 CoDel 丢弃
+	PriorityCritical Priority = iota
+	PriorityHigh
+	PriorityNormal
+	PriorityLow
+
+	priorityCount
+)
+
+type priorityContextKey struct{}
+
+// WithPriority 返回携带请求优先级的 ctx, 未设置时为 PriorityNormal
+func WithPriority(ctx context.Context, priority Priority) context.Context {
+	return context.WithValue(ctx, priorityContextKey{}, priority)
+}
+
+// priorityFromContext 获取 ctx 中的优先级, 非法值按 PriorityLow 处理
+func priorityFromContext(ctx context.Context) Priority {
+	priority, ok := ctx.Value(priorityContextKey{}).(Priority)
+	if !ok {
+		return PriorityNormal
+	}
+	if priority >= priorityCount {
+		return PriorityLow
+	}
+	return priority
+}
+
+// PriorityStat 优先级队列状态信息
+type PriorityStat struct {
+	// LIFO: 当前是否处于后进先出模式
+	LIFO bool
+
+	// Packets: 全部优先级排队的请求数
+	Packets int64
+
+	// Priorities: 按 Priority 下标索引的各优先级 CoDel 状态
+	Priorities []Stat
+}
+
+// class 单个优先级的排队请求与 CoDel 状态
+type class struct {
+	// codel: 仅复用 Queue 的 judge 状态, 不使用其 packets
+	codel *Queue
+
+	packets *list.List
+}
+
+// PriorityQueue 带优先级的 CoDel 缓冲队列
+// 高优先级请求先出队, 每个优先级独立维护 CoDel 状态;
+// 队列持续非空超过 internal 时视为过载, 切换为后进先出(adaptive LIFO),
+// 同时排队超过 target 的旧请求直接丢弃
+type PriorityQueue struct {
+	mux  sync.Mutex
+	conf *config
+
+	classes [priorityCount]*class
+
+	// count: 全部优先级排队的请求数
+	count int64
+
+	// emptyTime: 队列最近一次为空的时间
+	emptyTime int64
+}
+
+// Reload 重新加载配置
+func (q *PriorityQueue) Reload(c *config) {
+	if c == nil || c.internal <= 0 || c.target <= 0 {
+		return
+	}
+	q.mux.Lock()
+	defer q.mux.Unlock()
+	q.conf = c
+	for _, cls := range q.classes {
+		cls.codel.Reload(c)
+	}
+}
+
+// Stat 返回优先级队列状态信息
+func (q *PriorityQueue) Stat() PriorityStat {
+	q.mux.Lock()
+	defer q.mux.Unlock()
+	stat := PriorityStat{
+		LIFO:       q.overloaded(nowMillis()),
+		Packets:    q.count,
+		Priorities: make([]Stat, 0, len(q.classes)),
+	}
+	for _, cls := range q.classes {
+		s := cls.codel.Stat()
+		s.Packets = int64(cls.packets.Len())
+		stat.Priorities = append(stat.Priorities, s)
+	}
+	return stat
+}
+
+// Push 请求按 ctx 中的优先级进入队列
+// 返回 nil 表示请求被放行, 缓冲区已满或被 CoDel 丢弃时返回 bbr.LimitExceed
+func (q *PriorityQueue) Push(ctx context.Context) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	cls := q.classes[priorityFromContext(ctx)]
+	r := packet{
+		ch: make(chan bool, 1),
+		ts: nowMillis(),
+	}
+
+	q.mux.Lock()
+	if q.count >= int64(q.conf.capacity) {
+		q.mux.Unlock()
+		return bbr.LimitExceed
+	}
+	if q.count == 0 {
+		q.emptyTime = r.ts
+	}
+	e := cls.packets.PushBack(r)
+	q.count++
+	q.mux.Unlock()
+
+	select {
+	case drop := <-r.ch:
+		if drop {
+			return bbr.LimitExceed
+		}
+		return nil
+	case <-ctx.Done():
+		// 请求仍在排队时将其移出, 已被 Pop 决策的请求由缓冲 channel 吸收
+		q.mux.Lock()
+		n := cls.packets.Len()
+		cls.packets.Remove(e)
+		if cls.packets.Len() < n {
+			q.count--
+			q.markEmpty(nowMillis())
+		}
+		q.mux.Unlock()
+		return ctx.Err()
+	}
+}
+
+// Pop 按优先级弹出请求, 直到放行一个请求或队列为空
+func (q *PriorityQueue) Pop() {
+	q.mux.Lock()
+	defer q.mux.Unlock()
+	now := nowMillis()
+	lifo := q.overloaded(now)
+	for priority, cls := range q.classes {
+		critical := Priority(priority) == PriorityCritical
+		if lifo && !critical {
+			q.expire(cls, now)
+		}
+		for cls.packets.Len() > 0 {
+			e := cls.packets.Front()
+			if lifo {
+				e = cls.packets.Back()
+			}
+			p := cls.packets.Remove(e).(packet)
+			q.count--
+			drop := !critical && cls.codel.judge(p)
+			p.ch <- drop
+			if !drop {
+				q.markEmpty(now)
+				return
+			}
+		}
+	}
+	q.markEmpty(now)
+}
+
+// expire 过载时丢弃队头排队超过 target 的请求
+func (q *PriorityQueue) expire(cls *class, now int64) {
+	for e := cls.packets.Front(); e != nil; e = cls.packets.Front() {
+		p := e.Value.(packet)
+		if now-p.ts < q.conf.target {
+			return
+		}
+		cls.packets.Remove(e)
+		q.count--
+		p.ch <- true
+	}
+}
+
+// overloaded 队列持续非空超过 internal 视为过载
+func (q *PriorityQueue) overloaded(now int64) bool {
+	return q.conf.lifo && q.count > 0 && now-q.emptyTime >= q.conf.internal
+}
+
+// markEmpty 队列为空时刷新 emptyTime
+func (q *PriorityQueue) markEmpty(now int64) {
+	if q.count == 0 {
+		q.emptyTime = now
+	}
+}
+
+// NewPriorityQueue 实例化带优先级的 CoDel Queue
+func NewPriorityQueue(options ...options.Option) *PriorityQueue {
+	conf := newConfig(options...)
+	q := &PriorityQueue{
+		conf:      conf,
+		emptyTime: nowMillis(),
+	}
+	for i := range q.classes {
+		q.classes[i] = &class{
+			codel:   &Queue{conf: conf},
+			packets: list.New(),
+		}
+	}
+	return q
+}
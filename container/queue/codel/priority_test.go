@@ -0,0 +1,144 @@
+package codel
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/songzhibin97/gkit/overload/bbr"
+)
+
+func waitForPriorityPackets(t *testing.T, q *PriorityQueue, want int64) {
+	t.Helper()
+	deadline := time.Now().Add(250 * time.Millisecond)
+	for time.Now().Before(deadline) {
+		if q.Stat().Packets == want {
+			return
+		}
+		time.Sleep(time.Millisecond)
+	}
+	t.Fatalf("queued packets = %d, want %d", q.Stat().Packets, want)
+}
+
+func pushWithPriority(q *PriorityQueue, priority Priority) <-chan error {
+	result := make(chan error, 1)
+	go func() { result <- q.Push(WithPriority(context.Background(), priority)) }()
+	return result
+}
+
+func TestPriorityQueuePopOrder(t *testing.T) {
+	q := NewPriorityQueue(SetTarget(1<<60), SetInternal(1<<60))
+	low := pushWithPriority(q, PriorityLow)
+	waitForPriorityPackets(t, q, 1)
+	critical := pushWithPriority(q, PriorityCritical)
+	waitForPriorityPackets(t, q, 2)
+
+	stat := q.Stat()
+	if stat.Priorities[PriorityCritical].Packets != 1 || stat.Priorities[PriorityLow].Packets != 1 {
+		t.Fatalf("per-priority packets = %+v, want one critical and one low", stat.Priorities)
+	}
+
+	q.Pop()
+	if err := awaitPushResult(t, critical); err != nil {
+		t.Fatalf("critical Push returned %v, want nil", err)
+	}
+	select {
+	case err := <-low:
+		t.Fatalf("low Push returned %v before its Pop", err)
+	default:
+	}
+	q.Pop()
+	if err := awaitPushResult(t, low); err != nil {
+		t.Fatalf("low Push returned %v, want nil", err)
+	}
+	if got := q.Stat().Packets; got != 0 {
+		t.Fatalf("queued packets = %d, want 0", got)
+	}
+}
+
+func TestPriorityQueueCapacity(t *testing.T) {
+	q := NewPriorityQueue(SetCapacity(1))
+	first := pushWithPriority(q, PriorityNormal)
+	waitForPriorityPackets(t, q, 1)
+	if err := q.Push(context.Background()); !errors.Is(err, bbr.LimitExceed) {
+		t.Fatalf("Push on full queue = %v, want bbr.LimitExceed", err)
+	}
+	q.Pop()
+	if err := awaitPushResult(t, first); err != nil {
+		t.Fatalf("first Push returned %v, want nil", err)
+	}
+}
+
+func TestPriorityQueueCanceledPushLeavesQueue(t *testing.T) {
+	q := NewPriorityQueue()
+	ctx, cancel := context.WithCancel(context.Background())
+	result := make(chan error, 1)
+	go func() { result <- q.Push(ctx) }()
+	waitForPriorityPackets(t, q, 1)
+	cancel()
+	if err := awaitPushResult(t, result); !errors.Is(err, context.Canceled) {
+		t.Fatalf("Push error = %v, want context.Canceled", err)
+	}
+	if got := q.Stat().Packets; got != 0 {
+		t.Fatalf("queued packets after cancellation = %d, want 0", got)
+	}
+}
+
+func TestPriorityQueueAdaptiveLIFO(t *testing.T) {
+	q := NewPriorityQueue(SetTarget(1<<60), SetInternal(1))
+	first := pushWithPriority(q, PriorityNormal)
+	waitForPriorityPackets(t, q, 1)
+	second := pushWithPriority(q, PriorityNormal)
+	waitForPriorityPackets(t, q, 2)
+	time.Sleep(5 * time.Millisecond)
+
+	if !q.Stat().LIFO {
+		t.Fatal("queue non-empty for longer than internal is not in LIFO mode")
+	}
+	q.Pop()
+	if err := awaitPushResult(t, second); err != nil {
+		t.Fatalf("newest Push returned %v, want nil", err)
+	}
+	q.Pop()
+	if err := awaitPushResult(t, first); err != nil {
+		t.Fatalf("oldest Push returned %v, want nil", err)
+	}
+	if q.Stat().LIFO {
+		t.Fatal("empty queue still in LIFO mode")
+	}
+}
+
+func TestPriorityQueueLIFOExpiresStalePackets(t *testing.T) {
+	q := NewPriorityQueue(SetTarget(1), SetInternal(1))
+	stale := pushWithPriority(q, PriorityNormal)
+	critical := pushWithPriority(q, PriorityCritical)
+	waitForPriorityPackets(t, q, 2)
+	time.Sleep(5 * time.Millisecond)
+
+	q.Pop()
+	if err := awaitPushResult(t, critical); err != nil {
+		t.Fatalf("critical Push returned %v, want nil", err)
+	}
+	q.Pop()
+	if err := awaitPushResult(t, stale); !errors.Is(err, bbr.LimitExceed) {
+		t.Fatalf("stale Push returned %v, want bbr.LimitExceed", err)
+	}
+}
+
+func TestPriorityQueueCriticalBypassesDrop(t *testing.T) {
+	q := NewPriorityQueue(SetTarget(1), SetInternal(1), SetLIFO(false))
+	cls := q.classes[PriorityCritical]
+	for i := 0; i < 10; i++ {
+		result := pushWithPriority(q, PriorityCritical)
+		waitForPriorityPackets(t, q, 1)
+		time.Sleep(3 * time.Millisecond)
+		q.Pop()
+		if err := awaitPushResult(t, result); err != nil {
+			t.Fatalf("critical Push %d returned %v, want nil", i, err)
+		}
+	}
+	if cls.codel.Stat().Dropping {
+		t.Fatal("critical class entered dropping state")
+	}
+}
@@ -21,6 +21,12 @@ type config struct {
 
 	// internal: 滑动最小时间窗口宽度(默认是500ms)
 	internal int64
+
+	// capacity: 缓冲区容量(默认是2048)
+	capacity int
+
+	// lifo: 过载时是否切换为后进先出(默认开启, 仅 PriorityQueue 生效)
+	lifo bool
 }
 
 // Stat CoDel 状态信息
@@ -82,7 +88,7 @@ func (q *Queue) Push(ctx context.Context) (err error) {
 
 	r := packet{
 		ch: make(chan bool, 1),
-		ts: time.Now().UnixNano() / int64(time.Millisecond),
+		ts: nowMillis(),
 	}
 	select {
 	case q.packets <- r:
@@ -130,7 +136,7 @@ func (q *Queue) controlLaw(now int64) int64 {
 // judge 决定数据包是否丢弃
 // Core: CoDel
 func (q *Queue) judge(p packet) (drop bool) {
-	now := time.Now().UnixNano() / int64(time.Millisecond)
+	now := nowMillis()
 	sojurn := now - p.ts
 	q.mux.Lock()
 	defer q.mux.Unlock()
@@ -171,6 +177,11 @@ func (q *Queue) judge(p packet) (drop bool) {
 	return
 }
 
+// nowMillis 当前毫秒时间戳
+func nowMillis() int64 {
+	return time.Now().UnixNano() / int64(time.Millisecond)
+}
+
 // Default 默认配置CoDel Queue
 func Default() *Queue {
 	return NewQueue()
@@ -181,6 +192,8 @@ func defaultConfig() *config {
 	return &config{
 		target:   20,
 		internal: 500,
+		capacity: 2048,
+		lifo:     true,
 	}
 }
 
@@ -200,18 +213,40 @@ func SetInternal(internal int64) options.Option {
 	}
 }
 
-// NewQueue 实例化 CoDel Queue
-func NewQueue(options ...options.Option) *Queue {
-	// new pool
-	q := &Queue{
-		packets: make(chan packet, 2048),
-		conf:    defaultConfig(),
+// SetCapacity 设置缓冲区容量
+func SetCapacity(capacity int) options.Option {
+	return func(c interface{}) {
+		c.(*config).capacity = capacity
 	}
+}
+
+// SetLIFO 设置过载时是否切换为后进先出, 仅 PriorityQueue 生效
+func SetLIFO(lifo bool) options.Option {
+	return func(c interface{}) {
+		c.(*config).lifo = lifo
+	}
+}
+
+// newConfig 应用选项, 非法配置回退到默认值
+func newConfig(options ...options.Option) *config {
+	conf := defaultConfig()
 	for _, option := range options {
-		option(q.conf)
+		option(conf)
+	}
+	if conf.target <= 0 || conf.internal <= 0 {
+		conf.target, conf.internal = defaultConfig().target, defaultConfig().internal
+	}
+	if conf.capacity <= 0 {
+		conf.capacity = defaultConfig().capacity
 	}
-	if q.conf.target <= 0 || q.conf.internal <= 0 {
-		q.conf = defaultConfig()
+	return conf
+}
+
+// NewQueue 实例化 CoDel Queue
+func NewQueue(options ...options.Option) *Queue {
+	conf := newConfig(options...)
+	return &Queue{
+		packets: make(chan packet, conf.capacity),
+		conf:    conf,
 	}
-	return q
 }
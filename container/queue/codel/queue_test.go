@@ -175,10 +175,6 @@ func queueInDroppingState(count, dropNext int64) *Queue {
 	return q
 }
 
-func nowMillis() int64 {
-	return time.Now().UnixNano() / int64(time.Millisecond)
-}
-
 func BenchmarkAQM(b *testing.B) {
 	q := Default()
 	b.RunParallel(func(p *testing.PB) {
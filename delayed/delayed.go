@@ -6,6 +6,18 @@ type Delayed interface {
 	Identify() string // 任务唯一标识
 }
 
+// Dispatcher 延时任务调度引擎, DispatchingDelayed 与 TimingWheel 均实现该接口
+type Dispatcher interface {
+	Add(delayed Delayed) error
+	Cancel(identify string) error
+	Close() error
+}
+
+var (
+	_ Dispatcher = (*DispatchingDelayed)(nil)
+	_ Dispatcher = (*TimingWheel)(nil)
+)
+
 // copy https://github.dev/golang/go/blob/a131fd1313e0056ad094d234c67648409d081b8c/src/runtime/time.go siftupTimer and siftdownTimer
 
 // Heap maintenance algorithms.
@@ -37,6 +49,13 @@ func siftupDelayed(d []Delayed, i int) int {
 	return i
 }
 
+// heapifyDelayed establishes the heap ordering of d.
+func heapifyDelayed(d []Delayed) {
+	for i := (len(d) - 2) / 4; i >= 0; i-- {
+		siftdownDelayed(d, i)
+	}
+}
+
 // siftdownDelayed puts the Delayed at position i in the right place
 // in the heap by moving it down toward the bottom of the heap.
 func siftdownDelayed(d []Delayed, i int) {
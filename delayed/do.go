@@ -15,6 +15,8 @@ import (
 
 var BadDelayed = &badDelayed{}
 var ErrorRepeatShutdown = errors.New("重复关闭")
+var ErrorNotFound = errors.New("任务不存在")
+var ErrorClosed = errors.New("调度已关闭")
 
 type badDelayed struct{}
 
@@ -46,37 +48,152 @@ type DispatchingDelayed struct {
 	sentinelDone   chan struct{}    // sentinel 退出信号
 	closeCtx       context.Context  // 取消后唤醒 sentinel 卡在 AddTaskN 的提交
 	closeCancel    context.CancelFunc
-	shutdownErr    error // pool.Shutdown 结果；sentinel 写、Close 读（经 sentinelDone 同步）
+	shutdownErr    error             // pool.Shutdown 结果；sentinel 写、Close 读（经 sentinelDone 同步）
+	store          Store             // 任务持久化(可选)
+	storeMu        sync.Mutex        // 串行化 store 的写入与堆的变更
+	versions       map[string]uint64 // 配置了 Store 时每个 Identify 最新任务的版本, 避免执行完成的旧任务删除新任务的记录
 }
 
-// AddDelayed 添加延时任务
+// AddDelayed 添加延时任务, 忽略错误, 需要感知持久化失败或已关闭时使用 Add
 func (d *DispatchingDelayed) AddDelayed(delayed Delayed) {
+	_ = d.Add(delayed)
+}
+
+// Add 添加延时任务
+// 配置了 Store 时先持久化任务, 持久化失败返回错误, 已关闭返回 ErrorClosed;
+// 记录以 Identify 为键, 已有相同 Identify 的待执行任务时替换该任务
+func (d *DispatchingDelayed) Add(delayed Delayed) error {
 	if delayed.ExecTime() <= 0 {
 		// 无效任务
-		return
+		return nil
 	}
 	if atomic.LoadInt32(&d.isClose) == 1 {
-		return
+		return ErrorClosed
+	}
+	if d.store != nil {
+		d.storeMu.Lock()
+		defer d.storeMu.Unlock()
+		record, err := newRecord(delayed)
+		if err != nil {
+			return err
+		}
+		if err = d.store.Save(record); err != nil {
+			return err
+		}
+	}
+	if !d.pushDelayed(delayed) {
+		if d.store != nil {
+			_ = d.store.Remove(delayed.Identify())
+		}
+		return ErrorClosed
 	}
+	return nil
+}
 
+// pushDelayed 将任务加入堆, 配置了 Store 时替换相同 Identify 的任务, 已关闭返回 false
+func (d *DispatchingDelayed) pushDelayed(delayed Delayed) bool {
 	d.Lock()
 	defer d.Unlock()
 	// Re-check under the lock: Close() may have set isClose (and the sentinel
 	// may have cleared d.delays) between the atomic check above and this lock;
 	// appending now would re-populate — leak into — a closed dispatcher.
 	if atomic.LoadInt32(&d.isClose) == 1 {
-		return
+		return false
 	}
 
+	if d.store != nil {
+		d.removeDelayedLocked(delayed.Identify())
+		d.versions[delayed.Identify()]++
+	}
 	i := len(d.delays)
 	d.delays = append(d.delays, delayed)
 	siftupDelayed(d.delays, i)
+	return true
+}
+
+// Cancel 根据 Identify 取消尚未执行的任务, 同时删除持久化记录
+func (d *DispatchingDelayed) Cancel(identify string) error {
+	if d.store == nil {
+		if !d.removeDelayed(identify) {
+			return ErrorNotFound
+		}
+		return nil
+	}
+	d.storeMu.Lock()
+	defer d.storeMu.Unlock()
+	if !d.removeDelayed(identify) {
+		return ErrorNotFound
+	}
+	return d.store.Remove(identify)
+}
+
+// removeDelayed 删除堆中所有 Identify 匹配的任务, 查找与删除在同一把锁内,
+// 避免 sentinel 在两者之间调整堆导致误删其他任务
+func (d *DispatchingDelayed) removeDelayed(identify string) bool {
+	d.Lock()
+	defer d.Unlock()
+	if !d.removeDelayedLocked(identify) {
+		return false
+	}
+	delete(d.versions, identify)
+	return true
+}
+
+// removeDelayedLocked 一次遍历删除所有 Identify 匹配的任务后重建堆, 调用方需持有写锁
+func (d *DispatchingDelayed) removeDelayedLocked(identify string) bool {
+	n := 0
+	for _, delayed := range d.delays {
+		if delayed.Identify() != identify {
+			d.delays[n] = delayed
+			n++
+		}
+	}
+	if n == len(d.delays) {
+		return false
+	}
+	for i := n; i < len(d.delays); i++ {
+		d.delays[i] = nil
+	}
+	d.delays = d.delays[:n]
+	heapifyDelayed(d.delays)
+	return true
+}
+
+// releaseVersion version 仍是 Identify 的最新版本时释放该版本并返回 true
+func (d *DispatchingDelayed) releaseVersion(identify string, version uint64) bool {
+	d.Lock()
+	defer d.Unlock()
+	if d.versions[identify] != version {
+		return false
+	}
+	delete(d.versions, identify)
+	return true
+}
+
+// do 执行任务, 完成后删除持久化记录
+// 执行期间又 Add 了相同 Identify 的任务时, 记录属于新任务, 予以保留
+func (d *DispatchingDelayed) do(delayed Delayed, version uint64) func() {
+	if d.store == nil {
+		return delayed.Do
+	}
+	return func() {
+		delayed.Do()
+		d.storeMu.Lock()
+		defer d.storeMu.Unlock()
+		if d.releaseVersion(delayed.Identify(), version) {
+			_ = d.store.Remove(delayed.Identify())
+		}
+	}
 }
 
 func (d *DispatchingDelayed) delDelayed(i int) Delayed {
 	d.Lock()
 	defer d.Unlock()
+	return d.delDelayedLocked(i)
+}
 
+// delDelayedLocked 删除堆中第 i 个任务, 调用方需持有写锁
+func (d *DispatchingDelayed) delDelayedLocked(i int) Delayed {
 	if i >= len(d.delays) {
 		return BadDelayed
 	}
@@ -130,17 +247,17 @@ func (d *DispatchingDelayed) getTopDelayed() Delayed {
 }
 
 // popIfReady 在持锁状态下检查 top 是否已到达执行时间；
-// 是则 pop 并返回，否则返回 BadDelayed。
+// 是则 pop 并返回任务及其版本，否则返回 BadDelayed。
 // 把"判断 + pop"合到同一把锁里，避免 sentinel 中无锁读 len(d.delays)
 // 以及 getTopDelayed/delDelayedTop 分离造成的 TOCTOU。
-func (d *DispatchingDelayed) popIfReady(now int64) Delayed {
+func (d *DispatchingDelayed) popIfReady(now int64) (Delayed, uint64) {
 	d.Lock()
 	defer d.Unlock()
 	if len(d.delays) == 0 {
-		return BadDelayed
+		return BadDelayed, 0
 	}
 	if d.delays[0].ExecTime() > now {
-		return BadDelayed
+		return BadDelayed, 0
 	}
 	ret := d.delays[0]
 	last := len(d.delays) - 1
@@ -152,7 +269,7 @@ func (d *DispatchingDelayed) popIfReady(now int64) Delayed {
 	if last > 0 {
 		siftdownDelayed(d.delays, 0)
 	}
-	return ret
+	return ret, d.versions[ret.Identify()]
 }
 
 // IsInvalid 判断任务是否有效
@@ -215,14 +332,14 @@ func (d *DispatchingDelayed) sentinel() {
 			}
 			now := time.Now().Unix()
 			for {
-				top := d.popIfReady(now)
+				top, version := d.popIfReady(now)
 				if d.IsInvalid(top) {
 					break
 				}
 				// Cancellable submit: Close() cancels closeCtx so a send stuck
 				// on the unbuffered task channel (all workers busy) unblocks and
 				// the sentinel can always reach the close branch.
-				d.pool.AddTaskN(d.closeCtx, top.Do)
+				d.pool.AddTaskN(d.closeCtx, d.do(top, version))
 			}
 		}
 	}()
@@ -230,6 +347,35 @@ func (d *DispatchingDelayed) sentinel() {
 
 // NewDispatchingDelayed 初始化调度实例
 func NewDispatchingDelayed(o ...options.Option) *DispatchingDelayed {
+	dispatchingDelayed := newDispatchingDelayed(o...)
+	dispatchingDelayed.start()
+	return dispatchingDelayed
+}
+
+// NewPersistentDelayed 初始化可持久化的调度实例
+// 启动前从 store 恢复任务, restore 根据记录重建任务;
+// 恢复失败的记录会被跳过并保留在 store 中
+func NewPersistentDelayed(store Store, restore Restore, o ...options.Option) (*DispatchingDelayed, error) {
+	records, err := store.Load()
+	if err != nil {
+		return nil, err
+	}
+	dispatchingDelayed := newDispatchingDelayed(o...)
+	dispatchingDelayed.store = store
+	dispatchingDelayed.versions = make(map[string]uint64)
+	for _, record := range records {
+		delayed, err := restore(record)
+		if err != nil || delayed == nil || delayed.ExecTime() <= 0 {
+			continue
+		}
+		dispatchingDelayed.pushDelayed(delayed)
+	}
+	dispatchingDelayed.start()
+	return dispatchingDelayed, nil
+}
+
+// newDispatchingDelayed 初始化调度实例, 不启动后台任务
+func newDispatchingDelayed(o ...options.Option) *DispatchingDelayed {
 	dispatchingDelayed := &DispatchingDelayed{
 		checkTime: time.Second,
 		Worker:    1,
@@ -260,9 +406,14 @@ func NewDispatchingDelayed(o ...options.Option) *DispatchingDelayed {
 		goroutine.SetMax(dispatchingDelayed.Worker),
 		goroutine.SetIdle(dispatchingDelayed.Worker),
 	)
-	if len(dispatchingDelayed.signal) != 0 && dispatchingDelayed.signalCallback != nil {
+	return dispatchingDelayed
+}
+
+// start 启动信号监听与 sentinel
+func (d *DispatchingDelayed) start() {
+	if len(d.signal) != 0 && d.signalCallback != nil {
 		sign := make(chan os.Signal, 1)
-		signal.Notify(sign, dispatchingDelayed.signal...)
+		signal.Notify(sign, d.signal...)
 		go func() {
 			// Match Notify with Stop on goroutine exit; the previous code
 			// leaked the signal forwarder for every DispatchingDelayed
@@ -270,14 +421,13 @@ func NewDispatchingDelayed(o ...options.Option) *DispatchingDelayed {
 			defer signal.Stop(sign)
 			for {
 				select {
-				case <-dispatchingDelayed.close:
+				case <-d.close:
 					return
 				case v := <-sign:
-					dispatchingDelayed.signalCallback(v, dispatchingDelayed)
+					d.signalCallback(v, d)
 				}
 			}
 		}()
 	}
-	dispatchingDelayed.sentinel()
-	return dispatchingDelayed
+	d.sentinel()
 }
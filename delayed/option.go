@@ -13,10 +13,15 @@ func SetCheckTime(checkTime time.Duration) options.Option {
 	}
 }
 
-// SetWorkerNumber 设置并发数
+// SetWorkerNumber 设置并发数, 适用于 DispatchingDelayed 与 TimingWheel
 func SetWorkerNumber(w int64) options.Option {
 	return func(o interface{}) {
-		o.(*DispatchingDelayed).Worker = w
+		switch v := o.(type) {
+		case *DispatchingDelayed:
+			v.Worker = w
+		case *TimingWheel:
+			v.Worker = w
+		}
 	}
 }
 
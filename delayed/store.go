@@ -0,0 +1,265 @@
+package delayed
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+var ErrorStoreClosed = errors.New("存储已关闭")
+
+// Record 持久化的任务记录
+type Record struct {
+	Identify string `json:"identify"`
+	ExecTime int64  `json:"exec_time"`
+	Payload  []byte `json:"payload,omitempty"`
+}
+
+// Store 任务存储
+// Add 时 Save, 任务执行完成或 Cancel 时 Remove, 启动时 Load 恢复
+type Store interface {
+	Save(record Record) error
+	Remove(identify string) error
+	Load() ([]Record, error)
+	Close() error
+}
+
+// Marshaler 需要持久化任务参数的 Delayed 实现 Marshal, 结果保存在 Record.Payload
+type Marshaler interface {
+	Marshal() ([]byte, error)
+}
+
+// Restore 根据 Record 恢复任务
+type Restore func(record Record) (Delayed, error)
+
+// newRecord 生成任务记录
+func newRecord(delayed Delayed) (Record, error) {
+	record := Record{
+		Identify: delayed.Identify(),
+		ExecTime: delayed.ExecTime(),
+	}
+	if m, ok := delayed.(Marshaler); ok {
+		payload, err := m.Marshal()
+		if err != nil {
+			return record, err
+		}
+		record.Payload = payload
+	}
+	return record, nil
+}
+
+// walOp 日志操作类型
+type walOp uint8
+
+const (
+	walSave walOp = iota + 1
+	walRemove
+)
+
+// walEntry 日志条目, 每行一条 json
+type walEntry struct {
+	Op     walOp   `json:"op"`
+	Record *Record `json:"record,omitempty"`
+	ID     string  `json:"id,omitempty"`
+}
+
+// compactThreshold 无效日志条数超过阈值且超过有效记录数时重写日志
+const compactThreshold = 1024
+
+// FileStore 基于追加写日志(WAL)的文件存储
+// 每次写入后 fsync, 无效条目累积后自动压缩
+type FileStore struct {
+	sync.Mutex
+	path    string
+	file    *os.File
+	records map[string]Record
+	// garbage: 被覆盖或删除的日志条数
+	garbage int
+}
+
+// Save 保存任务记录
+func (s *FileStore) Save(record Record) error {
+	s.Lock()
+	defer s.Unlock()
+	if s.file == nil {
+		return ErrorStoreClosed
+	}
+	if err := s.append(walEntry{Op: walSave, Record: &record}); err != nil {
+		return err
+	}
+	if _, ok := s.records[record.Identify]; ok {
+		s.garbage++
+	}
+	s.records[record.Identify] = record
+	return s.compactIfNeeded()
+}
+
+// Remove 删除任务记录
+func (s *FileStore) Remove(identify string) error {
+	s.Lock()
+	defer s.Unlock()
+	if s.file == nil {
+		return ErrorStoreClosed
+	}
+	if _, ok := s.records[identify]; !ok {
+		return nil
+	}
+	if err := s.append(walEntry{Op: walRemove, ID: identify}); err != nil {
+		return err
+	}
+	delete(s.records, identify)
+	// 删除条目与其对应的保存条目都已无效
+	s.garbage += 2
+	return s.compactIfNeeded()
+}
+
+// Load 返回全部任务记录
+func (s *FileStore) Load() ([]Record, error) {
+	s.Lock()
+	defer s.Unlock()
+	if s.file == nil {
+		return nil, ErrorStoreClosed
+	}
+	records := make([]Record, 0, len(s.records))
+	for _, record := range s.records {
+		records = append(records, record)
+	}
+	return records, nil
+}
+
+// Close 关闭存储
+func (s *FileStore) Close() error {
+	s.Lock()
+	defer s.Unlock()
+	if s.file == nil {
+		return ErrorStoreClosed
+	}
+	err := s.file.Close()
+	s.file = nil
+	return err
+}
+
+// append 追加日志并落盘
+func (s *FileStore) append(entry walEntry) error {
+	line, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+	if _, err = s.file.Write(append(line, '\n')); err != nil {
+		return err
+	}
+	return s.file.Sync()
+}
+
+// compactIfNeeded 无效条目过多时重写日志
+func (s *FileStore) compactIfNeeded() error {
+	if s.garbage < compactThreshold || s.garbage < len(s.records) {
+		return nil
+	}
+	return s.compact()
+}
+
+// compact 将有效记录写入临时文件后原子替换日志
+func (s *FileStore) compact() error {
+	tmp := s.path + ".compact"
+	f, err := os.OpenFile(tmp, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0o644)
+	if err != nil {
+		return err
+	}
+	w := bufio.NewWriter(f)
+	enc := json.NewEncoder(w)
+	for _, record := range s.records {
+		record := record
+		if err = enc.Encode(walEntry{Op: walSave, Record: &record}); err != nil {
+			break
+		}
+	}
+	if err == nil {
+		err = w.Flush()
+	}
+	if err == nil {
+		err = f.Sync()
+	}
+	if cErr := f.Close(); err == nil {
+		err = cErr
+	}
+	if err == nil {
+		err = os.Rename(tmp, s.path)
+	}
+	if err != nil {
+		_ = os.Remove(tmp)
+		return err
+	}
+	file, err := os.OpenFile(s.path, os.O_APPEND|os.O_WRONLY, 0o644)
+	if err != nil {
+		return err
+	}
+	_ = s.file.Close()
+	s.file = file
+	s.garbage = 0
+	return nil
+}
+
+// replay 回放日志, 末尾不完整的条目(写入时崩溃)会被忽略
+func (s *FileStore) replay() error {
+	f, err := os.Open(s.path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+	defer f.Close()
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 0, 64*1024), 64*1024*1024)
+	for scanner.Scan() {
+		var entry walEntry
+		if err = json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			s.garbage++
+			continue
+		}
+		switch {
+		case entry.Op == walSave && entry.Record != nil:
+			if _, ok := s.records[entry.Record.Identify]; ok {
+				s.garbage++
+			}
+			s.records[entry.Record.Identify] = *entry.Record
+		case entry.Op == walRemove:
+			delete(s.records, entry.ID)
+			s.garbage += 2
+		default:
+			s.garbage++
+		}
+	}
+	return scanner.Err()
+}
+
+// NewFileStore 实例化文件存储, path 不存在时自动创建
+func NewFileStore(path string) (*FileStore, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return nil, err
+	}
+	s := &FileStore{
+		path:    path,
+		records: make(map[string]Record),
+	}
+	if err := s.replay(); err != nil {
+		return nil, err
+	}
+	// 启动时重写日志, 丢弃无效及损坏的条目
+	file, err := os.OpenFile(s.path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o644)
+	if err != nil {
+		return nil, err
+	}
+	s.file = file
+	if s.garbage > 0 {
+		if err = s.compact(); err != nil {
+			_ = s.file.Close()
+			return nil, err
+		}
+	}
+	return s, nil
+}
@@ -0,0 +1,94 @@
+package store_redis
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/go-redis/redis/v8"
+	"github.com/songzhibin97/gkit/delayed"
+)
+
+// package store_redis: 基于 redis 有序集合的延时任务存储
+// key 为有序集合, member 为 Identify, score 为 ExecTime
+// key:records 为哈希表, 保存 Identify 对应的完整记录
+
+var _ delayed.Store = (*Store)(nil)
+
+// Store redis 存储
+type Store struct {
+	client redis.UniversalClient
+	key    string
+}
+
+// recordsKey 保存完整记录的哈希表
+func (s *Store) recordsKey() string {
+	return s.key + ":records"
+}
+
+// Save 保存任务记录
+func (s *Store) Save(record delayed.Record) error {
+	data, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+	ctx := context.Background()
+	_, err = s.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.ZAdd(ctx, s.key, &redis.Z{Score: float64(record.ExecTime), Member: record.Identify})
+		pipe.HSet(ctx, s.recordsKey(), record.Identify, data)
+		return nil
+	})
+	return err
+}
+
+// Remove 删除任务记录
+func (s *Store) Remove(identify string) error {
+	ctx := context.Background()
+	_, err := s.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.ZRem(ctx, s.key, identify)
+		pipe.HDel(ctx, s.recordsKey(), identify)
+		return nil
+	})
+	return err
+}
+
+// Load 按执行时间顺序返回全部任务记录
+func (s *Store) Load() ([]delayed.Record, error) {
+	ctx := context.Background()
+	ids, err := s.client.ZRange(ctx, s.key, 0, -1).Result()
+	if err != nil {
+		return nil, err
+	}
+	if len(ids) == 0 {
+		return nil, nil
+	}
+	values, err := s.client.HMGet(ctx, s.recordsKey(), ids...).Result()
+	if err != nil {
+		return nil, err
+	}
+	records := make([]delayed.Record, 0, len(values))
+	for _, value := range values {
+		data, ok := value.(string)
+		if !ok {
+			continue
+		}
+		var record delayed.Record
+		if err = json.Unmarshal([]byte(data), &record); err != nil {
+			continue
+		}
+		records = append(records, record)
+	}
+	return records, nil
+}
+
+// Close redis client 由调用方管理, 这里不关闭
+func (s *Store) Close() error {
+	return nil
+}
+
+// NewRedisStore 实例化 redis 存储
+func NewRedisStore(client redis.UniversalClient, key string) *Store {
+	return &Store{
+		client: client,
+		key:    key,
+	}
+}
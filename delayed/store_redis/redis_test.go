@@ -0,0 +1,37 @@
+package store_redis
+
+import (
+	"testing"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/go-redis/redis/v8"
+	"github.com/songzhibin97/gkit/delayed"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStore(t *testing.T) {
+	mr := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	t.Cleanup(func() { _ = client.Close() })
+
+	s := NewRedisStore(client, "delayed")
+	assert.NoError(t, s.Save(delayed.Record{Identify: "b", ExecTime: 20, Payload: []byte("b")}))
+	assert.NoError(t, s.Save(delayed.Record{Identify: "a", ExecTime: 10}))
+	assert.NoError(t, s.Save(delayed.Record{Identify: "c", ExecTime: 30}))
+	assert.NoError(t, s.Remove("c"))
+
+	records, err := s.Load()
+	assert.NoError(t, err)
+	assert.Equal(t, []delayed.Record{
+		{Identify: "a", ExecTime: 10},
+		{Identify: "b", ExecTime: 20, Payload: []byte("b")},
+	}, records)
+
+	// 重复保存覆盖原记录
+	assert.NoError(t, s.Save(delayed.Record{Identify: "a", ExecTime: 40}))
+	records, err = s.Load()
+	assert.NoError(t, err)
+	assert.Equal(t, "a", records[1].Identify)
+	assert.Equal(t, int64(40), records[1].ExecTime)
+	assert.NoError(t, s.Close())
+}
@@ -0,0 +1,245 @@
+package delayed
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type persistentDelayed struct {
+	id   string
+	exec int64
+	arg  string
+	done chan string
+}
+
+func (p *persistentDelayed) Do()              { p.done <- p.id + ":" + p.arg }
+func (p *persistentDelayed) ExecTime() int64  { return p.exec }
+func (p *persistentDelayed) Identify() string { return p.id }
+
+func (p *persistentDelayed) Marshal() ([]byte, error) {
+	return []byte(p.arg), nil
+}
+
+func loadSorted(t *testing.T, s Store) []Record {
+	t.Helper()
+	records, err := s.Load()
+	assert.NoError(t, err)
+	sort.Slice(records, func(i, j int) bool { return records[i].Identify < records[j].Identify })
+	return records
+}
+
+func TestFileStore(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "delayed", "wal")
+	s, err := NewFileStore(path)
+	assert.NoError(t, err)
+	assert.NoError(t, s.Save(Record{Identify: "a", ExecTime: 1, Payload: []byte("a")}))
+	assert.NoError(t, s.Save(Record{Identify: "b", ExecTime: 2}))
+	assert.NoError(t, s.Save(Record{Identify: "b", ExecTime: 3}))
+	assert.NoError(t, s.Remove("a"))
+	assert.NoError(t, s.Remove("not exist"))
+	assert.NoError(t, s.Close())
+	assert.Equal(t, ErrorStoreClosed, s.Close())
+
+	// 模拟写入时崩溃留下的不完整条目
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0o644)
+	assert.NoError(t, err)
+	_, err = f.WriteString(`{"op":1,"record":{"ident`)
+	assert.NoError(t, err)
+	assert.NoError(t, f.Close())
+
+	s, err = NewFileStore(path)
+	assert.NoError(t, err)
+	assert.Equal(t, []Record{{Identify: "b", ExecTime: 3}}, loadSorted(t, s))
+	assert.Equal(t, 0, s.garbage)
+	assert.NoError(t, s.Close())
+}
+
+func TestFileStoreCompact(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "wal")
+	s, err := NewFileStore(path)
+	assert.NoError(t, err)
+	for i := 0; i < compactThreshold; i++ {
+		id := strconv.Itoa(i)
+		assert.NoError(t, s.Save(Record{Identify: id, ExecTime: int64(i + 1)}))
+		if i > 0 {
+			assert.NoError(t, s.Remove(id))
+		}
+	}
+	assert.Less(t, s.garbage, compactThreshold)
+	assert.NoError(t, s.Close())
+
+	s, err = NewFileStore(path)
+	assert.NoError(t, err)
+	assert.Equal(t, []Record{{Identify: "0", ExecTime: 1}}, loadSorted(t, s))
+	assert.NoError(t, s.Close())
+}
+
+func TestNewPersistentDelayed(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "wal")
+	s, err := NewFileStore(path)
+	assert.NoError(t, err)
+
+	done := make(chan string, 3)
+	restore := func(record Record) (Delayed, error) {
+		if record.Identify == "bad" {
+			return nil, errors.New("bad record")
+		}
+		return &persistentDelayed{id: record.Identify, exec: record.ExecTime, arg: string(record.Payload), done: done}, nil
+	}
+
+	future := time.Now().Add(time.Hour).Unix()
+	d, err := NewPersistentDelayed(s, restore, SetSingle(), SetCheckTime(time.Millisecond*10))
+	assert.NoError(t, err)
+	assert.NoError(t, d.Add(&persistentDelayed{id: "later", exec: future, arg: "x", done: done}))
+	assert.NoError(t, d.Add(&persistentDelayed{id: "cancel", exec: future, arg: "y", done: done}))
+	assert.NoError(t, d.Cancel("cancel"))
+	assert.Equal(t, ErrorNotFound, d.Cancel("cancel"))
+	assert.NoError(t, s.Save(Record{Identify: "bad", ExecTime: 1}))
+	assert.NoError(t, d.Close())
+
+	// 重启后恢复未执行的任务, 已取消的任务不会恢复
+	assert.Equal(t, []Record{
+		{Identify: "bad", ExecTime: 1},
+		{Identify: "later", ExecTime: future, Payload: []byte("x")},
+	}, loadSorted(t, s))
+	assert.NoError(t, s.Save(Record{Identify: "now", ExecTime: time.Now().Unix(), Payload: []byte("z")}))
+
+	d, err = NewPersistentDelayed(s, restore, SetSingle(), SetCheckTime(time.Millisecond*10))
+	assert.NoError(t, err)
+	select {
+	case v := <-done:
+		assert.Equal(t, "now:z", v)
+	case <-time.After(time.Second * 3):
+		t.Fatal("restored task was not executed")
+	}
+	// 执行完成后删除记录, 恢复失败的记录保留
+	assert.Eventually(t, func() bool { return len(loadSorted(t, s)) == 2 }, time.Second, time.Millisecond*10)
+	assert.Equal(t, "bad", loadSorted(t, s)[0].Identify)
+	assert.NoError(t, d.Cancel("later"))
+	assert.NoError(t, d.Close())
+	assert.Equal(t, []Record{{Identify: "bad", ExecTime: 1}}, loadSorted(t, s))
+	assert.NoError(t, s.Close())
+}
+
+func TestCancel(t *testing.T) {
+	d := NewDispatchingDelayed(SetSingle())
+	defer d.Close()
+	for i := 0; i < 10; i++ {
+		assert.NoError(t, d.Add(&persistentDelayed{id: strconv.Itoa(i), exec: time.Now().Add(time.Hour).Unix()}))
+	}
+	assert.NoError(t, d.Cancel("5"))
+	d.RLock()
+	defer d.RUnlock()
+	assert.Equal(t, 9, len(d.delays))
+	for i, delayed := range d.delays {
+		assert.NotEqual(t, "5", delayed.Identify())
+		if i > 0 {
+			assert.LessOrEqual(t, d.delays[(i-1)/4].ExecTime(), delayed.ExecTime())
+		}
+	}
+}
+
+// TestCancelConcurrentPop Cancel 与 sentinel 同时调整堆时, 只删除被取消的任务
+func TestCancelConcurrentPop(t *testing.T) {
+	const n = 200
+	d := NewDispatchingDelayed(SetSingle(), SetCheckTime(time.Millisecond), SetWorkerNumber(4))
+	defer d.Close()
+
+	done := make(chan string, n)
+	future := time.Now().Add(time.Hour).Unix()
+	for i := 0; i < n; i++ {
+		assert.NoError(t, d.Add(&persistentDelayed{id: "future-" + strconv.Itoa(i), exec: future + int64(i)}))
+	}
+	go func() {
+		for i := 0; i < n; i++ {
+			_ = d.Add(&persistentDelayed{id: "now-" + strconv.Itoa(i), exec: time.Now().Unix(), done: done})
+		}
+	}()
+	for i := 0; i < n; i += 2 {
+		assert.NoError(t, d.Cancel("future-"+strconv.Itoa(i)))
+	}
+
+	for i := 0; i < n; i++ {
+		select {
+		case <-done:
+		case <-time.After(time.Second * 3):
+			t.Fatalf("due task dropped, executed %d", i)
+		}
+	}
+	d.RLock()
+	defer d.RUnlock()
+	assert.Equal(t, n/2, len(d.delays))
+	for _, delayed := range d.delays {
+		id, _ := strconv.Atoi(delayed.Identify()[len("future-"):])
+		assert.Equal(t, 1, id%2)
+	}
+}
+
+func TestAddAfterClose(t *testing.T) {
+	s, err := NewFileStore(filepath.Join(t.TempDir(), "wal"))
+	assert.NoError(t, err)
+	defer s.Close()
+	d, err := NewPersistentDelayed(s, func(record Record) (Delayed, error) { return nil, nil }, SetSingle())
+	assert.NoError(t, err)
+	assert.NoError(t, d.Close())
+	assert.Equal(t, ErrorClosed, d.Add(&persistentDelayed{id: "a", exec: time.Now().Add(time.Hour).Unix()}))
+	assert.Empty(t, loadSorted(t, s))
+}
+
+// TestAddDuplicate 相同 Identify 的任务替换待执行的任务, 执行中的旧任务不会删除新任务的记录
+func TestAddDuplicate(t *testing.T) {
+	has := func(d *DispatchingDelayed, identify string) bool {
+		d.RLock()
+		defer d.RUnlock()
+		for _, delayed := range d.delays {
+			if delayed.Identify() == identify {
+				return true
+			}
+		}
+		return false
+	}
+
+	path := filepath.Join(t.TempDir(), "wal")
+	s, err := NewFileStore(path)
+	assert.NoError(t, err)
+	defer s.Close()
+	restore := func(record Record) (Delayed, error) {
+		return &persistentDelayed{id: record.Identify, exec: record.ExecTime, arg: string(record.Payload)}, nil
+	}
+
+	future := time.Now().Add(time.Hour).Unix()
+	d, err := NewPersistentDelayed(s, restore, SetSingle(), SetCheckTime(time.Millisecond*10))
+	assert.NoError(t, err)
+	assert.NoError(t, d.Add(&persistentDelayed{id: "replace", exec: future, arg: "a"}))
+	assert.NoError(t, d.Add(&persistentDelayed{id: "replace", exec: future + 1, arg: "b"}))
+	d.RLock()
+	assert.Equal(t, 1, len(d.delays))
+	d.RUnlock()
+
+	// 旧任务执行中时添加相同 Identify 的新任务
+	done := make(chan string)
+	assert.NoError(t, d.Add(&persistentDelayed{id: "dup", exec: time.Now().Unix(), arg: "old", done: done}))
+	assert.Eventually(t, func() bool { return !has(d, "dup") }, time.Second*3, time.Millisecond*10)
+	assert.NoError(t, d.Add(&persistentDelayed{id: "dup", exec: future, arg: "new"}))
+	assert.Equal(t, "dup:old", <-done)
+	assert.NoError(t, d.Close())
+
+	assert.Equal(t, []Record{
+		{Identify: "dup", ExecTime: future, Payload: []byte("new")},
+		{Identify: "replace", ExecTime: future + 1, Payload: []byte("b")},
+	}, loadSorted(t, s))
+
+	// 重启后恢复新任务
+	d, err = NewPersistentDelayed(s, restore, SetSingle())
+	assert.NoError(t, err)
+	assert.True(t, has(d, "dup"))
+	assert.True(t, has(d, "replace"))
+	assert.NoError(t, d.Close())
+}
@@ -0,0 +1,410 @@
+package delayed
+
+import (
+	"container/heap"
+	"container/list"
+	"context"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/songzhibin97/gkit/goroutine"
+	"github.com/songzhibin97/gkit/options"
+)
+
+// MilliDelayed 毫秒精度的任务, TimingWheel 优先使用 ExecTimeMilli
+type MilliDelayed interface {
+	Delayed
+	ExecTimeMilli() int64 // 执行时间 time.UnixMilli()
+}
+
+// execTimeMilli 任务的毫秒执行时间
+func execTimeMilli(delayed Delayed) int64 {
+	if m, ok := delayed.(MilliDelayed); ok {
+		return m.ExecTimeMilli()
+	}
+	return delayed.ExecTime() * 1000
+}
+
+// wheelEntry 时间轮中的任务
+type wheelEntry struct {
+	delayed    Delayed
+	expiration int64
+	bucket     *wheelBucket
+	element    *list.Element
+}
+
+// wheelBucket 时间轮的槽, 到期时整体取出
+type wheelBucket struct {
+	// expiration: 槽的到期时间, -1 表示未加入 bucketQueue
+	expiration int64
+	index      int
+	entries    list.List
+}
+
+// bucketQueue 按到期时间排序的槽, 驱动协程只在最近的槽到期时唤醒
+type bucketQueue []*wheelBucket
+
+func (q bucketQueue) Len() int           { return len(q) }
+func (q bucketQueue) Less(i, j int) bool { return q[i].expiration < q[j].expiration }
+func (q bucketQueue) Swap(i, j int) {
+	q[i], q[j] = q[j], q[i]
+	q[i].index = i
+	q[j].index = j
+}
+
+func (q *bucketQueue) Push(x interface{}) {
+	b := x.(*wheelBucket)
+	b.index = len(*q)
+	*q = append(*q, b)
+}
+
+func (q *bucketQueue) Pop() interface{} {
+	old := *q
+	n := len(old)
+	b := old[n-1]
+	old[n-1] = nil
+	*q = old[:n-1]
+	return b
+}
+
+// wheel 单层时间轮, 超出范围的任务交给上层 overflow
+type wheel struct {
+	tick        int64
+	size        int64
+	interval    int64
+	currentTime int64
+	buckets     []*wheelBucket
+	overflow    *wheel
+}
+
+func newWheel(tick, size, startMs int64) *wheel {
+	buckets := make([]*wheelBucket, size)
+	for i := range buckets {
+		buckets[i] = &wheelBucket{expiration: -1}
+	}
+	return &wheel{
+		tick:        tick,
+		size:        size,
+		interval:    tick * size,
+		currentTime: startMs - startMs%tick,
+		buckets:     buckets,
+	}
+}
+
+// add 将任务放入对应的槽, 已到期返回 nil
+// 返回的槽首次设置到期时间时需要加入 bucketQueue
+func (w *wheel) add(e *wheelEntry) (b *wheelBucket, enqueue bool) {
+	switch {
+	case e.expiration < w.currentTime+w.tick:
+		return nil, false
+	case e.expiration < w.currentTime+w.interval:
+		virtualID := e.expiration / w.tick
+		b = w.buckets[virtualID%w.size]
+		e.bucket = b
+		e.element = b.entries.PushBack(e)
+		expiration := virtualID * w.tick
+		if b.expiration != expiration {
+			b.expiration = expiration
+			return b, true
+		}
+		return b, false
+	default:
+		if w.overflow == nil {
+			w.overflow = newWheel(w.interval, w.size, w.currentTime)
+		}
+		return w.overflow.add(e)
+	}
+}
+
+// advance 推进时钟
+func (w *wheel) advance(timeMs int64) {
+	if timeMs < w.currentTime+w.tick {
+		return
+	}
+	w.currentTime = timeMs - timeMs%w.tick
+	if w.overflow != nil {
+		w.overflow.advance(w.currentTime)
+	}
+}
+
+// TimingWheel 分层时间轮调度延时任务, 毫秒精度
+// 与 DispatchingDelayed 相比, 添加与取消均为 O(1), 且只在最近的槽到期时唤醒
+// Concurrency safety
+type TimingWheel struct {
+	mu sync.Mutex
+
+	wheel   *wheel
+	queue   bucketQueue
+	entries map[string][]*wheelEntry
+	due     []Delayed // 已到期, 等待驱动协程提交, 提交前仍可取消
+
+	tick   time.Duration // 最小刻度
+	size   int64         // 每层槽数
+	Worker int64         // 并发数(实际执行任务)
+
+	pool        goroutine.GGroup
+	wake        chan struct{}
+	close       chan struct{}
+	isClose     int32
+	done        chan struct{}
+	closeCtx    context.Context
+	closeCancel context.CancelFunc
+	shutdownErr error
+}
+
+// AddDelayed 添加延时任务, 忽略错误, 需要感知已关闭时使用 Add
+func (t *TimingWheel) AddDelayed(delayed Delayed) {
+	_ = t.Add(delayed)
+}
+
+// Add 添加延时任务, 已关闭返回 ErrorClosed
+// 已到期的任务交给驱动协程立即执行, Add 不会因 worker 繁忙而阻塞
+func (t *TimingWheel) Add(delayed Delayed) error {
+	if delayed.ExecTime() <= 0 {
+		// 无效任务
+		return nil
+	}
+	e := &wheelEntry{
+		delayed:    delayed,
+		expiration: execTimeMilli(delayed),
+	}
+
+	t.mu.Lock()
+	if atomic.LoadInt32(&t.isClose) == 1 {
+		t.mu.Unlock()
+		return ErrorClosed
+	}
+	b, enqueue := t.wheel.add(e)
+	if b == nil {
+		t.due = append(t.due, delayed)
+		t.mu.Unlock()
+		t.notify()
+		return nil
+	}
+	t.entries[e.delayed.Identify()] = append(t.entries[e.delayed.Identify()], e)
+	first := false
+	if enqueue {
+		heap.Push(&t.queue, b)
+		first = b.index == 0
+	}
+	t.mu.Unlock()
+	if first {
+		t.notify()
+	}
+	return nil
+}
+
+// notify 唤醒驱动协程
+func (t *TimingWheel) notify() {
+	select {
+	case t.wake <- struct{}{}:
+	default:
+	}
+}
+
+// Cancel 根据 Identify 取消尚未执行的任务, 包括已到期但尚未提交的任务
+func (t *TimingWheel) Cancel(identify string) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	entries, found := t.entries[identify]
+	for _, e := range entries {
+		e.bucket.entries.Remove(e.element)
+	}
+	delete(t.entries, identify)
+
+	n := 0
+	for _, delayed := range t.due {
+		if delayed.Identify() != identify {
+			t.due[n] = delayed
+			n++
+		}
+	}
+	if n != len(t.due) {
+		found = true
+		for i := n; i < len(t.due); i++ {
+			t.due[i] = nil
+		}
+		t.due = t.due[:n]
+	}
+	if !found {
+		return ErrorNotFound
+	}
+	return nil
+}
+
+// Len 尚未执行的任务数, 包括已到期但尚未提交的任务
+func (t *TimingWheel) Len() int {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	n := len(t.due)
+	for _, entries := range t.entries {
+		n += len(entries)
+	}
+	return n
+}
+
+// Close 关闭, 丢弃尚未执行的任务
+func (t *TimingWheel) Close() error {
+	if !atomic.CompareAndSwapInt32(&t.isClose, 0, 1) {
+		return ErrorRepeatShutdown
+	}
+	t.closeCancel()
+	close(t.close)
+	<-t.done
+	return t.shutdownErr
+}
+
+// forget 任务离开时间轮
+func (t *TimingWheel) forget(e *wheelEntry) {
+	identify := e.delayed.Identify()
+	entries := t.entries[identify]
+	for i, v := range entries {
+		if v == e {
+			entries = append(entries[:i], entries[i+1:]...)
+			break
+		}
+	}
+	if len(entries) == 0 {
+		delete(t.entries, identify)
+	} else {
+		t.entries[identify] = entries
+	}
+}
+
+// submit 提交执行
+func (t *TimingWheel) submit(delayed Delayed) {
+	t.pool.AddTaskN(t.closeCtx, delayed.Do)
+}
+
+// popDue 取出下一个待提交的任务
+func (t *TimingWheel) popDue() (Delayed, bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if len(t.due) == 0 {
+		return nil, false
+	}
+	delayed := t.due[0]
+	t.due[0] = nil
+	t.due = t.due[1:]
+	return delayed, true
+}
+
+// expire 取出已到期的槽, 槽内任务降级到下层时间轮或加入 due 等待执行
+func (t *TimingWheel) expire(now int64) {
+	for len(t.queue) > 0 && t.queue[0].expiration <= now {
+		b := heap.Pop(&t.queue).(*wheelBucket)
+		t.wheel.advance(b.expiration)
+		b.expiration = -1
+		var entries []*wheelEntry
+		for el := b.entries.Front(); el != nil; el = el.Next() {
+			entries = append(entries, el.Value.(*wheelEntry))
+		}
+		b.entries.Init()
+		for _, e := range entries {
+			nb, enqueue := t.wheel.add(e)
+			if nb == nil {
+				t.forget(e)
+				t.due = append(t.due, e.delayed)
+				continue
+			}
+			if enqueue {
+				heap.Push(&t.queue, nb)
+			}
+		}
+	}
+}
+
+// run 驱动时间轮
+func (t *TimingWheel) run() {
+	defer close(t.done)
+	timer := time.NewTimer(time.Hour)
+	defer timer.Stop()
+	for {
+		t.mu.Lock()
+		t.expire(time.Now().UnixMilli())
+		hasNext := len(t.queue) > 0
+		var next time.Duration
+		if hasNext {
+			next = time.Duration(t.queue[0].expiration-time.Now().UnixMilli()) * time.Millisecond
+		}
+		t.mu.Unlock()
+
+		// 逐个取出提交, 等待提交的任务仍可被 Cancel
+		for delayed, ok := t.popDue(); ok; delayed, ok = t.popDue() {
+			t.submit(delayed)
+		}
+
+		var timerC <-chan time.Time
+		if hasNext {
+			if !timer.Stop() {
+				select {
+				case <-timer.C:
+				default:
+				}
+			}
+			timer.Reset(next)
+			timerC = timer.C
+		}
+		select {
+		case <-timerC:
+		case <-t.wake:
+		case <-t.close:
+			t.mu.Lock()
+			t.entries = nil
+			t.queue = nil
+			t.due = nil
+			t.mu.Unlock()
+			t.shutdownErr = t.pool.Shutdown()
+			return
+		}
+	}
+}
+
+// SetTick 设置时间轮最小刻度, 最小 1ms
+func SetTick(tick time.Duration) options.Option {
+	return func(o interface{}) {
+		o.(*TimingWheel).tick = tick
+	}
+}
+
+// SetWheelSize 设置每层时间轮的槽数
+func SetWheelSize(size int64) options.Option {
+	return func(o interface{}) {
+		o.(*TimingWheel).size = size
+	}
+}
+
+// NewTimingWheel 初始化时间轮调度实例
+func NewTimingWheel(o ...options.Option) *TimingWheel {
+	t := &TimingWheel{
+		entries: make(map[string][]*wheelEntry),
+		tick:    time.Millisecond,
+		size:    512,
+		Worker:  1,
+		wake:    make(chan struct{}, 1),
+		close:   make(chan struct{}),
+		done:    make(chan struct{}),
+	}
+	t.closeCtx, t.closeCancel = context.WithCancel(context.Background())
+	for _, option := range o {
+		option(t)
+	}
+	if t.tick < time.Millisecond {
+		t.tick = time.Millisecond
+	}
+	if t.size <= 0 {
+		t.size = 512
+	}
+	if t.Worker <= 0 {
+		t.Worker = 1
+	}
+	t.wheel = newWheel(int64(t.tick/time.Millisecond), t.size, time.Now().UnixMilli())
+	t.pool = goroutine.NewGoroutine(
+		context.Background(),
+		goroutine.SetMax(t.Worker),
+		goroutine.SetIdle(t.Worker),
+	)
+	go t.run()
+	return t
+}
@@ -0,0 +1,207 @@
+package delayed
+
+import (
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type milliDelayed struct {
+	id   string
+	exec int64
+	fn   func()
+}
+
+func (m milliDelayed) Do()                  { m.fn() }
+func (m milliDelayed) ExecTime() int64      { return m.exec / 1000 }
+func (m milliDelayed) ExecTimeMilli() int64 { return m.exec }
+func (m milliDelayed) Identify() string     { return m.id }
+
+func TestTimingWheel_Order(t *testing.T) {
+	w := NewTimingWheel(SetWheelSize(8))
+	defer w.Close()
+
+	var mu sync.Mutex
+	var got []string
+	done := make(chan struct{}, 10)
+	now := time.Now().UnixMilli()
+	// 8ms 一层, 跨越多层时间轮
+	for _, delay := range []int64{120, 5, 70, 30, 15, 1} {
+		id := strconv.FormatInt(delay, 10)
+		assert.NoError(t, w.Add(milliDelayed{id: id, exec: now + delay, fn: func() {
+			mu.Lock()
+			got = append(got, id)
+			mu.Unlock()
+			done <- struct{}{}
+		}}))
+	}
+	assert.Equal(t, 6, w.Len())
+	for i := 0; i < 6; i++ {
+		select {
+		case <-done:
+		case <-time.After(time.Second):
+			t.Fatal("task was not executed")
+		}
+	}
+	assert.Equal(t, []string{"1", "5", "15", "30", "70", "120"}, got)
+	assert.Equal(t, 0, w.Len())
+}
+
+func TestTimingWheel_Precision(t *testing.T) {
+	w := NewTimingWheel()
+	defer w.Close()
+
+	done := make(chan time.Time, 1)
+	exec := time.Now().Add(50 * time.Millisecond)
+	assert.NoError(t, w.Add(milliDelayed{id: "a", exec: exec.UnixMilli(), fn: func() {
+		done <- time.Now()
+	}}))
+	select {
+	case at := <-done:
+		assert.False(t, at.Before(exec.Truncate(time.Millisecond)))
+		assert.Less(t, at.Sub(exec), 30*time.Millisecond)
+	case <-time.After(time.Second):
+		t.Fatal("task was not executed")
+	}
+}
+
+func TestTimingWheel_Cancel(t *testing.T) {
+	w := NewTimingWheel()
+	defer w.Close()
+
+	executed := make(chan string, 3)
+	now := time.Now().UnixMilli()
+	for _, id := range []string{"a", "b", "b"} {
+		id := id
+		assert.NoError(t, w.Add(milliDelayed{id: id, exec: now + 30, fn: func() { executed <- id }}))
+	}
+	assert.NoError(t, w.Cancel("b"))
+	assert.Equal(t, ErrorNotFound, w.Cancel("b"))
+	assert.Equal(t, 1, w.Len())
+	select {
+	case id := <-executed:
+		assert.Equal(t, "a", id)
+	case <-time.After(time.Second):
+		t.Fatal("task was not executed")
+	}
+	select {
+	case id := <-executed:
+		t.Fatalf("canceled task %s was executed", id)
+	case <-time.After(50 * time.Millisecond):
+	}
+}
+
+// TestTimingWheel_AddDueNotBlocking worker 繁忙时添加已到期的任务不阻塞调用方
+func TestTimingWheel_AddDueNotBlocking(t *testing.T) {
+	w := NewTimingWheel()
+	defer w.Close()
+
+	release := make(chan struct{})
+	executed := make(chan struct{}, 10)
+	added := make(chan struct{})
+	go func() {
+		defer close(added)
+		for i := 0; i < 10; i++ {
+			_ = w.Add(milliDelayed{id: strconv.Itoa(i), exec: 1000, fn: func() {
+				<-release
+				executed <- struct{}{}
+			}})
+		}
+	}()
+	select {
+	case <-added:
+	case <-time.After(time.Second):
+		t.Fatal("Add blocked on busy workers")
+	}
+	close(release)
+	for i := 0; i < 10; i++ {
+		select {
+		case <-executed:
+		case <-time.After(time.Second * 3):
+			t.Fatalf("due task dropped, executed %d", i)
+		}
+	}
+}
+
+// TestTimingWheel_CancelDue 已到期但尚未提交的任务计入 Len 且可以取消
+func TestTimingWheel_CancelDue(t *testing.T) {
+	w := NewTimingWheel()
+	defer w.Close()
+
+	started := make(chan struct{})
+	release := make(chan struct{})
+	executed := make(chan string, 2)
+	assert.NoError(t, w.Add(milliDelayed{id: "busy", exec: 1000, fn: func() {
+		close(started)
+		<-release
+	}}))
+	<-started
+	// worker 繁忙, 驱动协程阻塞在提交 blocked 上, due 到期时仍在等待提交
+	assert.NoError(t, w.Add(milliDelayed{id: "blocked", exec: 1000, fn: func() { executed <- "blocked" }}))
+	assert.NoError(t, w.Add(milliDelayed{id: "due", exec: 1000, fn: func() { executed <- "due" }}))
+	assert.Eventually(t, func() bool { return w.Len() == 1 }, time.Second, time.Millisecond)
+	assert.NoError(t, w.Cancel("due"))
+	assert.Equal(t, 0, w.Len())
+	assert.Equal(t, ErrorNotFound, w.Cancel("due"))
+
+	close(release)
+	assert.Equal(t, "blocked", <-executed)
+	select {
+	case id := <-executed:
+		t.Fatalf("canceled task %s executed", id)
+	case <-time.After(time.Millisecond * 50):
+	}
+}
+
+func TestTimingWheel_ExpiredAndClose(t *testing.T) {
+	w := NewTimingWheel()
+	executed := make(chan struct{}, 1)
+	assert.NoError(t, w.Add(milliDelayed{id: "a", exec: 1000, fn: func() { executed <- struct{}{} }}))
+	select {
+	case <-executed:
+	case <-time.After(time.Second):
+		t.Fatal("expired task was not executed immediately")
+	}
+	assert.NoError(t, w.Add(milliDelayed{id: "b", exec: time.Now().Add(time.Hour).UnixMilli(), fn: func() {}}))
+	assert.NoError(t, w.Close())
+	assert.Equal(t, ErrorRepeatShutdown, w.Close())
+	assert.Equal(t, ErrorClosed, w.Add(milliDelayed{id: "c", exec: time.Now().Add(time.Hour).UnixMilli(), fn: func() {}}))
+	assert.Equal(t, 0, w.Len())
+}
+
+// pending 1M 个一小时后执行的任务, 比较堆与时间轮的添加、取消开销
+const benchmarkPending = 1000000
+
+func benchmarkDispatcher(b *testing.B, d Dispatcher) {
+	exec := time.Now().Add(time.Hour).UnixMilli()
+	for i := 0; i < benchmarkPending; i++ {
+		_ = d.Add(milliDelayed{id: strconv.Itoa(i), exec: exec + int64(i%3600000), fn: func() {}})
+	}
+
+	b.Run("add", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			_ = d.Add(milliDelayed{id: "add", exec: exec + int64(i%3600000), fn: func() {}})
+		}
+	})
+	b.Run("cancel", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			id := strconv.Itoa(benchmarkPending + i)
+			_ = d.Add(milliDelayed{id: id, exec: exec + int64(i%3600000), fn: func() {}})
+			_ = d.Cancel(id)
+		}
+	})
+	_ = d.Close()
+}
+
+func BenchmarkPending1M_Heap(b *testing.B) {
+	benchmarkDispatcher(b, NewDispatchingDelayed(SetSingle()))
+}
+
+func BenchmarkPending1M_TimingWheel(b *testing.B) {
+	benchmarkDispatcher(b, NewTimingWheel())
+}
@@ -377,7 +377,7 @@ func TestOrdinaryCallbacksUsePublishCompensation(t *testing.T) {
 			name:      "successful success callback remains pending",
 			wantState: task.StatePending,
 			dispatch: func(worker *Worker, parent *task.Signature) error {
-				return worker.handlerSucceeded(parent, nil)
+				return worker.handlerSucceeded(context.Background(), parent, nil)
 			},
 		},
 		{
@@ -385,7 +385,7 @@ func TestOrdinaryCallbacksUsePublishCompensation(t *testing.T) {
 			wantState: task.StatePending,
 			dispatch: func(worker *Worker, parent *task.Signature) error {
 				worker.errorHandler = func(error) {}
-				return worker.handlerFailed(parent, errors.New("parent task failed"))
+				return worker.handlerFailed(context.Background(), parent, errors.New("parent task failed"))
 			},
 		},
 		{
@@ -394,7 +394,7 @@ func TestOrdinaryCallbacksUsePublishCompensation(t *testing.T) {
 			wantState:   task.StateFailure,
 			wantMessage: wantTaskPublicationFailureMessage,
 			dispatch: func(worker *Worker, parent *task.Signature) error {
-				return worker.handlerSucceeded(parent, nil)
+				return worker.handlerSucceeded(context.Background(), parent, nil)
 			},
 		},
 		{
@@ -404,7 +404,7 @@ func TestOrdinaryCallbacksUsePublishCompensation(t *testing.T) {
 			wantMessage: wantTaskPublicationFailureMessage,
 			dispatch: func(worker *Worker, parent *task.Signature) error {
 				worker.errorHandler = func(error) {}
-				return worker.handlerFailed(parent, errors.New("parent task failed"))
+				return worker.handlerFailed(context.Background(), parent, errors.New("parent task failed"))
 			},
 		},
 	}
@@ -439,10 +439,10 @@ func TestOrdinaryCallbackPublicationErrorsReachErrorHandler(t *testing.T) {
 		dispatch func(*Worker, *task.Signature) error
 	}{
 		{name: "success callback", dispatch: func(worker *Worker, parent *task.Signature) error {
-			return worker.handlerSucceeded(parent, nil)
+			return worker.handlerSucceeded(context.Background(), parent, nil)
 		}},
 		{name: "error callback", dispatch: func(worker *Worker, parent *task.Signature) error {
-			return worker.handlerFailed(parent, parentErr)
+			return worker.handlerFailed(context.Background(), parent, parentErr)
 		}},
 	}
 
@@ -489,10 +489,10 @@ func TestOrdinaryCallbackPublicationErrorsReachStructuredLogger(t *testing.T) {
 		dispatch func(*Worker, *task.Signature) error
 	}{
 		{name: "success callback", dispatch: func(worker *Worker, parent *task.Signature) error {
-			return worker.handlerSucceeded(parent, nil)
+			return worker.handlerSucceeded(context.Background(), parent, nil)
 		}},
 		{name: "error callback", dispatch: func(worker *Worker, parent *task.Signature) error {
-			return worker.handlerFailed(parent, parentErr)
+			return worker.handlerFailed(context.Background(), parent, parentErr)
 		}},
 	}
 
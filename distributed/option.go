@@ -58,3 +58,10 @@ func SetDurableChordRegistrationTimeout(timeout time.Duration) options.Option {
 		o.(*Config).DurableChordRegistrationTimeout = timeout
 	}
 }
+
+// SetTraceOptions 设置链路追踪配置, 如 trace.WithTracerProvider, trace.WithPropagator
+func SetTraceOptions(opts ...options.Option) options.Option {
+	return func(o interface{}) {
+		o.(*Config).TraceOptions = opts
+	}
+}
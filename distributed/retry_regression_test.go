@@ -60,7 +60,7 @@ func TestHandlerRetryClampsExtremeIntervalBeforeDurationConversion(t *testing.T)
 	}
 
 	before := time.Now()
-	if err := worker.handlerRetry(signature); err != nil {
+	if err := worker.handlerRetry(context.Background(), signature); err != nil {
 		t.Fatalf("handlerRetry returned error: %v", err)
 	}
 
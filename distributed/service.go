@@ -11,6 +11,7 @@ import (
 
 	"github.com/songzhibin97/gkit/options"
 	"github.com/songzhibin97/gkit/tools/rand_string"
+	"github.com/songzhibin97/gkit/trace"
 
 	"github.com/songzhibin97/gkit/log"
 
@@ -61,6 +62,8 @@ type Config struct {
 	EnableDurableChordRegistration  bool          `json:"enable_durable_chord_registration"`
 	RequireDurableChordBackend      bool          `json:"require_durable_chord_backend"`
 	DurableChordRegistrationTimeout time.Duration `json:"durable_chord_registration_timeout"`
+	// TraceOptions 链路追踪配置, 透传给 trace.NewTracer
+	TraceOptions []options.Option `json:"-"`
 }
 
 type Server struct {
@@ -90,6 +93,9 @@ type Server struct {
 	closing              bool
 	startupErr           error
 	lifecycleErrs        []error
+	tracerOnce           sync.Once
+	producer             *trace.Tracer // producer 发布任务的 tracer
+	consumer             *trace.Tracer // consumer 执行任务的 tracer
 }
 
 // GetConfig 获取配置文件
@@ -170,10 +176,13 @@ func (s *Server) GetRegisteredTask(name string) (interface{}, bool) {
 }
 
 // SendTaskWithContext 发送任务,可以传入ctx
-func (s *Server) SendTaskWithContext(ctx context.Context, signature *task.Signature) (*result.AsyncResult, error) {
+func (s *Server) SendTaskWithContext(ctx context.Context, signature *task.Signature) (_ *result.AsyncResult, err error) {
+	ctx, span := s.startPublishSpan(ctx, signature.Name, signature)
+	span.SetAttributes(taskAttributes(signature)...)
+	defer func() { s.producerTracer().End(ctx, span, nil, err) }()
+
 	attemptBackend, supportsAttemptCompensation := s.backend.(backend.PublicationAttemptBackend)
 	var attemptID string
-	var err error
 	if supportsAttemptCompensation {
 		attemptID, err = s.nextPublicationAttemptID()
 		if err != nil {
@@ -235,10 +244,13 @@ func (s *Server) SendChain(chain *task.Chain) (*result.ChainAsyncResult, error)
 }
 
 // SendGroupWithContext 发送并行执行的任务组
-func (s *Server) SendGroupWithContext(ctx context.Context, group *task.Group, concurrency int) ([]*result.AsyncResult, error) {
+func (s *Server) SendGroupWithContext(ctx context.Context, group *task.Group, concurrency int) (_ []*result.AsyncResult, err error) {
 	if err := task.ValidateGroup(group); err != nil {
 		return nil, err
 	}
+	ctx, span := s.startPublishSpan(ctx, group.Name, group.Tasks...)
+	span.SetAttributes(taskGroupIDKey.String(group.GroupID))
+	defer func() { s.producerTracer().End(ctx, span, nil, err) }()
 	if concurrency < 1 {
 		concurrency = 1
 	}
@@ -390,12 +402,17 @@ func (s *Server) SendGroup(group *task.Group, concurrency int) ([]*result.AsyncR
 }
 
 // SendGroupCallbackWithContext 发送具有回调任务的任务组
-func (s *Server) SendGroupCallbackWithContext(ctx context.Context, groupCallback *task.GroupCallback, concurrency int) (*result.GroupCallbackAsyncResult, error) {
+func (s *Server) SendGroupCallbackWithContext(ctx context.Context, groupCallback *task.GroupCallback, concurrency int) (_ *result.GroupCallbackAsyncResult, err error) {
 	workflowErr := task.ValidateGroupCallback(groupCallback)
 	chordErr := validateGroupCallback(groupCallback)
 	if err := stderrors.Join(workflowErr, chordErr); err != nil {
 		return nil, err
 	}
+	// 回调任务与组内任务共享同一个父 span
+	ctx, span := s.startPublishSpan(ctx, groupCallback.Group.Name,
+		append([]*task.Signature{groupCallback.Callback}, groupCallback.Group.Tasks...)...)
+	span.SetAttributes(taskGroupIDKey.String(groupCallback.Group.GroupID))
+	defer func() { s.producerTracer().End(ctx, span, nil, err) }()
 	if s.config != nil && s.config.EnableDurableChordRegistration {
 		if s.durableBackend == nil {
 			if s.config.RequireDurableChordBackend {
@@ -415,7 +432,7 @@ func (s *Server) SendGroupCallbackWithContext(ctx context.Context, groupCallback
 			return s.sendDurableGroupCallback(registrationCtx, groupCallback, concurrency)
 		}
 	}
-	_, err := s.SendGroupWithContext(ctx, groupCallback.Group, concurrency)
+	_, err = s.SendGroupWithContext(ctx, groupCallback.Group, concurrency)
 	if err != nil {
 		return nil, err
 	}
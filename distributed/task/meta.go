@@ -113,3 +113,36 @@ func (m *Meta) Range(f func(key string, value interface{})) {
 		f(k, v)
 	}
 }
+
+// MetaCarrier 将 Meta 适配为 propagation.TextMapCarrier, 用于在任务间传递链路信息
+type MetaCarrier struct {
+	meta *Meta
+}
+
+// NewMetaCarrier 实例化 MetaCarrier, meta 为 nil 时 Set 不生效
+func NewMetaCarrier(meta *Meta) MetaCarrier {
+	return MetaCarrier{meta: meta}
+}
+
+// Get 返回 key 对应的字符串值, 不存在或不是字符串时返回空
+func (c MetaCarrier) Get(key string) string {
+	v, _ := c.meta.Get(key)
+	s, _ := v.(string)
+	return s
+}
+
+// Set 设置 key 对应的值
+func (c MetaCarrier) Set(key string, value string) {
+	c.meta.Set(key, value)
+}
+
+// Keys 返回所有值为字符串的 key
+func (c MetaCarrier) Keys() []string {
+	var keys []string
+	c.meta.Range(func(key string, value interface{}) {
+		if _, ok := value.(string); ok {
+			keys = append(keys, key)
+		}
+	})
+	return keys
+}
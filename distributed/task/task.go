@@ -115,7 +115,12 @@ func SignatureFromContext(ctx context.Context) *Signature {
 
 // NewTaskWithSignature 初始化Task通过Signature
 func NewTaskWithSignature(taskFunc interface{}, signature *Signature) (*Task, error) {
-	ctx := context.WithValue(context.Background(), signatureCtx, signature)
+	return NewTaskWithSignatureContext(context.Background(), taskFunc, signature)
+}
+
+// NewTaskWithSignatureContext 初始化Task通过Signature, 任务上下文派生自 ctx (如携带链路信息)
+func NewTaskWithSignatureContext(ctx context.Context, taskFunc interface{}, signature *Signature) (*Task, error) {
+	ctx = context.WithValue(ctx, signatureCtx, signature)
 	task := &Task{
 		TaskFunc: reflect.ValueOf(taskFunc),
 		Context:  ctx,
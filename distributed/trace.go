@@ -0,0 +1,70 @@
+package distributed
+
+import (
+	"context"
+
+	"github.com/songzhibin97/gkit/distributed/task"
+	"github.com/songzhibin97/gkit/options"
+	"github.com/songzhibin97/gkit/trace"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/propagation"
+	oteltrace "go.opentelemetry.io/otel/trace"
+)
+
+// span 属性
+const (
+	taskNameKey       = attribute.Key("task.name")
+	taskIDKey         = attribute.Key("task.id")
+	taskRetryCountKey = attribute.Key("task.retry_count")
+	taskGroupIDKey    = attribute.Key("task.group_id")
+)
+
+// initTracer 根据 Config.TraceOptions 初始化 tracer
+func (s *Server) initTracer() {
+	s.tracerOnce.Do(func() {
+		var opts []options.Option
+		if s.config != nil {
+			opts = s.config.TraceOptions
+		}
+		s.producer = trace.NewTracer(oteltrace.SpanKindProducer, opts...)
+		s.consumer = trace.NewTracer(oteltrace.SpanKindConsumer, opts...)
+	})
+}
+
+// producerTracer 发布任务的 tracer
+func (s *Server) producerTracer() *trace.Tracer {
+	s.initTracer()
+	return s.producer
+}
+
+// consumerTracer 执行任务的 tracer
+func (s *Server) consumerTracer() *trace.Tracer {
+	s.initTracer()
+	return s.consumer
+}
+
+// startPublishSpan 开启 producer span, 并将链路注入 signatures 的 Meta
+func (s *Server) startPublishSpan(ctx context.Context, operation string, signatures ...*task.Signature) (context.Context, oteltrace.Span) {
+	producer := s.producerTracer()
+	ctx, span := producer.Start(ctx, operation, propagation.MapCarrier{})
+	for _, signature := range signatures {
+		if signature.Meta == nil {
+			signature.Meta = task.NewMeta(signature.MetaSafe)
+		}
+		producer.Inject(ctx, task.NewMetaCarrier(signature.Meta))
+	}
+	return ctx, span
+}
+
+// taskAttributes 任务相关的 span 属性
+func taskAttributes(signature *task.Signature) []attribute.KeyValue {
+	attrs := []attribute.KeyValue{
+		taskNameKey.String(signature.Name),
+		taskIDKey.String(signature.ID),
+		taskRetryCountKey.Int(signature.RetryCount),
+	}
+	if signature.GroupID != "" {
+		attrs = append(attrs, taskGroupIDKey.String(signature.GroupID))
+	}
+	return attrs
+}
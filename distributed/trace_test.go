@@ -0,0 +1,196 @@
+package distributed
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+
+	json "github.com/json-iterator/go"
+	"github.com/songzhibin97/gkit/distributed/task"
+	"github.com/songzhibin97/gkit/options"
+	"github.com/songzhibin97/gkit/trace"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	oteltrace "go.opentelemetry.io/otel/trace"
+)
+
+// newTraceTestServer 返回记录 span 的 Server, published 按发布顺序保存经过序列化的任务
+func newTraceTestServer(t *testing.T) (*Server, *tracetest.SpanRecorder, func() []*task.Signature) {
+	t.Helper()
+	var (
+		mu        sync.Mutex
+		published []*task.Signature
+	)
+	server, _ := newCallbackPublishTestServer(t, func(_ context.Context, signature *task.Signature) error {
+		body, err := json.Marshal(signature)
+		if err != nil {
+			return err
+		}
+		var decoded task.Signature
+		if err := json.Unmarshal(body, &decoded); err != nil {
+			return err
+		}
+		mu.Lock()
+		published = append(published, &decoded)
+		mu.Unlock()
+		return nil
+	})
+	recorder := tracetest.NewSpanRecorder()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+	t.Cleanup(func() { _ = provider.Shutdown(context.Background()) })
+	server.config.TraceOptions = []options.Option{trace.WithTracerProvider(provider)}
+	server.registeredTasks = &sync.Map{}
+	return server, recorder, func() []*task.Signature {
+		mu.Lock()
+		defer mu.Unlock()
+		return append([]*task.Signature(nil), published...)
+	}
+}
+
+func findSpan(t *testing.T, spans []sdktrace.ReadOnlySpan, name string, kind oteltrace.SpanKind) sdktrace.ReadOnlySpan {
+	t.Helper()
+	for _, span := range spans {
+		if span.Name() == name && span.SpanKind() == kind {
+			return span
+		}
+	}
+	t.Fatalf("span %q (%v) not found", name, kind)
+	return nil
+}
+
+func spanAttribute(span sdktrace.ReadOnlySpan, key attribute.Key) (attribute.Value, bool) {
+	for _, kv := range span.Attributes() {
+		if kv.Key == key {
+			return kv.Value, true
+		}
+	}
+	return attribute.Value{}, false
+}
+
+func TestTracePropagatesThroughTaskAndCallback(t *testing.T) {
+	server, recorder, published := newTraceTestServer(t)
+	if err := server.RegisteredTask("add", func(a, b int64) (int64, error) { return a + b, nil }); err != nil {
+		t.Fatal(err)
+	}
+	signature := task.NewSignature("trace-add", "add", task.SetArgs(
+		task.Arg{Type: "int64", Value: 1},
+		task.Arg{Type: "int64", Value: 2},
+	))
+	signature.CallbackOnSuccess = []*task.Signature{task.NewSignature("trace-callback", "callback")}
+
+	if _, err := server.SendTask(signature); err != nil {
+		t.Fatalf("SendTask: %v", err)
+	}
+	sent := published()
+	if len(sent) != 1 {
+		t.Fatalf("published %d tasks, want 1", len(sent))
+	}
+	if err := server.NewWorker("trace", 1, "").Process(sent[0]); err != nil {
+		t.Fatalf("Process: %v", err)
+	}
+	if sent = published(); len(sent) != 2 || sent[1].ID != "trace-callback" {
+		t.Fatalf("published = %d tasks, want task and callback", len(sent))
+	}
+
+	spans := recorder.Ended()
+	producer := findSpan(t, spans, "add", oteltrace.SpanKindProducer)
+	consumer := findSpan(t, spans, "add", oteltrace.SpanKindConsumer)
+	callback := findSpan(t, spans, "callback", oteltrace.SpanKindProducer)
+
+	if consumer.SpanContext().TraceID() != producer.SpanContext().TraceID() {
+		t.Fatal("consumer span is not in the producer trace")
+	}
+	if consumer.Parent().SpanID() != producer.SpanContext().SpanID() {
+		t.Fatal("consumer span is not a child of the producer span")
+	}
+	if callback.Parent().SpanID() != consumer.SpanContext().SpanID() {
+		t.Fatal("callback producer span is not a child of the consumer span")
+	}
+	if v, ok := spanAttribute(consumer, taskIDKey); !ok || v.AsString() != "trace-add" {
+		t.Fatalf("consumer task.id = %v, want trace-add", v.AsString())
+	}
+	if v, ok := spanAttribute(consumer, taskRetryCountKey); !ok || v.AsInt64() != 3 {
+		t.Fatalf("consumer task.retry_count = %v, want 3", v.AsInt64())
+	}
+	if consumer.Status().Code != codes.Ok {
+		t.Fatalf("consumer status = %v, want Ok", consumer.Status().Code)
+	}
+}
+
+func TestTraceVisibleInTaskContext(t *testing.T) {
+	server, recorder, published := newTraceTestServer(t)
+	var got oteltrace.SpanContext
+	if err := server.RegisteredTask("ctx", func(ctx context.Context) error {
+		got = oteltrace.SpanContextFromContext(ctx)
+		if task.SignatureFromContext(ctx) == nil {
+			return errors.New("signature missing from task context")
+		}
+		return nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := server.SendTask(task.NewSignature("trace-ctx", "ctx")); err != nil {
+		t.Fatalf("SendTask: %v", err)
+	}
+	if err := server.NewWorker("trace", 1, "").Process(published()[0]); err != nil {
+		t.Fatalf("Process: %v", err)
+	}
+
+	spans := recorder.Ended()
+	producer := findSpan(t, spans, "ctx", oteltrace.SpanKindProducer)
+	consumer := findSpan(t, spans, "ctx", oteltrace.SpanKindConsumer)
+	if got.TraceID() != producer.SpanContext().TraceID() {
+		t.Fatalf("task trace = %s, want %s", got.TraceID(), producer.SpanContext().TraceID())
+	}
+	if got.SpanID() != consumer.SpanContext().SpanID() {
+		t.Fatalf("task span = %s, want consumer span %s", got.SpanID(), consumer.SpanContext().SpanID())
+	}
+}
+
+func TestTraceRecordsHandledTaskFailure(t *testing.T) {
+	server, recorder, published := newTraceTestServer(t)
+	if err := server.RegisteredTask("fail", func() error { return errors.New("boom") }); err != nil {
+		t.Fatal(err)
+	}
+	signature := task.NewSignature("trace-fail", "fail", task.SetRetryCount(0))
+	if _, err := server.SendTask(signature); err != nil {
+		t.Fatalf("SendTask: %v", err)
+	}
+	worker := server.NewWorker("trace", 1, "")
+	worker.SetErrorHandler(func(error) {})
+	if err := worker.Process(published()[0]); err != nil {
+		t.Fatalf("Process: %v", err)
+	}
+
+	consumer := findSpan(t, recorder.Ended(), "fail", oteltrace.SpanKindConsumer)
+	if consumer.Status().Code != codes.Error || consumer.Status().Description != "boom" {
+		t.Fatalf("consumer status = %+v, want Error boom", consumer.Status())
+	}
+}
+
+func TestTracePropagatesToGroupMembers(t *testing.T) {
+	server, recorder, published := newTraceTestServer(t)
+	group, err := task.NewGroup("trace-group", "group",
+		task.NewSignature("trace-member-1", "member"),
+		task.NewSignature("trace-member-2", "member"),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := server.SendGroup(group, 2); err != nil {
+		t.Fatalf("SendGroup: %v", err)
+	}
+
+	producer := findSpan(t, recorder.Ended(), "group", oteltrace.SpanKindProducer)
+	consumer := server.consumerTracer()
+	for _, member := range published() {
+		ctx, span := consumer.Start(context.Background(), member.Name, task.NewMetaCarrier(member.Meta))
+		span.End()
+		if got := oteltrace.SpanContextFromContext(ctx).TraceID(); got != producer.SpanContext().TraceID() {
+			t.Fatalf("member %s trace = %s, want %s", member.ID, got, producer.SpanContext().TraceID())
+		}
+	}
+}
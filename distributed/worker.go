@@ -77,7 +77,8 @@ func (w *Worker) Quit() {
 }
 
 // Process 任务处理
-func (w *Worker) Process(signature *task.Signature) error {
+// 从 Signature.Meta 中提取上游链路, 开启 consumer span, 任务函数、重试及回调任务挂在该 span 下
+func (w *Worker) Process(signature *task.Signature) (err error) {
 	// 如果任务没有注册,快速返回
 	if !w.bindService.IsRegisteredTask(signature.Name) {
 		return nil
@@ -87,13 +88,25 @@ func (w *Worker) Process(signature *task.Signature) error {
 	if !ok {
 		return nil
 	}
+	consumer := w.bindService.consumerTracer()
+	ctx, span := consumer.Start(context.Background(), signature.Name, task.NewMetaCarrier(signature.Meta))
+	span.SetAttributes(taskAttributes(signature)...)
+	// taskErr: 任务执行失败但已被处理时 Process 返回 nil, span 仍记录失败
+	var taskErr error
+	defer func() {
+		if err != nil {
+			taskErr = err
+		}
+		consumer.End(ctx, span, nil, taskErr)
+	}()
+
 	// 设置任务状态,改为接收状态
 	if err := w.bindService.GetBackend().SetStateReceived(signature); err != nil {
 		return errors.Wrap(err, "worker set task state to 'received' error, signature id:"+signature.ID)
 	}
-	exec, err := task.NewTaskWithSignature(handler, signature)
+	exec, err := task.NewTaskWithSignatureContext(ctx, handler, signature)
 	if err != nil {
-		_ = w.handlerFailed(signature, err)
+		_ = w.handlerFailed(ctx, signature, err)
 		return err
 	}
 
@@ -113,25 +126,26 @@ func (w *Worker) Process(signature *task.Signature) error {
 	// 任务调用
 	results, err := exec.Call()
 	if err != nil {
+		taskErr = err
 		// 判断err是否是可重试错误
 		retryErr, ok := (interface{})(err).(task.ErrRetryTaskLater)
 		if ok {
 			// 重试
-			return w.handlerRetryIn(signature, retryErr.RetryIn())
+			return w.handlerRetryIn(ctx, signature, retryErr.RetryIn())
 		}
 		// 根据自定义重试次数开始重试
 		if signature.RetryCount > 0 {
-			return w.handlerRetry(signature)
+			return w.handlerRetry(ctx, signature)
 		}
 		// 置为失败
-		return w.handlerFailed(signature, err)
+		return w.handlerFailed(ctx, signature, err)
 	}
 	// 任务完成
-	return w.handlerSucceeded(signature, results)
+	return w.handlerSucceeded(ctx, signature, results)
 }
 
 // handlerRetry 处理程序重试
-func (w *Worker) handlerRetry(signature *task.Signature) error {
+func (w *Worker) handlerRetry(ctx context.Context, signature *task.Signature) error {
 	// 设置重试状态
 	if err := w.bindService.GetBackend().SetStateRetry(signature); err != nil {
 		return errors.Wrap(err, "worker set task state to 'retry' error, signature id:"+signature.ID)
@@ -145,12 +159,12 @@ func (w *Worker) handlerRetry(signature *task.Signature) error {
 	eta := time.Now().Add(retryDelay)
 	signature.ETA = &eta
 	w.bindService.helper.Warnf("Task %s failed. Going to retry in %d seconds.", signature.ID, signature.RetryInterval)
-	_, err := w.bindService.SendTask(signature)
+	_, err := w.bindService.SendTaskWithContext(ctx, signature)
 	return err
 }
 
 // handlerRetry 处理指定错误程序重试
-func (w *Worker) handlerRetryIn(signature *task.Signature, retryIn time.Duration) error {
+func (w *Worker) handlerRetryIn(ctx context.Context, signature *task.Signature, retryIn time.Duration) error {
 	// 设置重试状态
 	if err := w.bindService.GetBackend().SetStateRetry(signature); err != nil {
 		return errors.Wrap(err, "worker set task state to 'retry' error, signature id:"+signature.ID)
@@ -158,12 +172,12 @@ func (w *Worker) handlerRetryIn(signature *task.Signature, retryIn time.Duration
 	eta := time.Now().Add(retryIn)
 	signature.ETA = &eta
 	w.bindService.helper.Warnf("Task %s failed. Going to retry in %.0f seconds.", signature.ID, retryIn.Seconds())
-	_, err := w.bindService.SendTask(signature)
+	_, err := w.bindService.SendTaskWithContext(ctx, signature)
 	return err
 }
 
 // handlerSucceeded 处理程序成功状态
-func (w *Worker) handlerSucceeded(signature *task.Signature, results []*task.Result) error {
+func (w *Worker) handlerSucceeded(ctx context.Context, signature *task.Signature, results []*task.Result) error {
 	if err := w.bindService.GetBackend().SetStateSuccess(signature, results); err != nil {
 		return errors.Wrap(err, "worker set task state to 'succeeded' error, signature id:"+signature.ID)
 	}
@@ -181,7 +195,7 @@ func (w *Worker) handlerSucceeded(signature *task.Signature, results []*task.Res
 				},
 			)
 		}
-		if _, err := w.bindService.SendTask(success); err != nil {
+		if _, err := w.bindService.SendTaskWithContext(ctx, success); err != nil {
 			w.reportCallbackPublicationError(signature, success, "success", err)
 		}
 	}
@@ -239,12 +253,12 @@ func (w *Worker) handlerSucceeded(signature *task.Signature, results []*task.Res
 				task.Arg{Type: result.Type, Value: result.Value})
 		}
 	}
-	_, err = w.bindService.SendTask(signature.CallbackChord)
+	_, err = w.bindService.SendTaskWithContext(ctx, signature.CallbackChord)
 	return err
 }
 
 // handlerFailed 处理任务失败状态
-func (w *Worker) handlerFailed(signature *task.Signature, err error) error {
+func (w *Worker) handlerFailed(ctx context.Context, signature *task.Signature, err error) error {
 	if err := w.bindService.GetBackend().SetStateFailure(signature, err.Error()); err != nil {
 		return errors.Wrap(err, "worker set task state to 'succeeded' error, signature id:"+signature.ID)
 	}
@@ -258,7 +272,7 @@ func (w *Worker) handlerFailed(signature *task.Signature, err error) error {
 	}
 	for _, _error := range signature.CallbackOnError {
 		_error.Args = append([]task.Arg{{Type: "string", Value: err.Error()}}, _error.Args...)
-		if _, callbackErr := w.bindService.SendTask(_error); callbackErr != nil {
+		if _, callbackErr := w.bindService.SendTaskWithContext(ctx, _error); callbackErr != nil {
 			w.reportCallbackPublicationError(signature, _error, "error", callbackErr)
 		}
 	}
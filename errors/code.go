@@ -4,11 +4,12 @@ package errors
 // They MUST NOT pass `message` to Errorf — Errorf treats its third
 // argument as a fmt format string, so a message containing `%` produces
 // corrupted output (e.g. "100% wrong" → "100%!w(MISSING)rong").
-// Use New, which takes the message verbatim.
+// Use newError (the implementation of New), which takes the message verbatim
+// and records the stack from the caller of the helper.
 
 // BadRequest new BadRequest error that is mapped to a 400 response.
 func BadRequest(reason, message string) *Error {
-	return New(400, reason, message)
+	return newError(400, reason, message)
 }
 
 // IsBadRequest determines if err is an error which indicates a BadRequest error.
@@ -19,7 +20,7 @@ func IsBadRequest(err error) bool {
 
 // Unauthorized new Unauthorized error that is mapped to a 401 response.
 func Unauthorized(reason, message string) *Error {
-	return New(401, reason, message)
+	return newError(401, reason, message)
 }
 
 // IsUnauthorized determines if err is an error which indicates a Unauthorized error.
@@ -30,7 +31,7 @@ func IsUnauthorized(err error) bool {
 
 // Forbidden new Forbidden error that is mapped to a 403 response.
 func Forbidden(reason, message string) *Error {
-	return New(403, reason, message)
+	return newError(403, reason, message)
 }
 
 // IsForbidden determines if err is an error which indicates a Forbidden error.
@@ -41,7 +42,7 @@ func IsForbidden(err error) bool {
 
 // NotFound new NotFound error that is mapped to a 404 response.
 func NotFound(reason, message string) *Error {
-	return New(404, reason, message)
+	return newError(404, reason, message)
 }
 
 // IsNotFound determines if err is an error which indicates an NotFound error.
@@ -52,7 +53,7 @@ func IsNotFound(err error) bool {
 
 // Conflict new Conflict error that is mapped to a 409 response.
 func Conflict(reason, message string) *Error {
-	return New(409, reason, message)
+	return newError(409, reason, message)
 }
 
 // IsConflict determines if err is an error which indicates a Conflict error.
@@ -63,7 +64,7 @@ func IsConflict(err error) bool {
 
 // InternalServer new InternalServer error that is mapped to a 500 response.
 func InternalServer(reason, message string) *Error {
-	return New(500, reason, message)
+	return newError(500, reason, message)
 }
 
 // IsInternalServer determines if err is an error which indicates an Internal error.
@@ -74,7 +75,7 @@ func IsInternalServer(err error) bool {
 
 // ServiceUnavailable new ServiceUnavailable error that is mapped to a HTTP 503 response.
 func ServiceUnavailable(reason, message string) *Error {
-	return New(503, reason, message)
+	return newError(503, reason, message)
 }
 
 // IsServiceUnavailable determines if err is an error which indicates a Unavailable error.
@@ -85,7 +86,7 @@ func IsServiceUnavailable(err error) bool {
 
 // GatewayTimeout new GatewayTimeout error that is mapped to a HTTP 504 response.
 func GatewayTimeout(reason, message string) *Error {
-	return New(504, reason, message)
+	return newError(504, reason, message)
 }
 
 // IsGatewayTimeout determines if err is an error which indicates a GatewayTimeout error.
@@ -96,7 +97,7 @@ func IsGatewayTimeout(err error) bool {
 
 // ClientClosed new ClientClosed error that is mapped to a HTTP 499 response.
 func ClientClosed(reason, message string) *Error {
-	return New(499, reason, message)
+	return newError(499, reason, message)
 }
 
 // IsClientClosed determines if err is an error which indicates a IsClientClosed error.
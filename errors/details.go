@@ -0,0 +1,185 @@
+package errors
+
+import (
+	"time"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/anypb"
+	"google.golang.org/protobuf/types/known/durationpb"
+)
+
+// WithDetails 返回追加 google.rpc 错误详情的副本, 无法序列化的详情会被忽略
+func (x *Error) WithDetails(details ...proto.Message) *Error {
+	err := x.clone()
+	for _, detail := range details {
+		if a, aErr := anypb.New(detail); aErr == nil {
+			err.Details = append(err.Details, a)
+		}
+	}
+	return err
+}
+
+// WithFieldViolation 返回追加 BadRequest 字段校验错误的副本, 多次调用合并到同一个 BadRequest
+func (x *Error) WithFieldViolation(field, description string) *Error {
+	br := &errdetails.BadRequest{}
+	return x.mergeDetail(br, func() {
+		br.FieldViolations = append(br.FieldViolations, &errdetails.BadRequest_FieldViolation{
+			Field:       field,
+			Description: description,
+		})
+	})
+}
+
+// WithRetryInfo 返回设置 RetryInfo 的副本, 客户端应至少等待 delay 后重试
+func (x *Error) WithRetryInfo(delay time.Duration) *Error {
+	ri := &errdetails.RetryInfo{}
+	return x.mergeDetail(ri, func() {
+		ri.RetryDelay = durationpb.New(delay)
+	})
+}
+
+// WithQuotaViolation 返回追加 QuotaFailure 配额错误的副本
+func (x *Error) WithQuotaViolation(subject, description string) *Error {
+	qf := &errdetails.QuotaFailure{}
+	return x.mergeDetail(qf, func() {
+		qf.Violations = append(qf.Violations, &errdetails.QuotaFailure_Violation{
+			Subject:     subject,
+			Description: description,
+		})
+	})
+}
+
+// WithPreconditionViolation 返回追加 PreconditionFailure 前置条件错误的副本
+func (x *Error) WithPreconditionViolation(typ, subject, description string) *Error {
+	pf := &errdetails.PreconditionFailure{}
+	return x.mergeDetail(pf, func() {
+		pf.Violations = append(pf.Violations, &errdetails.PreconditionFailure_Violation{
+			Type:        typ,
+			Subject:     subject,
+			Description: description,
+		})
+	})
+}
+
+// WithResourceInfo 返回追加 ResourceInfo 的副本
+func (x *Error) WithResourceInfo(resourceType, resourceName, owner, description string) *Error {
+	return x.WithDetails(&errdetails.ResourceInfo{
+		ResourceType: resourceType,
+		ResourceName: resourceName,
+		Owner:        owner,
+		Description:  description,
+	})
+}
+
+// WithLocalizedMessage 返回设置 locale 对应 LocalizedMessage 的副本, 同一 locale 覆盖
+func (x *Error) WithLocalizedMessage(locale, message string) *Error {
+	err := x.clone()
+	for i, a := range err.Details {
+		lm := &errdetails.LocalizedMessage{}
+		if a.MessageIs(lm) && a.UnmarshalTo(lm) == nil && lm.Locale == locale {
+			err.Details = append(err.Details[:i:i], err.Details[i+1:]...)
+			break
+		}
+	}
+	return err.WithDetails(&errdetails.LocalizedMessage{Locale: locale, Message: message})
+}
+
+// FieldViolations 返回 BadRequest 中的字段校验错误
+func (x *Error) FieldViolations() []*errdetails.BadRequest_FieldViolation {
+	var violations []*errdetails.BadRequest_FieldViolation
+	x.rangeDetails(func(m proto.Message) {
+		if br, ok := m.(*errdetails.BadRequest); ok {
+			violations = append(violations, br.FieldViolations...)
+		}
+	})
+	return violations
+}
+
+// RetryDelay 返回 RetryInfo 中的重试间隔
+func (x *Error) RetryDelay() (time.Duration, bool) {
+	var (
+		delay time.Duration
+		found bool
+	)
+	x.rangeDetails(func(m proto.Message) {
+		if ri, ok := m.(*errdetails.RetryInfo); ok && !found {
+			delay, found = ri.GetRetryDelay().AsDuration(), true
+		}
+	})
+	return delay, found
+}
+
+// QuotaViolations 返回 QuotaFailure 中的配额错误
+func (x *Error) QuotaViolations() []*errdetails.QuotaFailure_Violation {
+	var violations []*errdetails.QuotaFailure_Violation
+	x.rangeDetails(func(m proto.Message) {
+		if qf, ok := m.(*errdetails.QuotaFailure); ok {
+			violations = append(violations, qf.Violations...)
+		}
+	})
+	return violations
+}
+
+// PreconditionViolations 返回 PreconditionFailure 中的前置条件错误
+func (x *Error) PreconditionViolations() []*errdetails.PreconditionFailure_Violation {
+	var violations []*errdetails.PreconditionFailure_Violation
+	x.rangeDetails(func(m proto.Message) {
+		if pf, ok := m.(*errdetails.PreconditionFailure); ok {
+			violations = append(violations, pf.Violations...)
+		}
+	})
+	return violations
+}
+
+// ResourceInfos 返回所有 ResourceInfo
+func (x *Error) ResourceInfos() []*errdetails.ResourceInfo {
+	var infos []*errdetails.ResourceInfo
+	x.rangeDetails(func(m proto.Message) {
+		if ri, ok := m.(*errdetails.ResourceInfo); ok {
+			infos = append(infos, ri)
+		}
+	})
+	return infos
+}
+
+// LocalizedMessages 返回所有 LocalizedMessage
+func (x *Error) LocalizedMessages() []*errdetails.LocalizedMessage {
+	var messages []*errdetails.LocalizedMessage
+	x.rangeDetails(func(m proto.Message) {
+		if lm, ok := m.(*errdetails.LocalizedMessage); ok {
+			messages = append(messages, lm)
+		}
+	})
+	return messages
+}
+
+// mergeDetail 将 update 应用到第一个与 m 同类型的详情上, 不存在时追加
+// update 调用前 m 已填充为已有详情的内容
+func (x *Error) mergeDetail(m proto.Message, update func()) *Error {
+	err := x.clone()
+	for i, a := range err.Details {
+		if !a.MessageIs(m) || a.UnmarshalTo(m) != nil {
+			continue
+		}
+		update()
+		if merged, aErr := anypb.New(m); aErr == nil {
+			err.Details[i] = merged
+		}
+		return err
+	}
+	update()
+	return err.WithDetails(m)
+}
+
+// rangeDetails 遍历可解析的详情, 未注册的类型被跳过
+func (x *Error) rangeDetails(f func(proto.Message)) {
+	if x == nil {
+		return
+	}
+	for _, a := range x.Details {
+		if m, err := a.UnmarshalNew(); err == nil {
+			f(m)
+		}
+	}
+}
@@ -0,0 +1,106 @@
+package errors
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/status"
+)
+
+func TestDetailsBuilders(t *testing.T) {
+	base := BadRequest("INVALID_ARGUMENT", "invalid request")
+	err := base.
+		WithFieldViolation("name", "required").
+		WithFieldViolation("age", "must be positive").
+		WithRetryInfo(time.Second).
+		WithRetryInfo(2*time.Second).
+		WithQuotaViolation("project:gkit", "daily limit").
+		WithPreconditionViolation("TOS", "user:1", "terms not accepted").
+		WithResourceInfo("user", "users/1", "gkit", "not found").
+		WithLocalizedMessage("zh-CN", "参数错误").
+		WithLocalizedMessage("zh-CN", "请求参数错误")
+
+	if len(base.Details) != 0 {
+		t.Fatalf("builders modified the receiver: %v", base.Details)
+	}
+	if v := err.FieldViolations(); len(v) != 2 || v[0].Field != "name" || v[1].Field != "age" {
+		t.Fatalf("FieldViolations = %v", v)
+	}
+	if d, ok := err.RetryDelay(); !ok || d != 2*time.Second {
+		t.Fatalf("RetryDelay = (%v, %v), want 2s", d, ok)
+	}
+	if v := err.QuotaViolations(); len(v) != 1 || v[0].Subject != "project:gkit" {
+		t.Fatalf("QuotaViolations = %v", v)
+	}
+	if v := err.PreconditionViolations(); len(v) != 1 || v[0].Type != "TOS" {
+		t.Fatalf("PreconditionViolations = %v", v)
+	}
+	if v := err.ResourceInfos(); len(v) != 1 || v[0].ResourceName != "users/1" {
+		t.Fatalf("ResourceInfos = %v", v)
+	}
+	if v := err.LocalizedMessages(); len(v) != 1 || v[0].Message != "请求参数错误" {
+		t.Fatalf("LocalizedMessages = %v", v)
+	}
+	// BadRequest, RetryInfo, QuotaFailure, PreconditionFailure, ResourceInfo, LocalizedMessage
+	if len(err.Details) != 6 {
+		t.Fatalf("len(Details) = %d, want 6", len(err.Details))
+	}
+}
+
+func TestDetailsGRPCRoundTrip(t *testing.T) {
+	err := BadRequest("INVALID_ARGUMENT", "invalid request").
+		AddMetadata(map[string]string{"k": "v"}).
+		WithFieldViolation("name", "required").
+		WithRetryInfo(time.Second)
+
+	gs := err.GRPCStatus()
+	if details := gs.Details(); len(details) != 3 {
+		t.Fatalf("grpc details = %v, want ErrorInfo + 2", details)
+	} else if _, ok := details[0].(*errdetails.ErrorInfo); !ok {
+		t.Fatalf("first grpc detail = %T, want ErrorInfo", details[0])
+	}
+
+	se := FromError(fmt.Errorf("call: %w", gs.Err()))
+	if se.Code != 400 || se.Reason != "INVALID_ARGUMENT" || se.Metadata["k"] != "v" {
+		t.Fatalf("FromError = %v", se)
+	}
+	if v := se.FieldViolations(); len(v) != 1 || v[0].Field != "name" {
+		t.Fatalf("FieldViolations after round trip = %v", v)
+	}
+	if d, ok := se.RetryDelay(); !ok || d != time.Second {
+		t.Fatalf("RetryDelay after round trip = (%v, %v)", d, ok)
+	}
+
+	// 非 gkit 生成的 status 同样保留详情
+	plain, _ := status.New(gs.Code(), "plain").WithDetails(&errdetails.QuotaFailure{
+		Violations: []*errdetails.QuotaFailure_Violation{{Subject: "s"}},
+	})
+	if se := FromError(plain.Err()); se.Reason != UnknownReason || len(se.QuotaViolations()) != 1 {
+		t.Fatalf("FromError(plain) = %v, quota = %v", se, se.QuotaViolations())
+	}
+}
+
+func TestDetailsJSON(t *testing.T) {
+	err := BadRequest("INVALID_ARGUMENT", "invalid request").WithFieldViolation("name", "required")
+	body, mErr := json.Marshal(err)
+	if mErr != nil {
+		t.Fatal(mErr)
+	}
+	for _, want := range []string{`"code":400`, `"reason":"INVALID_ARGUMENT"`, `"@type":"type.googleapis.com/google.rpc.BadRequest"`, `"field":"name"`} {
+		if !strings.Contains(strings.ReplaceAll(string(body), " ", ""), want) {
+			t.Fatalf("json = %s, want to contain %s", body, want)
+		}
+	}
+
+	decoded := &Error{}
+	if uErr := json.Unmarshal(body, decoded); uErr != nil {
+		t.Fatal(uErr)
+	}
+	if decoded.Code != 400 || decoded.Reason != "INVALID_ARGUMENT" || len(decoded.FieldViolations()) != 1 {
+		t.Fatalf("decoded = %v, violations = %v", decoded, decoded.FieldViolations())
+	}
+}
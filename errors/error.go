@@ -3,11 +3,15 @@ package errors
 import (
 	"errors"
 	"fmt"
+	"io"
+	"sync/atomic"
 
-	"github.com/golang/protobuf/proto"
 	httputil "github.com/songzhibin97/gkit/errors/internal"
 	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	spb "google.golang.org/genproto/googleapis/rpc/status"
 	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/types/known/anypb"
 )
 
 //go:generate protoc -I. --go_out=paths=source_relative:. errors.proto
@@ -21,24 +25,75 @@ const (
 
 var ErrDetails = errors.New("no error details for status with code OK")
 
+// captureStack 非 0 时 New、Errorf 等构造函数记录调用栈
+var captureStack int32
+
+// SetCaptureStack 设置构造 Error 时是否记录调用栈, 默认关闭
+// 开启后 New、Errorf、BadRequest 等构造函数记录调用方的调用栈, 通过 %+v 输出;
+// 关闭时可以使用 WithStack 单独记录
+func SetCaptureStack(enable bool) {
+	var v int32
+	if enable {
+		v = 1
+	}
+	atomic.StoreInt32(&captureStack, v)
+}
+
+// Error 错误
+// Status 为传输格式, cause 与 stack 仅在进程内可见, 不会被序列化
+//
+// 不兼容变更: 原 proto 消息 Error 更名为 Status 并嵌入 Error,
+// 字段仍可通过 err.Code 等方式读写, 但复合字面量需要改为 &Error{Status: Status{Code: ...}}, 推荐使用 New
+type Error struct {
+	Status
+	cause error
+	stack *stack
+}
+
 // Error 实现Error接口
 func (x *Error) Error() string {
 	return fmt.Sprintf("error: code = %d reason = %s message = %s details = %v", x.Code, x.Reason, x.Message, x.Metadata)
 }
 
+// Unwrap 返回 cause, 使 errors.Is/As 可以匹配到底层错误
+func (x *Error) Unwrap() error {
+	return x.cause
+}
+
 // StatusCode HTTP code
 func (x *Error) StatusCode() int {
 	return int(x.Code)
 }
 
 // GRPCStatus 返回grpc status
+// details 依次为 ErrorInfo 以及 Details 中的详情
 func (x *Error) GRPCStatus() *status.Status {
-	s, _ := status.New(httputil.GRPCCodeFromStatus(x.StatusCode()), x.Message).
-		WithDetails(&errdetails.ErrorInfo{
-			Reason:   x.Reason,
-			Metadata: x.Metadata,
-		})
-	return s
+	details := make([]*anypb.Any, 0, len(x.Details)+1)
+	if info, err := anypb.New(&errdetails.ErrorInfo{
+		Reason:   x.Reason,
+		Metadata: x.Metadata,
+	}); err == nil {
+		details = append(details, info)
+	}
+	details = append(details, x.Details...)
+	return status.FromProto(&spb.Status{
+		Code:    int32(httputil.GRPCCodeFromStatus(x.StatusCode())),
+		Message: x.Message,
+		Details: details,
+	})
+}
+
+// MarshalJSON 以 protojson 格式输出
+// code、reason、message、metadata 与之前的 encoding/json 输出一致,
+// 新增的 details 为带有 @type 的详情数组, 详情字段使用 lowerCamelCase;
+// protojson 不保证输出的空白字符稳定, 不要按字节比较输出
+func (x *Error) MarshalJSON() ([]byte, error) {
+	return protojson.Marshal(&x.Status)
+}
+
+// UnmarshalJSON 解析 MarshalJSON 的输出
+func (x *Error) UnmarshalJSON(data []byte) error {
+	return protojson.UnmarshalOptions{DiscardUnknown: true}.Unmarshal(data, &x.Status)
 }
 
 // Is 跟 target Error 比较 判断是否相等
@@ -50,24 +105,96 @@ func (x *Error) Is(target error) bool {
 	}
 }
 
+// clone 复制 Error, metadata 深拷贝, cause 与 stack 共享
+func (x *Error) clone() *Error {
+	if x == nil {
+		return nil
+	}
+	var metadata map[string]string
+	if x.Metadata != nil {
+		metadata = make(map[string]string, len(x.Metadata))
+		for k, v := range x.Metadata {
+			metadata[k] = v
+		}
+	}
+	return &Error{
+		Status: Status{
+			Code:     x.Code,
+			Reason:   x.Reason,
+			Message:  x.Message,
+			Metadata: metadata,
+			Details:  append([]*anypb.Any(nil), x.Details...),
+		},
+		cause: x.cause,
+		stack: x.stack,
+	}
+}
+
 // AddMetadata 增加metadata
 func (x *Error) AddMetadata(mp map[string]string) *Error {
-	err := proto.Clone(x).(*Error)
+	err := x.clone()
 	err.Metadata = mp
 	return err
 }
 
-// New 实例化 Error 对象
-func New(code int, reason, message string) *Error {
-	return &Error{
-		Code:    int32(code),
-		Reason:  reason,
-		Message: message,
+// WithCause 返回携带底层错误 cause 的副本
+func (x *Error) WithCause(cause error) *Error {
+	err := x.clone()
+	err.cause = cause
+	return err
+}
+
+// WithStack 返回记录当前调用栈的副本
+func (x *Error) WithStack() *Error {
+	err := x.clone()
+	err.stack = callers(3)
+	return err
+}
+
+// Format 实现 fmt.Formatter
+// %+v 输出错误、调用栈以及 cause 链
+func (x *Error) Format(s fmt.State, verb rune) {
+	switch verb {
+	case 'v':
+		if s.Flag('+') {
+			_, _ = io.WriteString(s, x.Error())
+			x.stack.Format(s, verb)
+			if x.cause != nil {
+				_, _ = fmt.Fprintf(s, "\ncaused by: %+v", x.cause)
+			}
+			return
+		}
+		fallthrough
+	case 's':
+		_, _ = io.WriteString(s, x.Error())
+	case 'q':
+		_, _ = fmt.Fprintf(s, "%q", x.Error())
 	}
 }
 
+// New 实例化 Error 对象, SetCaptureStack 开启时记录调用栈
+func New(code int, reason, message string) *Error {
+	return newError(code, reason, message)
+}
+
+// Errorf 以 format 格式化 message 实例化 Error 对象, SetCaptureStack 开启时记录调用栈
 func Errorf(code int, reason, format string, a ...interface{}) *Error {
-	return New(code, reason, fmt.Sprintf(format, a...))
+	return newError(code, reason, fmt.Sprintf(format, a...))
+}
+
+// newError 实例化 Error 对象, 只能由导出的构造函数直接调用, 调用栈从构造函数的调用方开始记录
+func newError(code int, reason, message string) *Error {
+	err := &Error{
+		Status: Status{
+			Code:    int32(code),
+			Reason:  reason,
+			Message: message,
+		},
+	}
+	if atomic.LoadInt32(&captureStack) != 0 {
+		err.stack = callers(4)
+	}
+	return err
 }
 
 func FromError(err error) *Error {
@@ -79,23 +206,20 @@ func FromError(err error) *Error {
 	}
 	gs, ok := status.FromError(err)
 	if ok {
-		for _, detail := range gs.Details() {
-			switch d := detail.(type) {
-			case *errdetails.ErrorInfo:
-				return New(
-					httputil.StatusFromGRPCCode(gs.Code()),
-					d.Reason,
-					gs.Message(),
-				).AddMetadata(d.Metadata)
+		se := New(httputil.StatusFromGRPCCode(gs.Code()), UnknownReason, gs.Message())
+		// 第一个 ErrorInfo 还原为 reason 与 metadata, 其余详情保留在 Details 中
+		hasInfo := false
+		for _, detail := range gs.Proto().GetDetails() {
+			info := &errdetails.ErrorInfo{}
+			if !hasInfo && detail.MessageIs(info) && detail.UnmarshalTo(info) == nil {
+				se.Reason, se.Metadata, hasInfo = info.Reason, info.Metadata, true
+				continue
 			}
+			se.Details = append(se.Details, detail)
 		}
-		return New(
-			httputil.StatusFromGRPCCode(gs.Code()),
-			UnknownReason,
-			gs.Message(),
-		)
+		return se.WithCause(err)
 	}
-	return New(UnknownCode, UnknownReason, err.Error())
+	return New(UnknownCode, UnknownReason, err.Error()).WithCause(err)
 }
 
 // Code 返回err指定的错误码
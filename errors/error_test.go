@@ -3,8 +3,14 @@ package errors
 import (
 	"errors"
 	"fmt"
+	"io"
 	"net/http"
+	"strings"
 	"testing"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
 )
 
 func TestError(t *testing.T) {
@@ -97,3 +103,89 @@ func TestCode(t *testing.T) {
 		}
 	}
 }
+
+type causeError struct{ msg string }
+
+func (e *causeError) Error() string { return e.msg }
+
+func TestWithCause(t *testing.T) {
+	root := &causeError{msg: "connection refused"}
+	err := InternalServer("DB", "query failed").WithCause(fmt.Errorf("query: %w", root))
+
+	var target *causeError
+	if !errors.As(err, &target) || target != root {
+		t.Fatalf("As(%v) = %v, want root cause", err, target)
+	}
+	if !errors.Is(err, root) {
+		t.Fatal("Is should match the root cause")
+	}
+	if got := err.AddMetadata(map[string]string{"k": "v"}); !errors.Is(got, root) {
+		t.Fatal("AddMetadata should keep the cause")
+	}
+
+	// cause 不进入传输格式
+	se := FromError(err.GRPCStatus().Err())
+	if se.Reason != "DB" || se.Message != "query failed" || errors.Is(se, root) {
+		t.Fatalf("FromError(GRPCStatus) = %v, want reason/message without root cause", se)
+	}
+	body, mErr := proto.Marshal(&err.Status)
+	if mErr != nil {
+		t.Fatal(mErr)
+	}
+	if strings.Contains(string(body), root.msg) {
+		t.Fatal("cause leaked into the wire format")
+	}
+}
+
+func TestFromErrorKeepsCause(t *testing.T) {
+	root := io.ErrUnexpectedEOF
+	se := FromError(fmt.Errorf("read: %w", root))
+	if se.Code != UnknownCode || !errors.Is(se, root) {
+		t.Fatalf("FromError = %v, want unknown code with cause", se)
+	}
+	gs := status.New(codes.NotFound, "missing")
+	if se := FromError(gs.Err()); se.Code != http.StatusNotFound || errors.Unwrap(se) == nil {
+		t.Fatalf("FromError(grpc) = %v, want 404 with cause", se)
+	}
+}
+
+func TestFormat(t *testing.T) {
+	err := BadRequest("INVALID", "bad input").WithStack().WithCause(io.EOF)
+	if got := fmt.Sprintf("%v", err); got != err.Error() {
+		t.Fatalf("%%v = %q, want %q", got, err.Error())
+	}
+	if got := fmt.Sprintf("%s", err); got != err.Error() {
+		t.Fatalf("%%s = %q, want %q", got, err.Error())
+	}
+	verbose := fmt.Sprintf("%+v", err)
+	for _, want := range []string{err.Error(), "errors.TestFormat", "error_test.go", "caused by: EOF"} {
+		if !strings.Contains(verbose, want) {
+			t.Fatalf("%%+v = %q, want to contain %q", verbose, want)
+		}
+	}
+	if strings.Contains(fmt.Sprintf("%+v", BadRequest("r", "m")), "\n") {
+		t.Fatal("verbose format without stack or cause should be a single line")
+	}
+}
+
+func TestCaptureStack(t *testing.T) {
+	SetCaptureStack(true)
+	defer SetCaptureStack(false)
+
+	for _, err := range []*Error{
+		New(400, "r", "m"),
+		Errorf(400, "r", "%s", "m"),
+		BadRequest("r", "m"),
+	} {
+		// 第一帧为构造函数的调用方
+		lines := strings.Split(fmt.Sprintf("%+v", err), "\n")
+		if len(lines) < 3 || lines[1] != "github.com/songzhibin97/gkit/errors.TestCaptureStack" {
+			t.Fatalf("%%+v = %q, want the first frame to be the caller", lines)
+		}
+	}
+
+	SetCaptureStack(false)
+	if strings.Contains(fmt.Sprintf("%+v", New(400, "r", "m")), "\n") {
+		t.Fatal("stack captured after SetCaptureStack(false)")
+	}
+}
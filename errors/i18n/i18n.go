@@ -0,0 +1,250 @@
+package i18n
+
+import (
+	stderrors "errors"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"text/template"
+
+	"github.com/songzhibin97/gkit/coding"
+	"github.com/songzhibin97/gkit/coding/json"
+	"github.com/songzhibin97/gkit/coding/yaml"
+	"github.com/songzhibin97/gkit/errors"
+	"github.com/songzhibin97/gkit/options"
+)
+
+// package i18n: 按 reason 与 locale 本地化 errors.Error 的 Message
+//
+// 消息文件格式(yaml), json 格式相同:
+//
+//	USER_NOT_FOUND:
+//	  en: "user {{.id}} not found"
+//	  zh-CN: "用户 {{.id}} 不存在"
+//
+// 模板参数取自 errors.Error 的 Metadata, 缺失的参数输出为空
+
+// ErrUnknownFormat 无法根据文件扩展名确定格式
+var ErrUnknownFormat = stderrors.New("i18n: unknown catalog format")
+
+type config struct {
+	// defaultLocale: 请求的 locale 都不存在时使用
+	defaultLocale string
+}
+
+// SetDefaultLocale 设置默认 locale, 默认 en
+func SetDefaultLocale(locale string) options.Option {
+	return func(o interface{}) {
+		o.(*config).defaultLocale = locale
+	}
+}
+
+// Catalog 消息目录, reason -> locale -> 消息模板
+// Concurrency safety
+type Catalog struct {
+	conf *config
+
+	mu       sync.RWMutex
+	messages map[string]map[string]entry
+}
+
+// entry 消息模板, locale 保留添加时的写法
+type entry struct {
+	locale string
+	tpl    *template.Template
+}
+
+// NewCatalog 实例化消息目录
+func NewCatalog(opts ...options.Option) *Catalog {
+	conf := &config{defaultLocale: "en"}
+	for _, opt := range opts {
+		opt(conf)
+	}
+	conf.defaultLocale = normalize(conf.defaultLocale)
+	return &Catalog{conf: conf, messages: make(map[string]map[string]entry)}
+}
+
+// Add 添加 reason 在 locale 下的消息模板, 已存在时覆盖
+func (c *Catalog) Add(reason, locale, message string) error {
+	tpl, err := template.New(reason).Option("missingkey=zero").Parse(message)
+	if err != nil {
+		return fmt.Errorf("i18n: parse %s/%s: %w", reason, locale, err)
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	locales, ok := c.messages[reason]
+	if !ok {
+		locales = make(map[string]entry)
+		c.messages[reason] = locales
+	}
+	locales[normalize(locale)] = entry{locale: strings.TrimSpace(locale), tpl: tpl}
+	return nil
+}
+
+// Load 使用 coding 中名为 codeName 的编码器解析 data 并添加到目录
+func (c *Catalog) Load(codeName string, data []byte) error {
+	code := coding.GetCode(codeName)
+	if code == nil {
+		return ErrUnknownFormat
+	}
+	var messages map[string]map[string]string
+	if err := code.Unmarshal(data, &messages); err != nil {
+		return fmt.Errorf("i18n: decode catalog: %w", err)
+	}
+	for reason, locales := range messages {
+		for locale, message := range locales {
+			if err := c.Add(reason, locale, message); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// LoadFile 根据扩展名(.yaml/.yml/.json)解析消息文件并添加到目录
+func (c *Catalog) LoadFile(path string) error {
+	var codeName string
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		codeName = yaml.Name
+	case ".json":
+		codeName = json.Name
+	default:
+		return ErrUnknownFormat
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	return c.Load(codeName, data)
+}
+
+// Message 返回 reason 在 locales 中第一个可用 locale 下的消息, 以及实际使用的 locale
+// 依次尝试每个 locale 及其基础语言(zh-CN -> zh), 最后使用默认 locale
+func (c *Catalog) Message(reason string, metadata map[string]string, locales ...string) (string, string, bool) {
+	e, ok := c.lookup(reason, locales)
+	if !ok {
+		return "", "", false
+	}
+	data := metadata
+	if data == nil {
+		data = map[string]string{}
+	}
+	var b strings.Builder
+	if err := e.tpl.Execute(&b, data); err != nil {
+		return "", "", false
+	}
+	return b.String(), e.locale, true
+}
+
+// lookup 按优先级查找消息模板
+func (c *Catalog) lookup(reason string, locales []string) (entry, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	entries, ok := c.messages[reason]
+	if !ok {
+		return entry{}, false
+	}
+	for _, locale := range candidates(locales, c.conf.defaultLocale) {
+		if e, ok := entries[locale]; ok {
+			return e, true
+		}
+	}
+	return entry{}, false
+}
+
+// Localize 返回 Message 替换为本地化消息并附带 LocalizedMessage 详情的错误
+// 目录中没有对应消息时返回 errors.FromError(err)
+func (c *Catalog) Localize(err error, locales ...string) *errors.Error {
+	se := errors.FromError(err)
+	if se == nil {
+		return nil
+	}
+	message, locale, ok := c.Message(se.Reason, se.Metadata, locales...)
+	if !ok {
+		return se
+	}
+	localized := se.WithLocalizedMessage(locale, message)
+	localized.Message = message
+	return localized
+}
+
+// LocalizeRequest 根据请求的 Accept-Language 本地化错误
+func (c *Catalog) LocalizeRequest(r *http.Request, err error) *errors.Error {
+	var locales []string
+	if r != nil {
+		locales = ParseAcceptLanguage(r.Header.Get("Accept-Language"))
+	}
+	return c.Localize(err, locales...)
+}
+
+// ParseAcceptLanguage 解析 Accept-Language, 按权重从高到低返回 locale, 忽略 * 与 q=0
+func ParseAcceptLanguage(header string) []string {
+	type weighted struct {
+		locale string
+		q      float64
+	}
+	var tags []weighted
+	for _, part := range strings.Split(header, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		locale, q := part, 1.0
+		if i := strings.Index(part, ";"); i >= 0 {
+			locale = strings.TrimSpace(part[:i])
+			for _, param := range strings.Split(part[i+1:], ";") {
+				param = strings.TrimSpace(param)
+				if strings.HasPrefix(param, "q=") {
+					if v, err := strconv.ParseFloat(param[2:], 64); err == nil {
+						q = v
+					}
+				}
+			}
+		}
+		if locale == "" || locale == "*" || q <= 0 {
+			continue
+		}
+		tags = append(tags, weighted{locale: locale, q: q})
+	}
+	sort.SliceStable(tags, func(i, j int) bool {
+		return tags[i].q > tags[j].q
+	})
+	locales := make([]string, 0, len(tags))
+	for _, tag := range tags {
+		locales = append(locales, tag.locale)
+	}
+	return locales
+}
+
+// candidates 返回按优先级去重后的 locale, 每个 locale 后紧跟其基础语言
+func candidates(locales []string, defaultLocale string) []string {
+	seen := make(map[string]struct{}, len(locales)*2+1)
+	out := make([]string, 0, len(locales)*2+1)
+	add := func(locale string) {
+		if _, ok := seen[locale]; ok || locale == "" {
+			return
+		}
+		seen[locale] = struct{}{}
+		out = append(out, locale)
+	}
+	for _, locale := range locales {
+		locale = normalize(locale)
+		add(locale)
+		if i := strings.Index(locale, "-"); i > 0 {
+			add(locale[:i])
+		}
+	}
+	add(defaultLocale)
+	return out
+}
+
+// normalize 统一 locale 格式: zh_cn -> zh-cn
+func normalize(locale string) string {
+	return strings.ToLower(strings.ReplaceAll(strings.TrimSpace(locale), "_", "-"))
+}
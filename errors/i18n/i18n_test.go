@@ -0,0 +1,154 @@
+package i18n
+
+import (
+	"net/http"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/songzhibin97/gkit/errors"
+)
+
+const catalogYAML = `
+USER_NOT_FOUND:
+  en: "user {{.id}} not found"
+  zh: "用户 {{.id}} 不存在"
+  zh-TW: "使用者 {{.id}} 不存在"
+`
+
+const catalogJSON = `{"QUOTA_EXCEEDED": {"en": "quota {{.limit}} exceeded", "fr": "quota {{.limit}} dépassé"}}`
+
+func newTestCatalog(t *testing.T) *Catalog {
+	t.Helper()
+	c := NewCatalog()
+	dir := t.TempDir()
+	for name, data := range map[string]string{"a.yaml": catalogYAML, "b.json": catalogJSON} {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(data), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		if err := c.LoadFile(path); err != nil {
+			t.Fatalf("LoadFile(%s): %v", name, err)
+		}
+	}
+	return c
+}
+
+func TestCatalogMessage(t *testing.T) {
+	c := newTestCatalog(t)
+	md := map[string]string{"id": "42", "limit": "10"}
+
+	tests := []struct {
+		reason  string
+		locales []string
+		msg     string
+		locale  string
+		ok      bool
+	}{
+		{"USER_NOT_FOUND", nil, "user 42 not found", "en", true},
+		{"USER_NOT_FOUND", []string{"zh-TW"}, "使用者 42 不存在", "zh-TW", true},
+		{"USER_NOT_FOUND", []string{"zh_tw"}, "使用者 42 不存在", "zh-TW", true},
+		{"USER_NOT_FOUND", []string{"zh-CN"}, "用户 42 不存在", "zh", true},
+		{"USER_NOT_FOUND", []string{"de", "zh-CN"}, "用户 42 不存在", "zh", true},
+		{"USER_NOT_FOUND", []string{"de"}, "user 42 not found", "en", true},
+		{"QUOTA_EXCEEDED", []string{"fr-CA"}, "quota 10 dépassé", "fr", true},
+		{"UNKNOWN", []string{"en"}, "", "", false},
+	}
+	for _, tt := range tests {
+		msg, locale, ok := c.Message(tt.reason, md, tt.locales...)
+		if msg != tt.msg || locale != tt.locale || ok != tt.ok {
+			t.Errorf("Message(%s, %v) = (%q, %q, %v), want (%q, %q, %v)",
+				tt.reason, tt.locales, msg, locale, ok, tt.msg, tt.locale, tt.ok)
+		}
+	}
+
+	// 缺失的参数输出为空
+	if msg, _, _ := c.Message("USER_NOT_FOUND", nil); msg != "user  not found" {
+		t.Errorf("missing metadata: got %q", msg)
+	}
+}
+
+func TestCatalogDefaultLocale(t *testing.T) {
+	c := NewCatalog(SetDefaultLocale("zh"))
+	if err := c.Load("yaml", []byte(catalogYAML)); err != nil {
+		t.Fatal(err)
+	}
+	if msg, locale, _ := c.Message("USER_NOT_FOUND", map[string]string{"id": "1"}, "de"); msg != "用户 1 不存在" || locale != "zh" {
+		t.Errorf("got (%q, %q)", msg, locale)
+	}
+}
+
+func TestCatalogLoadError(t *testing.T) {
+	c := NewCatalog()
+	if err := c.Load("toml", nil); err != ErrUnknownFormat {
+		t.Errorf("Load unknown code: %v", err)
+	}
+	if err := c.LoadFile("messages.txt"); err != ErrUnknownFormat {
+		t.Errorf("LoadFile unknown ext: %v", err)
+	}
+	if err := c.Load("json", []byte(`{"R": {"en": "{{.id"}}`)); err == nil {
+		t.Error("expected template parse error")
+	}
+}
+
+func TestLocalize(t *testing.T) {
+	c := newTestCatalog(t)
+	origin := errors.NotFound("USER_NOT_FOUND", "user not found").AddMetadata(map[string]string{"id": "7"})
+
+	err := c.Localize(origin, "zh-CN")
+	if err.Code != origin.Code || err.Reason != origin.Reason {
+		t.Fatalf("code/reason changed: %d %s", err.Code, err.Reason)
+	}
+	if err.Message != "用户 7 不存在" {
+		t.Errorf("Message = %q", err.Message)
+	}
+	lms := err.LocalizedMessages()
+	if len(lms) != 1 || lms[0].Locale != "zh" || lms[0].Message != "用户 7 不存在" {
+		t.Errorf("LocalizedMessages = %v", lms)
+	}
+	if origin.Message != "user not found" || len(origin.Details) != 0 {
+		t.Errorf("origin modified: %v", origin)
+	}
+
+	// 目录中没有对应 reason 时原样返回
+	other := errors.BadRequest("OTHER", "bad request")
+	if got := c.Localize(other, "zh"); got.Message != "bad request" || len(got.Details) != 0 {
+		t.Errorf("unexpected localization: %v", got)
+	}
+	if c.Localize(nil) != nil {
+		t.Error("Localize(nil) should be nil")
+	}
+}
+
+func TestLocalizeRequest(t *testing.T) {
+	c := newTestCatalog(t)
+	r, _ := http.NewRequest(http.MethodGet, "/", nil)
+	r.Header.Set("Accept-Language", "de;q=0.9, zh-TW, en;q=0.5")
+
+	err := c.LocalizeRequest(r, errors.NotFound("USER_NOT_FOUND", "").AddMetadata(map[string]string{"id": "3"}))
+	if err.Message != "使用者 3 不存在" {
+		t.Errorf("Message = %q", err.Message)
+	}
+	if err = c.LocalizeRequest(nil, errors.NotFound("USER_NOT_FOUND", "")); err.Message != "user  not found" {
+		t.Errorf("nil request: Message = %q", err.Message)
+	}
+}
+
+func TestParseAcceptLanguage(t *testing.T) {
+	tests := []struct {
+		header string
+		want   []string
+	}{
+		{"", []string{}},
+		{"zh-CN", []string{"zh-CN"}},
+		{"en;q=0.5, zh-CN, fr;q=0.8", []string{"zh-CN", "fr", "en"}},
+		{"de, *;q=0.1, ja;q=0", []string{"de"}},
+		{"en-US;q=0.7;level=1, en;q=0.7, it;q=bad", []string{"it", "en-US", "en"}},
+	}
+	for _, tt := range tests {
+		if got := ParseAcceptLanguage(tt.header); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("ParseAcceptLanguage(%q) = %v, want %v", tt.header, got, tt.want)
+		}
+	}
+}
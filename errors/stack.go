@@ -0,0 +1,35 @@
+package errors
+
+import (
+	"fmt"
+	"runtime"
+)
+
+// maxStackDepth 记录调用栈的最大深度
+const maxStackDepth = 32
+
+// stack 调用栈
+type stack []uintptr
+
+// callers 记录调用栈, skip 为跳过的栈帧数
+func callers(skip int) *stack {
+	var pcs [maxStackDepth]uintptr
+	n := runtime.Callers(skip, pcs[:])
+	var st stack = pcs[0:n]
+	return &st
+}
+
+// Format 以 %+v 输出每一帧的函数名及文件行号
+func (s *stack) Format(st fmt.State, verb rune) {
+	if s == nil || verb != 'v' || !st.Flag('+') {
+		return
+	}
+	frames := runtime.CallersFrames(*s)
+	for {
+		frame, more := frames.Next()
+		_, _ = fmt.Fprintf(st, "\n%s\n\t%s:%d", frame.Function, frame.File, frame.Line)
+		if !more {
+			return
+		}
+	}
+}
@@ -0,0 +1,48 @@
+package errors
+
+import (
+	"encoding/xml"
+	"sort"
+)
+
+// xmlError Error 的 xml 格式, details 无法表示为 xml, 不输出
+type xmlError struct {
+	Code     int32      `xml:"code"`
+	Reason   string     `xml:"reason,omitempty"`
+	Message  string     `xml:"message,omitempty"`
+	Metadata []xmlEntry `xml:"metadata>entry,omitempty"`
+}
+
+type xmlEntry struct {
+	Key   string `xml:"key,attr"`
+	Value string `xml:",chardata"`
+}
+
+// MarshalXML 实现 xml.Marshaler, 根元素为 error, metadata 按 key 排序输出
+func (x *Error) MarshalXML(e *xml.Encoder, _ xml.StartElement) error {
+	v := xmlError{Code: x.Code, Reason: x.Reason, Message: x.Message}
+	for k, val := range x.Metadata {
+		v.Metadata = append(v.Metadata, xmlEntry{Key: k, Value: val})
+	}
+	sort.Slice(v.Metadata, func(i, j int) bool {
+		return v.Metadata[i].Key < v.Metadata[j].Key
+	})
+	return e.EncodeElement(v, xml.StartElement{Name: xml.Name{Local: "error"}})
+}
+
+// UnmarshalXML 实现 xml.Unmarshaler
+func (x *Error) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
+	var v xmlError
+	if err := d.DecodeElement(&v, &start); err != nil {
+		return err
+	}
+	x.Code, x.Reason, x.Message = v.Code, v.Reason, v.Message
+	x.Metadata = nil
+	if len(v.Metadata) > 0 {
+		x.Metadata = make(map[string]string, len(v.Metadata))
+		for _, entry := range v.Metadata {
+			x.Metadata[entry.Key] = entry.Value
+		}
+	}
+	return nil
+}
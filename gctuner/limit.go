@@ -0,0 +1,169 @@
+package gctuner
+
+import (
+	"math"
+	"runtime/debug"
+	"time"
+
+	"github.com/songzhibin97/gkit/metrics"
+	"github.com/songzhibin97/gkit/options"
+)
+
+const (
+	defaultThresholdRatio   = 0.7
+	defaultMemoryLimitRatio = 0.9
+	defaultRefreshInterval  = 10 * time.Second
+)
+
+// Event is a tuning decision, reported after every GC.
+type Event struct {
+	Inuse       uint64 // heap inuse in bytes
+	Threshold   uint64 // threshold in bytes
+	GCPercent   uint32 // the chosen GC percent
+	Limit       uint64 // memory hard limit in bytes, host/cgroup or WithLimit
+	MemoryLimit int64  // soft memory limit set by debug.SetMemoryLimit, math.MaxInt64 means no limit
+}
+
+type config struct {
+	// limit: fixed memory hard limit, 0 reads the host/cgroup limit
+	limit     uint64
+	useCGroup bool
+
+	// thresholdRatio: threshold = limit * thresholdRatio
+	thresholdRatio float64
+	// memoryLimitRatio: soft memory limit = limit * memoryLimitRatio, <= 0 not set
+	memoryLimitRatio float64
+
+	// refresh: interval to read the host/cgroup limit again
+	refresh time.Duration
+
+	callback func(Event)
+	gauge    metrics.Gauge
+}
+
+// WithLimit sets a fixed memory hard limit instead of reading the host/cgroup limit.
+func WithLimit(limit uint64) options.Option {
+	return func(o interface{}) {
+		o.(*config).limit = limit
+	}
+}
+
+// WithCGroup reads the memory hard limit from cgroup v2 memory.max, or cgroup v1 memory.limit_in_bytes.
+func WithCGroup() options.Option {
+	return func(o interface{}) {
+		o.(*config).useCGroup = true
+	}
+}
+
+// WithThresholdRatio sets the threshold as the ratio of the memory hard limit, default 0.7.
+func WithThresholdRatio(ratio float64) options.Option {
+	return func(o interface{}) {
+		o.(*config).thresholdRatio = ratio
+	}
+}
+
+// WithMemoryLimitRatio sets the soft memory limit as the ratio of the memory hard limit, default 0.9.
+// ratio <= 0 leaves the soft memory limit (GOMEMLIMIT) untouched.
+func WithMemoryLimitRatio(ratio float64) options.Option {
+	return func(o interface{}) {
+		o.(*config).memoryLimitRatio = ratio
+	}
+}
+
+// WithRefreshInterval sets the interval to read the host/cgroup limit again, default 10s,
+// so the limit changed at runtime takes effect. The limit is read in the GC callback.
+func WithRefreshInterval(d time.Duration) options.Option {
+	return func(o interface{}) {
+		o.(*config).refresh = d
+	}
+}
+
+// WithCallback sets the callback receiving every tuning event.
+// It's called after GC serially and should return quickly.
+func WithCallback(callback func(Event)) options.Option {
+	return func(o interface{}) {
+		o.(*config).callback = callback
+	}
+}
+
+// WithGauge sets the gauge of the tuning events,
+// the label value is one of inuse, threshold, gc_percent, limit and memory_limit.
+func WithGauge(gauge metrics.Gauge) options.Option {
+	return func(o interface{}) {
+		o.(*config).gauge = gauge
+	}
+}
+
+// TuningWithMemoryLimit tunes GC with both the soft memory limit and the GC percent.
+// The soft memory limit (debug.SetMemoryLimit) protects the process from OOM, while the GC percent
+// bounded by SetMinGCPercent/SetMaxGCPercent reduces GC times when the heap is far away from the threshold.
+// The previous tuner is replaced, and Tuning(0) disables it and restores the soft memory limit.
+func TuningWithMemoryLimit(opts ...options.Option) {
+	conf := &config{
+		thresholdRatio:   defaultThresholdRatio,
+		memoryLimitRatio: defaultMemoryLimitRatio,
+		refresh:          defaultRefreshInterval,
+	}
+	for _, opt := range opts {
+		opt(conf)
+	}
+
+	tuningMu.Lock()
+	defer tuningMu.Unlock()
+	if globalTuner != nil {
+		globalTuner.stop()
+	}
+	t := &tuner{
+		gcPercent:         defaultGCPercent,
+		conf:              conf,
+		originMemoryLimit: debug.SetMemoryLimit(-1),
+	}
+	t.refreshLimit(time.Now())
+	t.finalizer = newFinalizer(t.tuning) // start tuning
+	globalTuner = t
+}
+
+// readLimit returns the memory hard limit.
+func (c *config) readLimit() (uint64, error) {
+	if c.limit > 0 {
+		return c.limit, nil
+	}
+	if c.useCGroup {
+		return getCGroupMemoryLimit()
+	}
+	return getNormalMemoryLimit()
+}
+
+// refreshLimit reads the memory hard limit again once the refresh interval elapsed,
+// and updates the threshold and the soft memory limit when it changed.
+// must be called with t.tuningMu held or before the tuner starts.
+func (t *tuner) refreshLimit(now time.Time) {
+	if !t.refreshed.IsZero() && now.Sub(t.refreshed) < t.conf.refresh {
+		return
+	}
+	t.refreshed = now
+	limit, err := t.conf.readLimit()
+	if err != nil || limit == 0 || limit == t.limit {
+		return
+	}
+	t.limit = limit
+	t.setThreshold(uint64(float64(limit) * t.conf.thresholdRatio))
+	if t.conf.memoryLimitRatio > 0 {
+		t.memoryLimit = int64(math.Min(float64(limit)*t.conf.memoryLimitRatio, math.MaxInt64))
+		debug.SetMemoryLimit(t.memoryLimit)
+	}
+}
+
+// report sends the event to the callback and the gauge.
+func (t *tuner) report(e Event) {
+	if t.conf.callback != nil {
+		t.conf.callback(e)
+	}
+	if g := t.conf.gauge; g != nil {
+		g.With("inuse").Set(float64(e.Inuse))
+		g.With("threshold").Set(float64(e.Threshold))
+		g.With("gc_percent").Set(float64(e.GCPercent))
+		g.With("limit").Set(float64(e.Limit))
+		g.With("memory_limit").Set(float64(e.MemoryLimit))
+	}
+}
@@ -0,0 +1,189 @@
+package gctuner
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"runtime/debug"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/songzhibin97/gkit/metrics/memory"
+	"github.com/stretchr/testify/assert"
+)
+
+const mb = 1024 * 1024
+
+// restoreRuntime restores the GC percent and the soft memory limit changed by the test.
+func restoreRuntime(t *testing.T) {
+	gcPercent := debug.SetGCPercent(-1)
+	debug.SetGCPercent(gcPercent)
+	memoryLimit := debug.SetMemoryLimit(-1)
+	t.Cleanup(func() {
+		debug.SetGCPercent(gcPercent)
+		debug.SetMemoryLimit(memoryLimit)
+	})
+}
+
+// fakeCGroup points the cgroup limit files to a temp directory.
+func fakeCGroup(t *testing.T) (v2, v1 string) {
+	dir := t.TempDir()
+	v2, v1 = filepath.Join(dir, "memory.max"), filepath.Join(dir, "memory.limit_in_bytes")
+	oldV2, oldV1 := cgroupV2MemLimitPath, cgroupMemLimitPath
+	cgroupV2MemLimitPath, cgroupMemLimitPath = v2, v1
+	t.Cleanup(func() {
+		cgroupV2MemLimitPath, cgroupMemLimitPath = oldV2, oldV1
+	})
+	return v2, v1
+}
+
+func writeLimit(t *testing.T, path string, limit string) {
+	if err := os.WriteFile(path, []byte(limit+"\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestTunerMemoryLimitFollowsCGroup(t *testing.T) {
+	is := assert.New(t)
+	restoreRuntime(t)
+	v2, _ := fakeCGroup(t)
+	writeLimit(t, v2, strconv.Itoa(512*mb))
+
+	var events []Event
+	gauge := memory.NewGauge()
+	originMemoryLimit := debug.SetMemoryLimit(-1)
+	tn := &tuner{
+		finalizer: &finalizer{},
+		conf: &config{
+			useCGroup:        true,
+			thresholdRatio:   0.5,
+			memoryLimitRatio: 0.75,
+			callback:         func(e Event) { events = append(events, e) },
+			gauge:            gauge,
+		},
+		originMemoryLimit: originMemoryLimit,
+	}
+
+	tn.tuning()
+	is.Len(events, 1)
+	e := events[0]
+	is.Equal(uint64(512*mb), e.Limit)
+	is.Equal(uint64(256*mb), e.Threshold)
+	is.Equal(int64(384*mb), e.MemoryLimit)
+	is.Equal(int64(384*mb), debug.SetMemoryLimit(-1))
+	is.NotZero(e.Inuse)
+	is.Equal(calcGCPercent(e.Inuse, e.Threshold), e.GCPercent)
+	is.Equal(e.GCPercent, tn.getGCPercent())
+
+	is.Equal(float64(e.Inuse), gauge.Value("inuse"))
+	is.Equal(float64(e.Threshold), gauge.Value("threshold"))
+	is.Equal(float64(e.GCPercent), gauge.Value("gc_percent"))
+	is.Equal(float64(e.Limit), gauge.Value("limit"))
+	is.Equal(float64(e.MemoryLimit), gauge.Value("memory_limit"))
+
+	// the cgroup limit changed at runtime
+	writeLimit(t, v2, strconv.Itoa(256*mb))
+	tn.tuning()
+	e = events[1]
+	is.Equal(uint64(256*mb), e.Limit)
+	is.Equal(uint64(128*mb), e.Threshold)
+	is.Equal(int64(192*mb), debug.SetMemoryLimit(-1))
+
+	// no cgroup limit, use the host memory
+	writeLimit(t, v2, "max")
+	tn.tuning()
+	host, err := getNormalMemoryLimit()
+	is.NoError(err)
+	is.Equal(host, events[2].Limit)
+
+	tn.stop()
+	is.Equal(originMemoryLimit, debug.SetMemoryLimit(-1))
+}
+
+func TestTunerMemoryLimitRefreshInterval(t *testing.T) {
+	is := assert.New(t)
+	restoreRuntime(t)
+	v2, v1 := fakeCGroup(t)
+	// cgroup v1 is used when memory.max is absent
+	writeLimit(t, v1, strconv.Itoa(512*mb))
+
+	tn := &tuner{
+		finalizer: &finalizer{},
+		conf:      &config{useCGroup: true, thresholdRatio: 0.5, refresh: time.Hour},
+	}
+	tn.tuning()
+	is.Equal(uint64(512*mb), tn.limit)
+	is.Equal(uint64(256*mb), tn.getThreshold())
+
+	writeLimit(t, v2, strconv.Itoa(256*mb))
+	tn.tuning()
+	is.Equal(uint64(512*mb), tn.limit, "limit read again before the refresh interval")
+
+	tn.refreshLimit(time.Now().Add(time.Hour))
+	is.Equal(uint64(256*mb), tn.limit)
+	is.Zero(tn.memoryLimit, "soft memory limit is not set without ratio")
+}
+
+func TestTuningWithMemoryLimit(t *testing.T) {
+	is := assert.New(t)
+	restoreRuntime(t)
+	originMemoryLimit := debug.SetMemoryLimit(-1)
+
+	limit := uint64(1024 * mb)
+	events := make(chan Event, 16)
+	TuningWithMemoryLimit(WithLimit(limit), WithCallback(func(e Event) {
+		select {
+		case events <- e:
+		default:
+		}
+	}))
+	is.Equal(int64(float64(limit)*defaultMemoryLimitRatio), debug.SetMemoryLimit(-1))
+
+	deadline := time.After(5 * time.Second)
+	var e Event
+wait:
+	for {
+		runtime.GC()
+		select {
+		case e = <-events:
+			break wait
+		case <-deadline:
+			t.Fatal("no tuning event after GC")
+		default:
+		}
+	}
+	is.Equal(limit, e.Limit)
+	is.Equal(uint64(float64(limit)*defaultThresholdRatio), e.Threshold)
+	is.Equal(e.GCPercent, GetGCPercent())
+
+	// Tuning replaces the memory limit tuner
+	Tuning(100 * mb)
+	tuningMu.Lock()
+	is.NotNil(globalTuner)
+	is.Nil(globalTuner.conf)
+	is.Equal(uint64(100*mb), globalTuner.getThreshold())
+	tuningMu.Unlock()
+	is.Equal(originMemoryLimit, debug.SetMemoryLimit(-1))
+
+	TuningWithMemoryLimit(WithLimit(limit))
+	Tuning(0)
+	tuningMu.Lock()
+	is.Nil(globalTuner)
+	tuningMu.Unlock()
+	is.Equal(originMemoryLimit, debug.SetMemoryLimit(-1))
+}
+
+func TestCalcGCPercentBounds(t *testing.T) {
+	is := assert.New(t)
+	const gb = 1024 * 1024 * 1024
+	oldMin, oldMax := SetMinGCPercent(80), SetMaxGCPercent(200)
+	t.Cleanup(func() {
+		SetMinGCPercent(oldMin)
+		SetMaxGCPercent(oldMax)
+	})
+
+	is.Equal(uint32(80), calcGCPercent(3*gb, 4*gb))
+	is.Equal(uint32(100), calcGCPercent(2*gb, 4*gb))
+	is.Equal(uint32(200), calcGCPercent(1*gb, 4*gb))
+}
@@ -11,6 +11,7 @@ import (
 	"strings"
 	"sync"
 	"sync/atomic"
+	"time"
 
 	"github.com/docker/go-units"
 	mem_util "github.com/shirou/gopsutil/mem"
@@ -65,6 +66,11 @@ func Tuning(threshold uint64) {
 		return
 	}
 
+	// the threshold of TuningWithMemoryLimit follows the memory limit, replace it
+	if globalTuner != nil && globalTuner.conf != nil {
+		globalTuner.stop()
+		globalTuner = nil
+	}
 	if globalTuner == nil {
 		globalTuner = newTuner(threshold)
 		return
@@ -126,6 +132,13 @@ type tuner struct {
 	threshold uint64 // high water level, in bytes
 	stopped   int32
 	tuningMu  sync.Mutex
+
+	// conf is set by TuningWithMemoryLimit, the following fields are protected by tuningMu
+	conf              *config
+	limit             uint64 // memory hard limit, in bytes
+	refreshed         time.Time
+	memoryLimit       int64 // soft memory limit set by tuner
+	originMemoryLimit int64 // soft memory limit before tuning, restored when stopped
 }
 
 // tuning check the memory inuse and tune GC percent dynamically.
@@ -137,13 +150,25 @@ func (t *tuner) tuning() {
 		return
 	}
 	inuse := readMemoryInuse()
+	if t.conf != nil {
+		t.refreshLimit(time.Now())
+	}
 	threshold := t.getThreshold()
 	// stop gc tuning
 	if threshold <= 0 {
 		return
 	}
-	t.setGCPercent(calcGCPercent(inuse, threshold))
-	return
+	gcPercent := calcGCPercent(inuse, threshold)
+	t.setGCPercent(gcPercent)
+	if t.conf != nil {
+		t.report(Event{
+			Inuse:       inuse,
+			Threshold:   threshold,
+			GCPercent:   gcPercent,
+			Limit:       t.limit,
+			MemoryLimit: debug.SetMemoryLimit(-1),
+		})
+	}
 }
 
 // threshold = inuse + inuse * (gcPercent / 100)
@@ -155,15 +180,16 @@ func calcGCPercent(inuse, threshold uint64) uint32 {
 	if inuse == 0 || threshold == 0 {
 		return defaultGCPercent
 	}
+	minPercent, maxPercent := GetMinGCPercent(), GetMaxGCPercent()
 	// inuse heap larger than threshold, use min percent
 	if threshold <= inuse {
-		return minGCPercent
+		return minPercent
 	}
 	gcPercent := uint32(math.Floor(float64(threshold-inuse) / float64(inuse) * 100))
-	if gcPercent < minGCPercent {
-		return minGCPercent
-	} else if gcPercent > maxGCPercent {
-		return maxGCPercent
+	if gcPercent < minPercent {
+		return minPercent
+	} else if gcPercent > maxPercent {
+		return maxPercent
 	}
 	return gcPercent
 }
@@ -182,6 +208,9 @@ func (t *tuner) stop() {
 	t.finalizer.stop()
 	// Wait for a callback that passed finalizerHandler's stopped check.
 	t.tuningMu.Lock()
+	if t.memoryLimit > 0 {
+		debug.SetMemoryLimit(t.originMemoryLimit)
+	}
 	t.tuningMu.Unlock()
 }
 
@@ -231,10 +260,19 @@ func TuningWithAuto(isContainer bool) {
 	Tuning(uint64(float64(threshold) * 0.7))
 }
 
-const cgroupMemLimitPath = "/sys/fs/cgroup/memory/memory.limit_in_bytes"
+// variables for testing
+var (
+	cgroupV2MemLimitPath = "/sys/fs/cgroup/memory.max"
+	cgroupMemLimitPath   = "/sys/fs/cgroup/memory/memory.limit_in_bytes"
+)
 
+// getCGroupMemoryLimit reads cgroup v2 memory.max first, then cgroup v1 memory.limit_in_bytes,
+// the limit is never larger than the host memory.
 func getCGroupMemoryLimit() (uint64, error) {
-	usage, err := readUint(cgroupMemLimitPath)
+	usage, err := readCGroupV2Limit()
+	if err != nil {
+		usage, err = readUint(cgroupMemLimitPath)
+	}
 	if err != nil {
 		return 0, err
 	}
@@ -254,6 +292,18 @@ func getNormalMemoryLimit() (uint64, error) {
 	return machineMemory.Total, nil
 }
 
+// readCGroupV2Limit reads cgroup v2 memory.max, "max" means no limit.
+func readCGroupV2Limit() (uint64, error) {
+	v, err := ioutil.ReadFile(cgroupV2MemLimitPath)
+	if err != nil {
+		return 0, err
+	}
+	if s := strings.TrimSpace(string(v)); s != "max" {
+		return parseUint(s, 10, 64)
+	}
+	return math.MaxUint64, nil
+}
+
 // copied from https://github.com/containerd/cgroups/blob/318312a373405e5e91134d8063d04d59768a1bff/utils.go#L251
 func parseUint(s string, base, bitSize int) (uint64, error) {
 	v, err := strconv.ParseUint(s, base, bitSize)
@@ -0,0 +1,276 @@
+package log
+
+import (
+	"context"
+	"errors"
+	"io"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/songzhibin97/gkit/options"
+)
+
+// ErrAsyncClosed Async 已关闭
+var ErrAsyncClosed = errors.New("async logger closed")
+
+var _ Logger = (*Async)(nil)
+
+// Syncer 支持落盘的 Logger, Async 周期性及关闭时调用
+type Syncer interface {
+	Sync() error
+}
+
+// syncWriter w 实现 Syncer 时调用其 Sync
+func syncWriter(w io.Writer) error {
+	if s, ok := w.(Syncer); ok {
+		return s.Sync()
+	}
+	return nil
+}
+
+// OverflowPolicy 缓冲区满时的处理策略
+type OverflowPolicy int8
+
+const (
+	// OverflowBlock 阻塞直到缓冲区有空位
+	OverflowBlock OverflowPolicy = iota
+	// OverflowDropNewest 丢弃新写入的日志
+	OverflowDropNewest
+	// OverflowDropBelow 丢弃低于 dropLevel 的日志, 其余阻塞
+	OverflowDropBelow
+)
+
+type asyncConfig struct {
+	// size: 环形缓冲区大小
+	size int
+
+	// policy: 缓冲区满时的处理策略
+	policy OverflowPolicy
+
+	// dropLevel: OverflowDropBelow 时低于该等级的日志被丢弃
+	dropLevel Lever
+
+	// flushInterval: 周期性调用 Syncer.Sync 的间隔, <= 0 不触发
+	flushInterval time.Duration
+
+	// errHandler: 后台写入失败时回调
+	errHandler func(error)
+}
+
+// SetBufferSize 设置缓冲区大小
+func SetBufferSize(size int) options.Option {
+	return func(c interface{}) {
+		c.(*asyncConfig).size = size
+	}
+}
+
+// SetOverflowPolicy 设置缓冲区满时的处理策略
+func SetOverflowPolicy(policy OverflowPolicy) options.Option {
+	return func(c interface{}) {
+		c.(*asyncConfig).policy = policy
+	}
+}
+
+// SetDropLevel 设置 OverflowDropBelow 时的等级
+func SetDropLevel(level Lever) options.Option {
+	return func(c interface{}) {
+		c.(*asyncConfig).dropLevel = level
+	}
+}
+
+// SetFlushInterval 设置周期性落盘的间隔
+func SetFlushInterval(interval time.Duration) options.Option {
+	return func(c interface{}) {
+		c.(*asyncConfig).flushInterval = interval
+	}
+}
+
+// SetErrorHandler 设置后台写入失败时的回调
+func SetErrorHandler(handler func(error)) options.Option {
+	return func(c interface{}) {
+		c.(*asyncConfig).errHandler = handler
+	}
+}
+
+// record 缓冲的日志
+type record struct {
+	level Lever
+	kvs   []interface{}
+}
+
+// Async 异步写入的 Logger
+// 日志先写入有界环形缓冲区, 由后台协程批量写入被包装的 Logger
+// Concurrency safety
+type Async struct {
+	logger Logger
+	conf   *asyncConfig
+
+	mu       sync.Mutex
+	notEmpty *sync.Cond
+	notFull  *sync.Cond
+	// written: 写入进度变化时通知 Sync
+	written *sync.Cond
+
+	ring  []record
+	head  int
+	count int
+
+	// enqueued/flushed: 已入队及已写入的日志序号
+	enqueued uint64
+	flushed  uint64
+
+	closed  bool
+	dropped uint64
+	done    chan struct{}
+}
+
+// Log 将日志写入缓冲区, Valuer 在调用时求值
+func (a *Async) Log(level Lever, kvs ...interface{}) error {
+	nKvs := make([]interface{}, len(kvs))
+	copy(nKvs, kvs)
+	bindValues(context.Background(), nKvs)
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	for !a.closed && a.count == len(a.ring) {
+		if a.conf.policy == OverflowDropNewest ||
+			(a.conf.policy == OverflowDropBelow && level < a.conf.dropLevel) {
+			atomic.AddUint64(&a.dropped, 1)
+			return nil
+		}
+		a.notFull.Wait()
+	}
+	if a.closed {
+		return ErrAsyncClosed
+	}
+	a.ring[(a.head+a.count)%len(a.ring)] = record{level: level, kvs: nKvs}
+	a.count++
+	a.enqueued++
+	a.notEmpty.Signal()
+	return nil
+}
+
+// Dropped 返回缓冲区满时累计丢弃的日志条数
+func (a *Async) Dropped() uint64 {
+	return atomic.LoadUint64(&a.dropped)
+}
+
+// Sync 等待调用前写入的日志全部写出, 被包装的 Logger 实现 Syncer 时调用其 Sync
+func (a *Async) Sync() error {
+	a.mu.Lock()
+	target := a.enqueued
+	for a.flushed < target {
+		a.written.Wait()
+	}
+	a.mu.Unlock()
+	return a.sync()
+}
+
+// Close 停止接收日志, 写出缓冲区中剩余的日志后返回
+func (a *Async) Close() error {
+	a.mu.Lock()
+	if a.closed {
+		a.mu.Unlock()
+		return ErrAsyncClosed
+	}
+	a.closed = true
+	a.notEmpty.Broadcast()
+	a.notFull.Broadcast()
+	a.mu.Unlock()
+	<-a.done
+	return a.sync()
+}
+
+// sync 调用被包装 Logger 的 Sync
+func (a *Async) sync() error {
+	if s, ok := a.logger.(Syncer); ok {
+		return s.Sync()
+	}
+	return nil
+}
+
+// run 后台写入协程
+func (a *Async) run() {
+	defer close(a.done)
+	batch := make([]record, 0, len(a.ring))
+	for {
+		a.mu.Lock()
+		for a.count == 0 && !a.closed {
+			a.notEmpty.Wait()
+		}
+		if a.count == 0 && a.closed {
+			a.mu.Unlock()
+			return
+		}
+		for a.count > 0 {
+			batch = append(batch, a.ring[a.head])
+			a.ring[a.head] = record{}
+			a.head = (a.head + 1) % len(a.ring)
+			a.count--
+		}
+		a.notFull.Broadcast()
+		a.mu.Unlock()
+
+		for _, r := range batch {
+			if err := a.logger.Log(r.level, r.kvs...); err != nil && a.conf.errHandler != nil {
+				a.conf.errHandler(err)
+			}
+		}
+
+		a.mu.Lock()
+		a.flushed += uint64(len(batch))
+		a.written.Broadcast()
+		a.mu.Unlock()
+		for i := range batch {
+			batch[i] = record{}
+		}
+		batch = batch[:0]
+	}
+}
+
+// runFlush 周期性落盘
+func (a *Async) runFlush() {
+	ticker := time.NewTicker(a.conf.flushInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			if err := a.sync(); err != nil && a.conf.errHandler != nil {
+				a.conf.errHandler(err)
+			}
+		case <-a.done:
+			return
+		}
+	}
+}
+
+// NewAsync 实例化异步 Logger, 默认缓冲 1024 条, 缓冲区满时阻塞, 每秒落盘一次
+func NewAsync(logger Logger, options ...options.Option) *Async {
+	conf := &asyncConfig{
+		size:          1024,
+		policy:        OverflowBlock,
+		dropLevel:     LevelWarn,
+		flushInterval: time.Second,
+	}
+	for _, option := range options {
+		option(conf)
+	}
+	if conf.size <= 0 {
+		conf.size = 1024
+	}
+	a := &Async{
+		logger: logger,
+		conf:   conf,
+		ring:   make([]record, conf.size),
+		done:   make(chan struct{}),
+	}
+	a.notEmpty = sync.NewCond(&a.mu)
+	a.notFull = sync.NewCond(&a.mu)
+	a.written = sync.NewCond(&a.mu)
+	go a.run()
+	if conf.flushInterval > 0 {
+		go a.runFlush()
+	}
+	return a
+}
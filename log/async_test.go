@@ -0,0 +1,133 @@
+package log
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// blockingLogger 在 release 关闭前阻塞写入
+type blockingLogger struct {
+	started chan struct{}
+	release chan struct{}
+	once    sync.Once
+
+	mu    sync.Mutex
+	lines []string
+	syncs int32
+}
+
+func newBlockingLogger() *blockingLogger {
+	return &blockingLogger{started: make(chan struct{}), release: make(chan struct{})}
+}
+
+func (l *blockingLogger) Log(level Lever, kvs ...interface{}) error {
+	l.once.Do(func() { close(l.started) })
+	<-l.release
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.lines = append(l.lines, level.Name()+" "+kvs[1].(string))
+	return nil
+}
+
+func (l *blockingLogger) Sync() error {
+	atomic.AddInt32(&l.syncs, 1)
+	return nil
+}
+
+func TestAsyncOrderAndClose(t *testing.T) {
+	var buf bytes.Buffer
+	logger := NewAsync(NewLogfmtLogger(&buf), SetBufferSize(4))
+	for i := 0; i < 100; i++ {
+		if err := logger.Log(LevelInfo, "i", i); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := logger.Close(); err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 100 || lines[0] != "level=info i=0" || lines[99] != "level=info i=99" {
+		t.Fatalf("unexpected output: %d lines, first %q", len(lines), lines[0])
+	}
+	if err := logger.Log(LevelInfo, "k", "v"); !errors.Is(err, ErrAsyncClosed) {
+		t.Fatalf("Log after Close = %v, want %v", err, ErrAsyncClosed)
+	}
+	if err := logger.Close(); !errors.Is(err, ErrAsyncClosed) {
+		t.Fatalf("second Close = %v, want %v", err, ErrAsyncClosed)
+	}
+}
+
+func TestAsyncDropNewest(t *testing.T) {
+	inner := newBlockingLogger()
+	logger := NewAsync(inner, SetBufferSize(2), SetOverflowPolicy(OverflowDropNewest))
+	_ = logger.Log(LevelInfo, "msg", "0")
+	<-inner.started // 0 已被后台协程取出并阻塞
+	for i := 1; i <= 5; i++ {
+		_ = logger.Log(LevelInfo, "msg", string(rune('0'+i)))
+	}
+	if got := logger.Dropped(); got != 3 {
+		t.Fatalf("Dropped = %d, want 3", got)
+	}
+	close(inner.release)
+	_ = logger.Close()
+	if got := strings.Join(inner.lines, ","); got != "info 0,info 1,info 2" {
+		t.Fatalf("lines = %s", got)
+	}
+}
+
+func TestAsyncDropBelow(t *testing.T) {
+	inner := newBlockingLogger()
+	logger := NewAsync(inner, SetBufferSize(1), SetOverflowPolicy(OverflowDropBelow), SetDropLevel(LevelWarn))
+	_ = logger.Log(LevelInfo, "msg", "0")
+	<-inner.started
+	_ = logger.Log(LevelInfo, "msg", "1")
+	_ = logger.Log(LevelInfo, "msg", "2") // 缓冲区满, 低于 Warn 丢弃
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		_ = logger.Log(LevelError, "msg", "3") // 缓冲区满, 阻塞等待
+	}()
+	select {
+	case <-done:
+		t.Fatal("error level must block instead of being dropped")
+	case <-time.After(20 * time.Millisecond):
+	}
+	close(inner.release)
+	<-done
+	_ = logger.Close()
+	if got := strings.Join(inner.lines, ","); got != "info 0,info 1,error 3" {
+		t.Fatalf("lines = %s", got)
+	}
+	if got := logger.Dropped(); got != 1 {
+		t.Fatalf("Dropped = %d, want 1", got)
+	}
+}
+
+func TestAsyncSync(t *testing.T) {
+	inner := newBlockingLogger()
+	close(inner.release)
+	logger := NewAsync(inner, SetFlushInterval(5*time.Millisecond))
+	defer logger.Close()
+	for i := 0; i < 10; i++ {
+		_ = logger.Log(LevelWarn, "msg", "x")
+	}
+	if err := logger.Sync(); err != nil {
+		t.Fatal(err)
+	}
+	inner.mu.Lock()
+	n := len(inner.lines)
+	inner.mu.Unlock()
+	if n != 10 {
+		t.Fatalf("Sync returned with %d/10 lines written", n)
+	}
+	time.Sleep(30 * time.Millisecond)
+	if atomic.LoadInt32(&inner.syncs) < 2 {
+		t.Fatalf("periodic flush not triggered, syncs = %d", inner.syncs)
+	}
+}
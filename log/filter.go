@@ -0,0 +1,198 @@
+package log
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/songzhibin97/gkit/options"
+)
+
+// DefaultMask 脱敏后的值
+const DefaultMask = "***"
+
+// defaultFilterKeys 默认脱敏的键
+var defaultFilterKeys = []string{"password", "token"}
+
+var _ Logger = (*Filter)(nil)
+
+type prefixLevel struct {
+	prefix string
+	level  Lever
+}
+
+type filterConfig struct {
+	// level: 全局最低等级
+	level Lever
+
+	// prefixLevels: 键的值前缀对应的最低等级, 最长前缀优先
+	prefixLevels map[string][]prefixLevel
+
+	// keys: 需要脱敏的键, 不区分大小写
+	keys map[string]struct{}
+
+	// noDefaultKeys: 不对默认的键脱敏
+	noDefaultKeys bool
+
+	// values: 需要脱敏的值匹配规则
+	values []*regexp.Regexp
+
+	// filter: 返回 true 时丢弃整条日志
+	filter func(level Lever, kvs ...interface{}) bool
+
+	mask string
+}
+
+// FilterLevel 设置全局最低等级
+func FilterLevel(level Lever) options.Option {
+	return func(c interface{}) {
+		c.(*filterConfig).level = level
+	}
+}
+
+// FilterPrefixLevel 键 key 的值以 prefix 开头时使用 level 作为最低等级
+// 例如 FilterPrefixLevel("module", "db.", LevelWarn), 可以注册多个键,
+// 多个前缀(包括不同键的前缀)匹配时最长前缀优先
+func FilterPrefixLevel(key, prefix string, level Lever) options.Option {
+	return func(c interface{}) {
+		conf := c.(*filterConfig)
+		conf.prefixLevels[key] = append(conf.prefixLevels[key], prefixLevel{prefix: prefix, level: level})
+	}
+}
+
+// FilterKey 对指定键的值脱敏
+func FilterKey(keys ...string) options.Option {
+	return func(c interface{}) {
+		conf := c.(*filterConfig)
+		for _, key := range keys {
+			conf.keys[strings.ToLower(key)] = struct{}{}
+		}
+	}
+}
+
+// FilterNoDefaultKeys 不对默认的 password、token 脱敏
+func FilterNoDefaultKeys() options.Option {
+	return func(c interface{}) {
+		c.(*filterConfig).noDefaultKeys = true
+	}
+}
+
+// FilterValue 将值中匹配 patterns 的部分脱敏
+func FilterValue(patterns ...*regexp.Regexp) options.Option {
+	return func(c interface{}) {
+		conf := c.(*filterConfig)
+		conf.values = append(conf.values, patterns...)
+	}
+}
+
+// FilterFunc 自定义过滤, f 返回 true 时丢弃整条日志
+func FilterFunc(f func(level Lever, kvs ...interface{}) bool) options.Option {
+	return func(c interface{}) {
+		c.(*filterConfig).filter = f
+	}
+}
+
+// FilterMask 设置脱敏后的值, 默认 DefaultMask
+func FilterMask(mask string) options.Option {
+	return func(c interface{}) {
+		c.(*filterConfig).mask = mask
+	}
+}
+
+// Filter 日志过滤及脱敏
+type Filter struct {
+	logger Logger
+	conf   *filterConfig
+}
+
+// Log 实现 Logger 接口
+func (f *Filter) Log(level Lever, kvs ...interface{}) error {
+	if !f.allow(level, kvs) {
+		return nil
+	}
+	if f.conf.filter != nil && f.conf.filter(level, kvs...) {
+		return nil
+	}
+	if len(f.conf.keys) == 0 && len(f.conf.values) == 0 {
+		return f.logger.Log(level, kvs...)
+	}
+	nKvs := make([]interface{}, len(kvs))
+	copy(nKvs, kvs)
+	for i := 0; i+1 < len(nKvs); i += 2 {
+		if _, ok := f.conf.keys[strings.ToLower(renderKey(nKvs[i]))]; ok {
+			nKvs[i+1] = f.conf.mask
+			continue
+		}
+		nKvs[i+1] = f.redact(nKvs[i+1])
+	}
+	return f.logger.Log(level, nKvs...)
+}
+
+// allow 判断等级是否满足, 匹配到前缀时以前缀等级为准
+func (f *Filter) allow(level Lever, kvs []interface{}) bool {
+	min := f.conf.level
+	if len(f.conf.prefixLevels) > 0 {
+		longest := -1
+		for i := 0; i+1 < len(kvs); i += 2 {
+			prefixLevels, ok := f.conf.prefixLevels[renderKey(kvs[i])]
+			if !ok {
+				continue
+			}
+			value := fmt.Sprint(kvs[i+1])
+			for _, p := range prefixLevels {
+				if len(p.prefix) > longest && strings.HasPrefix(value, p.prefix) {
+					longest, min = len(p.prefix), p.level
+				}
+			}
+		}
+	}
+	return min.Allow(level)
+}
+
+// redact 将值中匹配的部分替换为 mask
+func (f *Filter) redact(value interface{}) interface{} {
+	if len(f.conf.values) == 0 {
+		return value
+	}
+	var s string
+	switch v := value.(type) {
+	case string:
+		s = v
+	case []byte:
+		s = string(v)
+	case error:
+		s = safeString(v, v.Error)
+	case fmt.Stringer:
+		s = safeString(v, v.String)
+	default:
+		return value
+	}
+	redacted := s
+	for _, re := range f.conf.values {
+		redacted = re.ReplaceAllString(redacted, f.conf.mask)
+	}
+	if redacted == s {
+		return value
+	}
+	return redacted
+}
+
+// NewFilter 实例化日志过滤器
+// 默认对 password、token 脱敏, 使用 FilterNoDefaultKeys 关闭
+func NewFilter(logger Logger, options ...options.Option) *Filter {
+	conf := &filterConfig{
+		level:        LevelDebug,
+		prefixLevels: make(map[string][]prefixLevel),
+		keys:         make(map[string]struct{}),
+		mask:         DefaultMask,
+	}
+	for _, option := range options {
+		option(conf)
+	}
+	if !conf.noDefaultKeys {
+		for _, key := range defaultFilterKeys {
+			conf.keys[key] = struct{}{}
+		}
+	}
+	return &Filter{logger: logger, conf: conf}
+}
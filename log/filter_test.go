@@ -0,0 +1,102 @@
+package log
+
+import (
+	"bytes"
+	"errors"
+	"regexp"
+	"testing"
+)
+
+func TestFilterLevel(t *testing.T) {
+	var buf bytes.Buffer
+	logger := NewFilter(NewLogfmtLogger(&buf),
+		FilterLevel(LevelInfo),
+		FilterPrefixLevel("module", "db", LevelWarn),
+		FilterPrefixLevel("module", "db.slow", LevelDebug),
+	)
+	_ = logger.Log(LevelDebug, "module", "http", "msg", "1")
+	_ = logger.Log(LevelInfo, "module", "http", "msg", "2")
+	_ = logger.Log(LevelInfo, "module", "db.conn", "msg", "3")
+	_ = logger.Log(LevelWarn, "module", "db.conn", "msg", "4")
+	_ = logger.Log(LevelDebug, "module", "db.slow.query", "msg", "5")
+
+	want := "level=info module=http msg=2\n" +
+		"level=warn module=db.conn msg=4\n" +
+		"level=debug module=db.slow.query msg=5\n"
+	if got := buf.String(); got != want {
+		t.Fatalf("got  %q\nwant %q", got, want)
+	}
+}
+
+func TestFilterPrefixLevelKeys(t *testing.T) {
+	var buf bytes.Buffer
+	logger := NewFilter(NewLogfmtLogger(&buf),
+		FilterLevel(LevelInfo),
+		FilterPrefixLevel("module", "db", LevelWarn),
+		FilterPrefixLevel("component", "cache", LevelError),
+		FilterPrefixLevel("component", "cache.debug", LevelDebug),
+	)
+	_ = logger.Log(LevelInfo, "module", "db.conn", "msg", "1")
+	_ = logger.Log(LevelWarn, "module", "db.conn", "msg", "2")
+	_ = logger.Log(LevelWarn, "component", "cache.redis", "msg", "3")
+	_ = logger.Log(LevelError, "component", "cache.redis", "msg", "4")
+	_ = logger.Log(LevelDebug, "component", "cache.debug", "msg", "5")
+	// component 的值不会按 module 的前缀匹配
+	_ = logger.Log(LevelInfo, "component", "db.conn", "msg", "6")
+	// 多个键匹配时最长前缀优先
+	_ = logger.Log(LevelDebug, "module", "db.conn", "component", "cache.debug", "msg", "7")
+
+	want := "level=warn module=db.conn msg=2\n" +
+		"level=error component=cache.redis msg=4\n" +
+		"level=debug component=cache.debug msg=5\n" +
+		"level=info component=db.conn msg=6\n" +
+		"level=debug module=db.conn component=cache.debug msg=7\n"
+	if got := buf.String(); got != want {
+		t.Fatalf("got  %q\nwant %q", got, want)
+	}
+}
+
+func TestFilterRedact(t *testing.T) {
+	var buf bytes.Buffer
+	logger := NewFilter(NewLogfmtLogger(&buf),
+		FilterKey("password", "Token"),
+		FilterValue(regexp.MustCompile(`\d{11}`)),
+	)
+	kvs := []interface{}{"user", "gkit", "PASSWORD", "secret", "token", 123, "phone", "call 13800000000", "err", errors.New("bad 13800000000")}
+	_ = logger.Log(LevelInfo, kvs...)
+
+	want := `level=info user=gkit PASSWORD=*** token=*** phone="call ***" err="bad ***"` + "\n"
+	if got := buf.String(); got != want {
+		t.Fatalf("got  %q\nwant %q", got, want)
+	}
+	if kvs[3] != "secret" {
+		t.Fatal("caller kvs was modified")
+	}
+}
+
+func TestFilterDefaultKeys(t *testing.T) {
+	var buf bytes.Buffer
+	_ = NewFilter(NewLogfmtLogger(&buf)).Log(LevelInfo, "user", "gkit", "Password", "p", "token", "t")
+	if got, want := buf.String(), "level=info user=gkit Password=*** token=***\n"; got != want {
+		t.Fatalf("got %q, want %q", got, want)
+	}
+
+	buf.Reset()
+	_ = NewFilter(NewLogfmtLogger(&buf), FilterNoDefaultKeys()).Log(LevelInfo, "password", "p")
+	if got, want := buf.String(), "level=info password=p\n"; got != want {
+		t.Fatalf("got %q, want %q", got, want)
+	}
+}
+
+func TestFilterFunc(t *testing.T) {
+	var buf bytes.Buffer
+	logger := NewFilter(NewLogfmtLogger(&buf), FilterMask("[redacted]"), FilterKey("k"),
+		FilterFunc(func(level Lever, kvs ...interface{}) bool {
+			return level == LevelDebug
+		}))
+	_ = logger.Log(LevelDebug, "k", "v")
+	_ = logger.Log(LevelInfo, "k", "v")
+	if got, want := buf.String(), "level=info k=[redacted]\n"; got != want {
+		t.Fatalf("got %q, want %q", got, want)
+	}
+}
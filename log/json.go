@@ -0,0 +1,90 @@
+package log
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sync"
+)
+
+var _ Logger = (*jsonLogger)(nil)
+
+type jsonLogger struct {
+	mu       sync.Mutex
+	w        io.Writer
+	raw      io.Writer
+	pool     *sync.Pool
+	minLevel Lever
+}
+
+// NewJSONLogger new a logger that writes one JSON object per line.
+// The level is written as the "level" field before the kv pairs, a kv key
+// "level" is renamed to "fields.level" to keep the keys unique.
+func NewJSONLogger(w io.Writer) Logger {
+	return NewJSONLoggerWithLevel(w, LevelDebug)
+}
+
+// NewJSONLoggerWithLevel new a JSON logger that drops messages below `min`.
+func NewJSONLoggerWithLevel(w io.Writer, min Lever) Logger {
+	return &jsonLogger{
+		w:   shortWriteWriter{Writer: w},
+		raw: w,
+		pool: &sync.Pool{
+			New: func() interface{} {
+				return new(bytes.Buffer)
+			},
+		},
+		minLevel: min,
+	}
+}
+
+// Log print the kv pairs log as a JSON object.
+func (l *jsonLogger) Log(level Lever, kvs ...interface{}) error {
+	if !l.minLevel.Allow(level) {
+		return nil
+	}
+	if len(kvs) == 0 {
+		return nil
+	}
+	kvs = normalizeKvs(kvs)
+	buf := l.pool.Get().(*bytes.Buffer)
+	defer func() {
+		buf.Reset()
+		l.pool.Put(buf)
+	}()
+
+	buf.WriteString(`{"` + LevelKey + `":"` + level.Name() + `"`)
+	for i := 0; i < len(kvs); i += 2 {
+		buf.WriteByte(',')
+		writeJSON(buf, renderFieldKey(kvs[i]))
+		buf.WriteByte(':')
+		writeJSON(buf, renderValue(kvs[i+1]))
+	}
+	buf.WriteString("}\n")
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	_, err := l.w.Write(buf.Bytes())
+	return err
+}
+
+// writeJSON 写入 v 的 JSON 编码, 无法编码时写入其 %+v 字符串
+func writeJSON(buf *bytes.Buffer, v interface{}) {
+	var b bytes.Buffer
+	enc := json.NewEncoder(&b)
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(v); err != nil {
+		b.Reset()
+		_ = enc.Encode(fmt.Sprintf("%+v", v))
+	}
+	// Encode 末尾会追加换行
+	buf.Write(bytes.TrimRight(b.Bytes(), "\n"))
+}
+
+// Sync 底层 io.Writer 实现 Syncer 时将其落盘
+func (l *jsonLogger) Sync() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return syncWriter(l.raw)
+}
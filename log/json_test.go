@@ -0,0 +1,105 @@
+package log
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"testing"
+	"time"
+)
+
+type stringer struct{}
+
+func (stringer) String() string { return "stringer" }
+
+type nilError struct{ msg string }
+
+func (e *nilError) Error() string { return e.msg }
+
+func TestJSONLogger(t *testing.T) {
+	var buf bytes.Buffer
+	logger := NewJSONLogger(&buf)
+	ts := time.Date(2021, 1, 2, 3, 4, 5, 6, time.UTC)
+	var ne *nilError
+	err := logger.Log(LevelWarn,
+		"msg", "a \"quoted\"\nline",
+		"err", errors.New("boom"),
+		"nil_err", ne,
+		"ts", ts,
+		"s", stringer{},
+		"map", map[interface{}]interface{}{1: map[string]int{"b": 2}},
+		10, "odd",
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var m map[string]interface{}
+	if err = json.Unmarshal(buf.Bytes(), &m); err != nil {
+		t.Fatalf("invalid json %q: %v", buf.String(), err)
+	}
+	want := map[string]interface{}{
+		"level":   "warn",
+		"msg":     "a \"quoted\"\nline",
+		"err":     "boom",
+		"nil_err": "null",
+		"ts":      ts.Format(time.RFC3339Nano),
+		"s":       "stringer",
+		"map":     map[string]interface{}{"1": map[string]interface{}{"b": float64(2)}},
+		"10":      "odd",
+	}
+	if len(m) != len(want) {
+		t.Fatalf("got %v, want %v", m, want)
+	}
+	for k, v := range want {
+		got, _ := json.Marshal(m[k])
+		exp, _ := json.Marshal(v)
+		if !bytes.Equal(got, exp) {
+			t.Errorf("%s = %s, want %s", k, got, exp)
+		}
+	}
+	if !bytes.HasPrefix(buf.Bytes(), []byte(`{"level":"warn",`)) {
+		t.Errorf("level is not the first field: %q", buf.String())
+	}
+}
+
+func TestJSONLoggerOddKeyvals(t *testing.T) {
+	var buf bytes.Buffer
+	_ = NewJSONLogger(&buf).Log(LevelInfo, "k")
+	if got, want := buf.String(), `{"level":"info","k":"(MISSING)"}`+"\n"; got != want {
+		t.Fatalf("got %q, want %q", got, want)
+	}
+}
+
+func TestJSONLoggerLevelKey(t *testing.T) {
+	var buf bytes.Buffer
+	_ = NewJSONLogger(&buf).Log(LevelInfo, "level", "user", "msg", "m")
+	if got, want := buf.String(), `{"level":"info","fields.level":"user","msg":"m"}`+"\n"; got != want {
+		t.Fatalf("got %q, want %q", got, want)
+	}
+}
+
+func TestJSONLoggerLevelAndErrors(t *testing.T) {
+	var buf bytes.Buffer
+	logger := NewJSONLoggerWithLevel(&buf, LevelError)
+	_ = logger.Log(LevelWarn, "k", "v")
+	if buf.Len() != 0 {
+		t.Fatalf("Warn emitted under Error floor: %q", buf.String())
+	}
+
+	logger = NewJSONLogger(writerFunc(func(p []byte) (int, error) {
+		return len(p) - 1, nil
+	}))
+	if err := logger.Log(LevelInfo, "k", "v"); !errors.Is(err, io.ErrShortWrite) {
+		t.Fatalf("Log error = %v, want %v", err, io.ErrShortWrite)
+	}
+}
+
+func TestJSONLoggerUnsupportedValue(t *testing.T) {
+	var buf bytes.Buffer
+	_ = NewJSONLogger(&buf).Log(LevelInfo, "ch", make(chan int))
+	var m map[string]interface{}
+	if err := json.Unmarshal(buf.Bytes(), &m); err != nil {
+		t.Fatalf("invalid json %q: %v", buf.String(), err)
+	}
+}
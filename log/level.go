@@ -17,6 +17,13 @@ var m = map[Lever]string{
 	LevelError: "[Error]",
 }
 
+var names = map[Lever]string{
+	LevelDebug: "debug",
+	LevelInfo:  "info",
+	LevelWarn:  "warn",
+	LevelError: "error",
+}
+
 // Allow 允许是否可以打印
 func (l Lever) Allow(lv Lever) bool {
 	return lv >= l
@@ -29,3 +36,11 @@ func (l Lever) String() string {
 	}
 	return "UNKNOWN"
 }
+
+// Name 结构化日志中使用的小写名称
+func (l Lever) Name() string {
+	if v, ok := names[l]; ok {
+		return v
+	}
+	return "unknown"
+}
@@ -0,0 +1,151 @@
+package log
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"unicode"
+	"unicode/utf8"
+)
+
+var _ Logger = (*logfmtLogger)(nil)
+
+type logfmtLogger struct {
+	mu       sync.Mutex
+	w        io.Writer
+	raw      io.Writer
+	pool     *sync.Pool
+	minLevel Lever
+}
+
+// NewLogfmtLogger new a logger that writes one logfmt line per record.
+// The level is written as the "level" field before the kv pairs, a kv key
+// "level" is renamed to "fields.level" and nested maps are flattened into
+// dotted keys.
+func NewLogfmtLogger(w io.Writer) Logger {
+	return NewLogfmtLoggerWithLevel(w, LevelDebug)
+}
+
+// NewLogfmtLoggerWithLevel new a logfmt logger that drops messages below `min`.
+func NewLogfmtLoggerWithLevel(w io.Writer, min Lever) Logger {
+	return &logfmtLogger{
+		w:   shortWriteWriter{Writer: w},
+		raw: w,
+		pool: &sync.Pool{
+			New: func() interface{} {
+				return new(bytes.Buffer)
+			},
+		},
+		minLevel: min,
+	}
+}
+
+// Log print the kv pairs log in logfmt.
+func (l *logfmtLogger) Log(level Lever, kvs ...interface{}) error {
+	if !l.minLevel.Allow(level) {
+		return nil
+	}
+	if len(kvs) == 0 {
+		return nil
+	}
+	kvs = normalizeKvs(kvs)
+	buf := l.pool.Get().(*bytes.Buffer)
+	defer func() {
+		buf.Reset()
+		l.pool.Put(buf)
+	}()
+
+	buf.WriteString(LevelKey + "=" + level.Name())
+	for i := 0; i < len(kvs); i += 2 {
+		writeLogfmtPair(buf, renderFieldKey(kvs[i]), renderValue(kvs[i+1]))
+	}
+	buf.WriteByte('\n')
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	_, err := l.w.Write(buf.Bytes())
+	return err
+}
+
+// writeLogfmtPair 写入 key=value, map 按键排序展开为 key.sub=value
+func writeLogfmtPair(buf *bytes.Buffer, key string, value interface{}) {
+	if m, ok := value.(map[string]interface{}); ok && len(m) > 0 {
+		keys := make([]string, 0, len(m))
+		for k := range m {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			writeLogfmtPair(buf, key+"."+k, m[k])
+		}
+		return
+	}
+	buf.WriteByte(' ')
+	buf.WriteString(logfmtKey(key))
+	buf.WriteByte('=')
+	buf.WriteString(logfmtValue(value))
+}
+
+// logfmtKey 将空白、'='、'"' 及控制字符替换为 '_'
+func logfmtKey(key string) string {
+	if key == "" {
+		return "_"
+	}
+	return strings.Map(func(r rune) rune {
+		if r <= ' ' || r == '=' || r == '"' || r == utf8.RuneError || unicode.IsSpace(r) || unicode.IsControl(r) {
+			return '_'
+		}
+		return r
+	}, key)
+}
+
+// logfmtValue 将值转为字符串, 必要时加引号转义
+func logfmtValue(value interface{}) string {
+	var s string
+	switch v := value.(type) {
+	case nil:
+		return "null"
+	case string:
+		s = v
+	case []byte:
+		s = string(v)
+	case map[string]interface{}, []interface{}:
+		b, err := json.Marshal(v)
+		if err != nil {
+			s = fmt.Sprintf("%+v", v)
+		} else {
+			s = string(b)
+		}
+	default:
+		s = fmt.Sprint(v)
+	}
+	if needsQuote(s) {
+		return strconv.Quote(s)
+	}
+	return s
+}
+
+// needsQuote 空字符串及包含空白、'='、'"'、'\' 或控制字符的值需要加引号
+func needsQuote(s string) bool {
+	if s == "" {
+		return true
+	}
+	for _, r := range s {
+		if r <= ' ' || r == '=' || r == '"' || r == '\\' || r == utf8.RuneError || unicode.IsSpace(r) || unicode.IsControl(r) {
+			return true
+		}
+	}
+	return false
+}
+
+// Sync 底层 io.Writer 实现 Syncer 时将其落盘
+func (l *logfmtLogger) Sync() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return syncWriter(l.raw)
+}
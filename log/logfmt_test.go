@@ -0,0 +1,54 @@
+package log
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestLogfmtLogger(t *testing.T) {
+	var buf bytes.Buffer
+	logger := NewLogfmtLogger(&buf)
+	ts := time.Date(2021, 1, 2, 3, 4, 5, 0, time.UTC)
+	err := logger.Log(LevelInfo,
+		"msg", "hello world",
+		"plain", "value",
+		"empty", "",
+		"eq", "a=b",
+		"err", errors.New("boom"),
+		"ts", ts,
+		"s", stringer{},
+		"nil", nil,
+		"bad key", 1,
+		"map", map[string]interface{}{"b": 2, "a": map[string]string{"c": "d e"}},
+		"list", []int{1, 2},
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `level=info msg="hello world" plain=value empty="" eq="a=b" err=boom ts=2021-01-02T03:04:05Z s=stringer nil=null bad_key=1 map.a.c="d e" map.b=2 list=[1,2]` + "\n"
+	if got := buf.String(); got != want {
+		t.Fatalf("got  %q\nwant %q", got, want)
+	}
+}
+
+func TestLogfmtLoggerOddKeyvals(t *testing.T) {
+	var buf bytes.Buffer
+	_ = NewLogfmtLoggerWithLevel(&buf, LevelWarn).Log(LevelInfo, "k")
+	if buf.Len() != 0 {
+		t.Fatalf("Info emitted under Warn floor: %q", buf.String())
+	}
+	_ = NewLogfmtLogger(&buf).Log(LevelError, "k")
+	if got, want := buf.String(), "level=error k=(MISSING)\n"; got != want {
+		t.Fatalf("got %q, want %q", got, want)
+	}
+}
+
+func TestLogfmtLoggerLevelKey(t *testing.T) {
+	var buf bytes.Buffer
+	_ = NewLogfmtLogger(&buf).Log(LevelInfo, "level", "user", "msg", "m")
+	if got, want := buf.String(), "level=info fields.level=user msg=m\n"; got != want {
+		t.Fatalf("got %q, want %q", got, want)
+	}
+}
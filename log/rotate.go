@@ -0,0 +1,416 @@
+package log
+
+import (
+	"compress/gzip"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/songzhibin97/gkit/options"
+)
+
+// ErrRotateWriterClosed RotateWriter 已关闭
+var ErrRotateWriterClosed = errors.New("rotate writer closed")
+
+// compressSuffix 压缩后的备份文件后缀
+const compressSuffix = ".gz"
+
+// Rollover 按时间切割的周期
+type Rollover int8
+
+const (
+	// RolloverNone 不按时间切割
+	RolloverNone Rollover = iota
+	// RolloverHourly 每小时切割
+	RolloverHourly
+	// RolloverDaily 每天零点切割
+	RolloverDaily
+)
+
+// start 返回 t 所在周期的起始时间
+func (r Rollover) start(t time.Time) time.Time {
+	switch r {
+	case RolloverHourly:
+		return time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), 0, 0, 0, t.Location())
+	case RolloverDaily:
+		return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+	default:
+		return time.Time{}
+	}
+}
+
+type rotateConfig struct {
+	// maxSize: 单个文件最大字节数, <= 0 不按大小切割
+	maxSize int64
+
+	// rollover: 按时间切割的周期
+	rollover Rollover
+
+	// maxBackups: 保留的备份数, <= 0 不限制
+	maxBackups int
+
+	// maxAge: 备份保留时长, <= 0 不限制
+	maxAge time.Duration
+
+	// compress: 是否 gzip 压缩备份
+	compress bool
+
+	// layout: 备份文件名后缀的时间格式, 备份为 filename + time.Format(layout)
+	layout string
+}
+
+// defaultRotateConfig 默认配置: 100MB 切割, 不按时间切割, 不清理备份
+func defaultRotateConfig() *rotateConfig {
+	return &rotateConfig{
+		maxSize: 100 * 1024 * 1024,
+		layout:  ".2006-01-02T15-04-05.000",
+	}
+}
+
+// SetMaxSize 设置单个文件最大字节数
+func SetMaxSize(size int64) options.Option {
+	return func(c interface{}) {
+		c.(*rotateConfig).maxSize = size
+	}
+}
+
+// SetRollover 设置按时间切割的周期
+func SetRollover(rollover Rollover) options.Option {
+	return func(c interface{}) {
+		c.(*rotateConfig).rollover = rollover
+	}
+}
+
+// SetMaxBackups 设置保留的备份数
+func SetMaxBackups(n int) options.Option {
+	return func(c interface{}) {
+		c.(*rotateConfig).maxBackups = n
+	}
+}
+
+// SetMaxAge 设置备份保留时长
+func SetMaxAge(age time.Duration) options.Option {
+	return func(c interface{}) {
+		c.(*rotateConfig).maxAge = age
+	}
+}
+
+// SetCompress 设置是否 gzip 压缩备份
+func SetCompress(compress bool) options.Option {
+	return func(c interface{}) {
+		c.(*rotateConfig).compress = compress
+	}
+}
+
+// SetBackupLayout 设置备份文件名后缀的时间格式
+func SetBackupLayout(layout string) options.Option {
+	return func(c interface{}) {
+		c.(*rotateConfig).layout = layout
+	}
+}
+
+// RotateWriter 按大小及时间切割的文件 io.Writer
+// 切割后的压缩与清理在后台协程中进行
+// Concurrency safety
+type RotateWriter struct {
+	mu       sync.Mutex
+	filename string
+	conf     *rotateConfig
+
+	file   *os.File
+	size   int64
+	period time.Time
+	closed bool
+
+	mill chan struct{}
+	done chan struct{}
+}
+
+// Write 实现 io.Writer, 写入前判断是否需要切割
+func (w *RotateWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.closed {
+		return 0, ErrRotateWriterClosed
+	}
+	if now := time.Now(); w.shouldRotate(int64(len(p)), now) {
+		if w.size == 0 {
+			// 空文件无需切割, 只刷新周期
+			w.period = w.conf.rollover.start(now)
+		} else if err := w.rotate(); err != nil {
+			return 0, err
+		}
+	}
+	n, err := w.file.Write(p)
+	w.size += int64(n)
+	return n, err
+}
+
+// Rotate 立即切割
+func (w *RotateWriter) Rotate() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.closed {
+		return ErrRotateWriterClosed
+	}
+	return w.rotate()
+}
+
+// Sync 将文件内容落盘
+func (w *RotateWriter) Sync() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.closed {
+		return ErrRotateWriterClosed
+	}
+	return w.file.Sync()
+}
+
+// Close 关闭文件, 等待后台压缩与清理完成
+func (w *RotateWriter) Close() error {
+	w.mu.Lock()
+	if w.closed {
+		w.mu.Unlock()
+		return ErrRotateWriterClosed
+	}
+	w.closed = true
+	err := w.file.Close()
+	close(w.mill)
+	w.mu.Unlock()
+	<-w.done
+	return err
+}
+
+// shouldRotate 写入 n 字节后超过 maxSize 或已进入新的周期
+func (w *RotateWriter) shouldRotate(n int64, now time.Time) bool {
+	if w.conf.maxSize > 0 && w.size+n > w.conf.maxSize {
+		return true
+	}
+	return w.conf.rollover != RolloverNone && w.conf.rollover.start(now).After(w.period)
+}
+
+// rotate 关闭当前文件, 重命名为备份后打开新文件
+// 关闭失败时句柄已不可用, 仍然继续切割并打开新文件, 随后返回关闭的错误
+func (w *RotateWriter) rotate() error {
+	closeErr := w.file.Close()
+	if _, err := RotateFile(w.filename, w.conf.layout, time.Now()); err != nil {
+		// 重命名失败时继续写入原文件
+		if oErr := w.open(); oErr != nil {
+			return oErr
+		}
+		return err
+	}
+	if err := w.open(); err != nil {
+		return err
+	}
+	select {
+	case w.mill <- struct{}{}:
+	default:
+	}
+	return closeErr
+}
+
+// open 以追加方式打开文件
+func (w *RotateWriter) open() error {
+	if err := os.MkdirAll(filepath.Dir(w.filename), 0o755); err != nil {
+		return err
+	}
+	f, err := os.OpenFile(w.filename, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return err
+	}
+	info, err := f.Stat()
+	if err != nil {
+		_ = f.Close()
+		return err
+	}
+	w.file = f
+	w.size = info.Size()
+	// 已存在的文件按最后修改时间归属周期, 跨周期后首次写入即切割
+	w.period = w.conf.rollover.start(info.ModTime())
+	if w.size == 0 {
+		w.period = w.conf.rollover.start(time.Now())
+	}
+	return nil
+}
+
+// runMill 后台压缩与清理备份
+func (w *RotateWriter) runMill() {
+	defer close(w.done)
+	for range w.mill {
+		if w.conf.compress {
+			backups, _ := listBackups(w.filename, w.conf.layout)
+			for _, b := range backups {
+				if !strings.HasSuffix(b.path, compressSuffix) {
+					_ = CompressFile(b.path)
+				}
+			}
+		}
+		_ = RemoveBackups(w.filename, w.conf.layout, w.conf.maxBackups, w.conf.maxAge)
+	}
+}
+
+// NewRotateWriter 实例化切割文件 Writer, 文件及目录不存在时自动创建
+func NewRotateWriter(filename string, options ...options.Option) (*RotateWriter, error) {
+	conf := defaultRotateConfig()
+	for _, option := range options {
+		option(conf)
+	}
+	if conf.layout == "" {
+		conf.layout = defaultRotateConfig().layout
+	}
+	w := &RotateWriter{
+		filename: filename,
+		conf:     conf,
+		mill:     make(chan struct{}, 1),
+		done:     make(chan struct{}),
+	}
+	if err := w.open(); err != nil {
+		return nil, err
+	}
+	go w.runMill()
+	// 启动时清理历史备份
+	w.mill <- struct{}{}
+	return w, nil
+}
+
+// RotateFile 将 filename 重命名为 filename + t.Format(layout), 返回备份路径
+// 同名备份(包括压缩后的备份)已存在时追加序号, 如 filename + t.Format(layout) + ".1"
+func RotateFile(filename, layout string, t time.Time) (string, error) {
+	name := filename + t.Format(layout)
+	backup := name
+	for seq := 1; backupExists(backup); seq++ {
+		backup = name + "." + strconv.Itoa(seq)
+	}
+	if err := os.Rename(filename, backup); err != nil {
+		return "", err
+	}
+	return backup, nil
+}
+
+// backupExists 判断备份或其压缩文件是否存在
+func backupExists(path string) bool {
+	for _, p := range []string{path, path + compressSuffix} {
+		if _, err := os.Lstat(p); err == nil || !os.IsNotExist(err) {
+			return true
+		}
+	}
+	return false
+}
+
+// CompressFile 将 path gzip 压缩为 path.gz 并删除原文件
+func CompressFile(path string) (err error) {
+	src, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+	dst, err := os.OpenFile(path+compressSuffix, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0o644)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			_ = os.Remove(path + compressSuffix)
+		}
+	}()
+	gz := gzip.NewWriter(dst)
+	if _, err = io.Copy(gz, src); err != nil {
+		_ = dst.Close()
+		return err
+	}
+	if err = gz.Close(); err != nil {
+		_ = dst.Close()
+		return err
+	}
+	if err = dst.Close(); err != nil {
+		return err
+	}
+	return os.Remove(path)
+}
+
+// RemoveBackups 按数量及时长清理 RotateFile 生成的备份(包括压缩后的备份)
+// maxBackups <= 0 不限制数量, maxAge <= 0 不限制时长
+func RemoveBackups(filename, layout string, maxBackups int, maxAge time.Duration) error {
+	if maxBackups <= 0 && maxAge <= 0 {
+		return nil
+	}
+	backups, err := listBackups(filename, layout)
+	if err != nil {
+		return err
+	}
+	var cutoff time.Time
+	if maxAge > 0 {
+		cutoff = time.Now().Add(-maxAge)
+	}
+	for i, b := range backups {
+		if (maxBackups > 0 && i >= maxBackups) || (maxAge > 0 && b.t.Before(cutoff)) {
+			if rErr := os.Remove(b.path); rErr != nil && err == nil {
+				err = rErr
+			}
+		}
+	}
+	return err
+}
+
+// backup 备份文件
+type backup struct {
+	path string
+	t    time.Time
+	// seq: 同一时间的备份序号
+	seq int
+}
+
+// listBackups 返回 filename 的备份, 按时间从新到旧排序
+func listBackups(filename, layout string) ([]backup, error) {
+	entries, err := os.ReadDir(filepath.Dir(filename))
+	if err != nil {
+		return nil, err
+	}
+	base := filepath.Base(filename)
+	var backups []backup
+	for _, e := range entries {
+		name := e.Name()
+		if e.IsDir() || !strings.HasPrefix(name, base) || name == base {
+			continue
+		}
+		t, seq, ok := parseBackup(strings.TrimSuffix(name[len(base):], compressSuffix), layout)
+		if !ok {
+			continue
+		}
+		backups = append(backups, backup{path: filepath.Join(filepath.Dir(filename), name), t: t, seq: seq})
+	}
+	sort.Slice(backups, func(i, j int) bool {
+		if backups[i].t.Equal(backups[j].t) {
+			return backups[i].seq > backups[j].seq
+		}
+		return backups[i].t.After(backups[j].t)
+	})
+	return backups, nil
+}
+
+// parseBackup 解析备份后缀的时间与 RotateFile 追加的序号
+func parseBackup(suffix, layout string) (time.Time, int, bool) {
+	if t, err := time.ParseInLocation(layout, suffix, time.Local); err == nil {
+		return t, 0, true
+	}
+	idx := strings.LastIndexByte(suffix, '.')
+	if idx < 0 {
+		return time.Time{}, 0, false
+	}
+	seq, err := strconv.Atoi(suffix[idx+1:])
+	if err != nil || seq <= 0 {
+		return time.Time{}, 0, false
+	}
+	t, err := time.ParseInLocation(layout, suffix[:idx], time.Local)
+	if err != nil {
+		return time.Time{}, 0, false
+	}
+	return t, seq, true
+}
@@ -0,0 +1,220 @@
+package log
+
+import (
+	"compress/gzip"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func readBackups(t *testing.T, filename, layout string) []backup {
+	t.Helper()
+	backups, err := listBackups(filename, layout)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return backups
+}
+
+func TestRotateWriterMaxSize(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "sub", "app.log")
+	w, err := NewRotateWriter(filename, SetMaxSize(10), SetBackupLayout(".2006-01-02T15-04-05.000000000"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, s := range []string{"12345\n", "12345\n", "1234567890abc\n"} {
+		if _, err = w.Write([]byte(s)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err = w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	data, _ := os.ReadFile(filename)
+	if string(data) != "1234567890abc\n" {
+		t.Fatalf("current file = %q", data)
+	}
+	if got := len(readBackups(t, filename, w.conf.layout)); got != 2 {
+		t.Fatalf("backups = %d, want 2", got)
+	}
+	if _, err = w.Write([]byte("x")); err != ErrRotateWriterClosed {
+		t.Fatalf("Write after Close = %v, want %v", err, ErrRotateWriterClosed)
+	}
+}
+
+func TestRotateWriterRollover(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "app.log")
+	if err := os.WriteFile(filename, []byte("yesterday\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	past := time.Now().Add(-48 * time.Hour)
+	if err := os.Chtimes(filename, past, past); err != nil {
+		t.Fatal(err)
+	}
+	w, err := NewRotateWriter(filename, SetRollover(RolloverDaily))
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, _ = w.Write([]byte("today\n"))
+	_, _ = w.Write([]byte("today\n"))
+	_ = w.Close()
+	data, _ := os.ReadFile(filename)
+	if string(data) != "today\ntoday\n" {
+		t.Fatalf("current file = %q", data)
+	}
+	backups := readBackups(t, filename, w.conf.layout)
+	if len(backups) != 1 {
+		t.Fatalf("backups = %d, want 1", len(backups))
+	}
+}
+
+func TestRotateWriterCompressAndPrune(t *testing.T) {
+	dir := t.TempDir()
+	filename := filepath.Join(dir, "app.log")
+	layout := ".2006-01-02T15-04-05.000000000"
+	old := filename + time.Now().Add(-72*time.Hour).Format(layout)
+	if err := os.WriteFile(old, []byte("old\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	w, err := NewRotateWriter(filename, SetBackupLayout(layout), SetCompress(true),
+		SetMaxBackups(2), SetMaxAge(24*time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 3; i++ {
+		_, _ = w.Write([]byte("line\n"))
+		if err = w.Rotate(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	_ = w.Close()
+
+	backups := readBackups(t, filename, layout)
+	if len(backups) != 2 {
+		t.Fatalf("backups = %d, want 2", len(backups))
+	}
+	for _, b := range backups {
+		if !strings.HasSuffix(b.path, compressSuffix) {
+			t.Fatalf("backup %s is not compressed", b.path)
+		}
+		f, err := os.Open(b.path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		gz, err := gzip.NewReader(f)
+		if err != nil {
+			t.Fatal(err)
+		}
+		data, _ := io.ReadAll(gz)
+		_ = f.Close()
+		if string(data) != "line\n" {
+			t.Fatalf("backup content = %q", data)
+		}
+	}
+	if _, err = os.Stat(old); !os.IsNotExist(err) {
+		t.Fatalf("expired backup was not removed: %v", err)
+	}
+}
+
+func TestRotateWriterConcurrent(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "app.log")
+	w, err := NewRotateWriter(filename, SetMaxSize(64), SetBackupLayout(".2006-01-02T15-04-05.000000000"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	logger := NewLogfmtLogger(w)
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 50; j++ {
+				if err := logger.Log(LevelInfo, "msg", "concurrent"); err != nil {
+					t.Error(err)
+					return
+				}
+			}
+		}()
+	}
+	wg.Wait()
+	_ = w.Close()
+
+	lines := 0
+	for _, b := range append(readBackups(t, filename, w.conf.layout), backup{path: filename}) {
+		data, err := os.ReadFile(b.path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		lines += strings.Count(string(data), "\n")
+	}
+	if lines != 400 {
+		t.Fatalf("lines = %d, want 400", lines)
+	}
+}
+
+func TestRotateFileSameTime(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "app.log")
+	layout := defaultRotateConfig().layout
+	now := time.Now()
+	var paths []string
+	for i := 0; i < 3; i++ {
+		if err := os.WriteFile(filename, []byte{byte('a' + i)}, 0o644); err != nil {
+			t.Fatal(err)
+		}
+		path, err := RotateFile(filename, layout, now)
+		if err != nil {
+			t.Fatal(err)
+		}
+		paths = append(paths, path)
+		if i == 1 {
+			if err = CompressFile(path); err != nil {
+				t.Fatal(err)
+			}
+			paths[i] += compressSuffix
+		}
+	}
+	if paths[1] != paths[0]+".1"+compressSuffix || paths[2] != paths[0]+".2" {
+		t.Fatalf("backups = %v", paths)
+	}
+	backups := readBackups(t, filename, layout)
+	if len(backups) != 3 {
+		t.Fatalf("backups = %d, want 3", len(backups))
+	}
+	for i, b := range backups {
+		if b.path != paths[2-i] {
+			t.Fatalf("backups[%d] = %s, want %s", i, b.path, paths[2-i])
+		}
+	}
+	if data, _ := os.ReadFile(paths[0]); string(data) != "a" {
+		t.Fatalf("first backup overwritten: %q", data)
+	}
+}
+
+func TestRotateWriterCloseError(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "app.log")
+	w, err := NewRotateWriter(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = w.Write([]byte("before\n")); err != nil {
+		t.Fatal(err)
+	}
+	// 句柄已关闭, 切割返回关闭的错误, 但之后的写入使用新文件
+	_ = w.file.Close()
+	if err = w.Rotate(); err == nil {
+		t.Fatal("Rotate with closed file returns nil")
+	}
+	if _, err = w.Write([]byte("after\n")); err != nil {
+		t.Fatalf("Write after failed close = %v", err)
+	}
+	if err = w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if data, _ := os.ReadFile(filename); string(data) != "after\n" {
+		t.Fatalf("current file = %q", data)
+	}
+}
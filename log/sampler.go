@@ -0,0 +1,148 @@
+package log
+
+import (
+	"sync/atomic"
+	"time"
+
+	"github.com/songzhibin97/gkit/options"
+)
+
+// samplerBuckets 计数器数量, 不同消息按哈希共享计数器以限制内存,
+// 哈希冲突的消息共用同一个计数器, 互相占用限额
+const samplerBuckets = 4096
+
+var _ Logger = (*Sampler)(nil)
+
+type samplerConfig struct {
+	// first: 每个周期内每条消息最先输出的条数
+	first int64
+
+	// thereafter: 超过 first 后每 thereafter 条输出 1 条, <= 0 全部丢弃
+	thereafter int64
+
+	// tick: 计数周期
+	tick time.Duration
+
+	// keys: 作为消息的键, 取第一个匹配的值
+	keys []string
+}
+
+// SetSampleFirst 设置每个周期内每条消息最先输出的条数
+func SetSampleFirst(n int64) options.Option {
+	return func(c interface{}) {
+		c.(*samplerConfig).first = n
+	}
+}
+
+// SetSampleThereafter 设置超过 first 后每 m 条输出 1 条
+func SetSampleThereafter(m int64) options.Option {
+	return func(c interface{}) {
+		c.(*samplerConfig).thereafter = m
+	}
+}
+
+// SetSampleTick 设置计数周期
+func SetSampleTick(tick time.Duration) options.Option {
+	return func(c interface{}) {
+		c.(*samplerConfig).tick = tick
+	}
+}
+
+// SetSampleKeys 设置作为消息的键
+func SetSampleKeys(keys ...string) options.Option {
+	return func(c interface{}) {
+		c.(*samplerConfig).keys = keys
+	}
+}
+
+// counter 单个周期内的计数
+type counter struct {
+	resetAt int64
+	n       int64
+}
+
+// inc 计数加一并返回周期内的条数
+func (c *counter) inc(now int64, tick int64) int64 {
+	resetAt := atomic.LoadInt64(&c.resetAt)
+	if resetAt > now {
+		return atomic.AddInt64(&c.n, 1)
+	}
+	// 进入新的周期, 只有一个协程负责重置
+	if atomic.CompareAndSwapInt64(&c.resetAt, resetAt, now+tick) {
+		atomic.StoreInt64(&c.n, 1)
+		return 1
+	}
+	return atomic.AddInt64(&c.n, 1)
+}
+
+// Sampler 按消息采样的日志限流
+// 每个周期内相同等级、相同消息的日志先输出 first 条, 之后每 thereafter 条输出 1 条;
+// 消息按哈希映射到 4096 个计数器, 哈希冲突的不同消息会共用计数器并互相限流,
+// 消息种类较多时应调大 first 留出余量
+// Concurrency safety
+type Sampler struct {
+	logger   Logger
+	conf     *samplerConfig
+	counters *[samplerBuckets]counter
+	dropped  uint64
+}
+
+// Log 实现 Logger 接口
+func (s *Sampler) Log(level Lever, kvs ...interface{}) error {
+	c := &s.counters[s.bucket(level, kvs)]
+	n := c.inc(time.Now().UnixNano(), int64(s.conf.tick))
+	if n > s.conf.first && (s.conf.thereafter <= 0 || (n-s.conf.first)%s.conf.thereafter != 0) {
+		atomic.AddUint64(&s.dropped, 1)
+		return nil
+	}
+	return s.logger.Log(level, kvs...)
+}
+
+// Dropped 返回累计丢弃的日志条数
+func (s *Sampler) Dropped() uint64 {
+	return atomic.LoadUint64(&s.dropped)
+}
+
+// bucket 根据等级及消息选择计数器
+func (s *Sampler) bucket(level Lever, kvs []interface{}) uint32 {
+	var msg string
+	for i := 0; i+1 < len(kvs) && msg == ""; i += 2 {
+		key := renderKey(kvs[i])
+		for _, k := range s.conf.keys {
+			if key == k {
+				msg = renderKey(kvs[i+1])
+				break
+			}
+		}
+	}
+	// fnv-1a
+	h := uint32(2166136261) ^ uint32(level)
+	h *= 16777619
+	for i := 0; i < len(msg); i++ {
+		h ^= uint32(msg[i])
+		h *= 16777619
+	}
+	return h % samplerBuckets
+}
+
+// NewSampler 实例化日志采样器
+// 默认每秒每条消息输出前 100 条, 之后每 100 条输出 1 条
+func NewSampler(logger Logger, options ...options.Option) *Sampler {
+	conf := &samplerConfig{
+		first:      100,
+		thereafter: 100,
+		tick:       time.Second,
+		keys:       []string{"msg", "message"},
+	}
+	for _, option := range options {
+		option(conf)
+	}
+	if conf.tick <= 0 {
+		conf.tick = time.Second
+	}
+	return &Sampler{
+		logger:   logger,
+		conf:     conf,
+		counters: new([samplerBuckets]counter),
+	}
+}
@@ -0,0 +1,41 @@
+package log
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSampler(t *testing.T) {
+	var buf bytes.Buffer
+	logger := NewSampler(NewLogfmtLogger(&buf), SetSampleFirst(2), SetSampleThereafter(3))
+	for i := 0; i < 11; i++ {
+		_ = logger.Log(LevelError, "msg", "hot")
+	}
+	_ = logger.Log(LevelError, "msg", "cold")
+	_ = logger.Log(LevelInfo, "msg", "hot")
+
+	// hot: 1, 2 输出, 之后第 5, 8, 11 条输出
+	if got := strings.Count(buf.String(), "level=error msg=hot\n"); got != 5 {
+		t.Fatalf("hot lines = %d, want 5", got)
+	}
+	if !strings.Contains(buf.String(), "msg=cold") || !strings.Contains(buf.String(), "level=info msg=hot") {
+		t.Fatalf("distinct messages must be counted separately: %q", buf.String())
+	}
+	if got := logger.Dropped(); got != 6 {
+		t.Fatalf("Dropped = %d, want 6", got)
+	}
+}
+
+func TestSamplerTick(t *testing.T) {
+	var buf bytes.Buffer
+	logger := NewSampler(NewLogfmtLogger(&buf), SetSampleFirst(1), SetSampleThereafter(0), SetSampleTick(time.Millisecond*20))
+	_ = logger.Log(LevelError, "msg", "hot")
+	_ = logger.Log(LevelError, "msg", "hot")
+	time.Sleep(time.Millisecond * 30)
+	_ = logger.Log(LevelError, "msg", "hot")
+	if got := strings.Count(buf.String(), "\n"); got != 2 {
+		t.Fatalf("lines = %d, want 2", got)
+	}
+}
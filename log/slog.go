@@ -0,0 +1,170 @@
+//go:build go1.21
+
+package log
+
+import (
+	"context"
+	"log/slog"
+	"time"
+)
+
+var (
+	_ slog.Handler = (*slogHandler)(nil)
+	_ Logger       = (*slogLogger)(nil)
+)
+
+// slogHandler 由 gkit Logger 实现的 slog.Handler
+type slogHandler struct {
+	logger Logger
+	// prefix: WithAttrs 累积的 kv 键值对
+	prefix []interface{}
+	// group: WithGroup 累积的键前缀, 形如 "a.b."
+	group string
+}
+
+// NewSlogHandler 返回由 Logger 输出的 slog.Handler
+// slog 的等级映射为 Lever, 消息以 "msg" 键写入, 分组展开为 "group.key";
+// Logger 中的 Valuer(如 TraceID) 使用 Handle 传入的 ctx 求值,
+// Caller 取 slog.Record.PC 对应的 slog 调用方
+func NewSlogHandler(l Logger) slog.Handler {
+	return &slogHandler{logger: l}
+}
+
+// Enabled 等级过滤交由 Logger 处理
+func (h *slogHandler) Enabled(context.Context, slog.Level) bool {
+	return true
+}
+
+// Handle 将 slog.Record 转换为 kv 键值对输出
+func (h *slogHandler) Handle(ctx context.Context, r slog.Record) error {
+	kvs := make([]interface{}, 0, len(h.prefix)+2+r.NumAttrs()*2)
+	kvs = append(kvs, h.prefix...)
+	kvs = append(kvs, slog.MessageKey, r.Message)
+	r.Attrs(func(attr slog.Attr) bool {
+		kvs = appendAttr(kvs, h.group, attr)
+		return true
+	})
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	if r.PC != 0 {
+		ctx = context.WithValue(ctx, callerPCKey{}, r.PC)
+	}
+	bindValues(ctx, kvs)
+	return WithContext(ctx, h.logger).Log(fromSlogLevel(r.Level), kvs...)
+}
+
+// WithAttrs 返回携带 attrs 的 Handler
+func (h *slogHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	if len(attrs) == 0 {
+		return h
+	}
+	prefix := make([]interface{}, 0, len(h.prefix)+len(attrs)*2)
+	prefix = append(prefix, h.prefix...)
+	for _, attr := range attrs {
+		prefix = appendAttr(prefix, h.group, attr)
+	}
+	return &slogHandler{logger: h.logger, prefix: prefix, group: h.group}
+}
+
+// WithGroup 返回之后的 attr 均带有 name 前缀的 Handler
+func (h *slogHandler) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return h
+	}
+	return &slogHandler{logger: h.logger, prefix: h.prefix, group: h.group + name + "."}
+}
+
+// appendAttr 展开 attr 追加到 kvs, Valuer 保留原样, 在 Handle 时求值
+func appendAttr(kvs []interface{}, group string, attr slog.Attr) []interface{} {
+	attr.Value = attr.Value.Resolve()
+	if attr.Value.Kind() != slog.KindGroup {
+		if attr.Key == "" {
+			return kvs
+		}
+		return append(kvs, group+attr.Key, attr.Value.Any())
+	}
+	attrs := attr.Value.Group()
+	if len(attrs) == 0 {
+		return kvs
+	}
+	if attr.Key != "" {
+		group += attr.Key + "."
+	}
+	for _, a := range attrs {
+		kvs = appendAttr(kvs, group, a)
+	}
+	return kvs
+}
+
+// slogLogger 由 *slog.Logger 实现的 Logger
+type slogLogger struct {
+	logger *slog.Logger
+}
+
+// NewSlogLogger 返回由 *slog.Logger 输出的 Logger
+// kv 中的 "msg" 或 "message" 作为 slog 的消息, 其余作为 attr
+func NewSlogLogger(l *slog.Logger) Logger {
+	if l == nil {
+		l = slog.Default()
+	}
+	return &slogLogger{logger: l}
+}
+
+// Log 实现 Logger 接口
+func (l *slogLogger) Log(lever Lever, kvs ...interface{}) error {
+	ctx := context.Background()
+	level := toSlogLevel(lever)
+	handler := l.logger.Handler()
+	if !handler.Enabled(ctx, level) {
+		return nil
+	}
+	kvs = normalizeKvs(kvs)
+	var (
+		msg    string
+		hasMsg bool
+		attrs  = make([]slog.Attr, 0, len(kvs)/2)
+	)
+	for i := 0; i < len(kvs); i += 2 {
+		key := renderKey(kvs[i])
+		value := Value(ctx, kvs[i+1])
+		if !hasMsg && (key == slog.MessageKey || key == "message") {
+			if s, ok := value.(string); ok {
+				msg, hasMsg = s, true
+				continue
+			}
+		}
+		attrs = append(attrs, slog.Any(key, value))
+	}
+	r := slog.NewRecord(time.Now(), level, msg, 0)
+	r.AddAttrs(attrs...)
+	return handler.Handle(ctx, r)
+}
+
+// toSlogLevel Lever 转换为 slog.Level
+func toSlogLevel(lever Lever) slog.Level {
+	switch lever {
+	case LevelDebug:
+		return slog.LevelDebug
+	case LevelInfo:
+		return slog.LevelInfo
+	case LevelWarn:
+		return slog.LevelWarn
+	default:
+		return slog.LevelError
+	}
+}
+
+// fromSlogLevel slog.Level 转换为 Lever, 自定义等级向下取整
+func fromSlogLevel(level slog.Level) Lever {
+	switch {
+	case level < slog.LevelInfo:
+		return LevelDebug
+	case level < slog.LevelWarn:
+		return LevelInfo
+	case level < slog.LevelError:
+		return LevelWarn
+	default:
+		return LevelError
+	}
+}
@@ -0,0 +1,92 @@
+//go:build go1.21
+
+package log
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"strings"
+	"testing"
+
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestSlogHandler(t *testing.T) {
+	var buf bytes.Buffer
+	traceID, _ := trace.TraceIDFromHex("0102030405060708090a0b0c0d0e0f10")
+	ctx := trace.ContextWithSpanContext(context.Background(), trace.NewSpanContext(trace.SpanContextConfig{TraceID: traceID}))
+
+	gl := With(NewJSONLogger(&buf), "trace", TraceID(), "caller", DefaultCaller)
+	logger := slog.New(NewSlogHandler(gl)).With("app", "demo").WithGroup("req")
+	logger.WarnContext(ctx, "hello", "id", 1, slog.Group("user", "name", "gkit"), slog.Any("span", SpanID()))
+
+	var m map[string]interface{}
+	if err := json.Unmarshal(buf.Bytes(), &m); err != nil {
+		t.Fatalf("invalid json %q: %v", buf.String(), err)
+	}
+	want := map[string]interface{}{
+		"level":         "warn",
+		"trace":         traceID.String(),
+		"app":           "demo",
+		"msg":           "hello",
+		"req.id":        float64(1),
+		"req.user.name": "gkit",
+		"req.span":      "",
+	}
+	for k, v := range want {
+		if m[k] != v {
+			t.Errorf("%s = %v, want %v", k, m[k], v)
+		}
+	}
+	if caller, _ := m["caller"].(string); !strings.HasPrefix(caller, "slog_test.go:") {
+		t.Errorf("caller = %v, want slog_test.go", m["caller"])
+	}
+}
+
+func TestSlogHandlerCaller(t *testing.T) {
+	var buf bytes.Buffer
+	logger := slog.New(NewSlogHandler(With(NewLogfmtLogger(&buf), "caller", DefaultCaller)))
+	logger.Info("hello")
+	if got := buf.String(); !strings.Contains(got, "caller=slog_test.go:") {
+		t.Fatalf("got %q, want the caller in slog_test.go", got)
+	}
+}
+
+func TestSlogHandlerLevel(t *testing.T) {
+	var buf bytes.Buffer
+	logger := slog.New(NewSlogHandler(NewLogfmtLoggerWithLevel(&buf, LevelWarn)))
+	logger.Info("skip")
+	logger.Log(context.Background(), slog.LevelWarn+2, "custom")
+	if got, want := buf.String(), "level=warn msg=custom\n"; got != want {
+		t.Fatalf("got %q, want %q", got, want)
+	}
+}
+
+func TestSlogLogger(t *testing.T) {
+	var buf bytes.Buffer
+	sl := slog.New(slog.NewJSONHandler(&buf, &slog.HandlerOptions{Level: slog.LevelInfo}))
+	logger := With(NewSlogLogger(sl), "app", "demo")
+	h := NewHelper(logger)
+
+	h.Debug("skip")
+	h.Warnw("message", "hello", "k", "v", "odd")
+
+	var m map[string]interface{}
+	if err := json.Unmarshal(buf.Bytes(), &m); err != nil {
+		t.Fatalf("invalid json %q: %v", buf.String(), err)
+	}
+	want := map[string]interface{}{
+		"level": "WARN",
+		"msg":   "hello",
+		"app":   "demo",
+		"k":     "v",
+		"odd":   MissingValue,
+	}
+	for k, v := range want {
+		if m[k] != v {
+			t.Errorf("%s = %v, want %v", k, m[k], v)
+		}
+	}
+}
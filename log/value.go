@@ -31,9 +31,20 @@ func Value(ctx context.Context, value interface{}) interface{} {
 	return value
 }
 
+// callerPCKey ctx 中记录的调用方 pc, 经由 NewSlogHandler 输出时为 slog.Record.PC
+type callerPCKey struct{}
+
 // Caller 返回调用方的堆信息
+// ctx 记录了调用方 pc 时(经由 NewSlogHandler 输出)以该 pc 为准, 不受栈深度影响
 func Caller(depth int) Valuer {
 	return func(ctx context.Context) interface{} {
+		if ctx != nil {
+			if pc, ok := ctx.Value(callerPCKey{}).(uintptr); ok {
+				frame, _ := runtime.CallersFrames([]uintptr{pc}).Next()
+				idx := strings.LastIndexByte(frame.File, '/')
+				return frame.File[idx+1:] + ":" + strconv.Itoa(frame.Line)
+			}
+		}
 		_, file, line, _ := runtime.Caller(depth)
 		if strings.LastIndex(file, "gkit/log") > 0 {
 			_, file, line, _ = runtime.Caller(depth + 1)
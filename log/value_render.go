@@ -0,0 +1,105 @@
+package log
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"time"
+)
+
+const (
+	// LevelKey 结构化日志中日志等级的键
+	LevelKey = "level"
+
+	// MissingValue 键值对数量为奇数时补齐的值
+	MissingValue = "(MISSING)"
+
+	// fieldsKeyPrefix 与 LevelKey 冲突的键加上的前缀
+	fieldsKeyPrefix = "fields."
+)
+
+// normalizeKvs 补齐奇数长度的键值对
+func normalizeKvs(kvs []interface{}) []interface{} {
+	if len(kvs)%2 != 0 {
+		kvs = append(kvs, MissingValue)
+	}
+	return kvs
+}
+
+// renderKey 将任意类型的键转换为字符串
+func renderKey(key interface{}) string {
+	switch k := key.(type) {
+	case string:
+		return k
+	case nil:
+		return "null"
+	default:
+		return fmt.Sprint(renderValue(k))
+	}
+}
+
+// renderFieldKey 将键转换为字符串, 与 LevelKey 冲突的键重命名为 fields.level
+func renderFieldKey(key interface{}) string {
+	k := renderKey(key)
+	if k == LevelKey {
+		return fieldsKeyPrefix + k
+	}
+	return k
+}
+
+// renderValue 将值转换为可序列化的形式
+// error、fmt.Stringer 转为字符串, time.Time 使用 RFC3339Nano,
+// map 转为 map[string]interface{}, slice 与 array 逐个转换
+func renderValue(value interface{}) (ret interface{}) {
+	switch v := value.(type) {
+	case nil, string, bool, int, int8, int16, int32, int64,
+		uint, uint8, uint16, uint32, uint64, float32, float64, []byte:
+		return v
+	case time.Time:
+		return v.Format(time.RFC3339Nano)
+	case error:
+		return safeString(v, v.Error)
+	case json.Marshaler:
+		return v
+	case fmt.Stringer:
+		return safeString(v, v.String)
+	}
+
+	rv := reflect.ValueOf(value)
+	switch rv.Kind() {
+	case reflect.Map:
+		if rv.IsNil() {
+			return nil
+		}
+		m := make(map[string]interface{}, rv.Len())
+		iter := rv.MapRange()
+		for iter.Next() {
+			m[renderKey(iter.Key().Interface())] = renderValue(iter.Value().Interface())
+		}
+		return m
+	case reflect.Slice, reflect.Array:
+		if rv.Kind() == reflect.Slice && rv.IsNil() {
+			return nil
+		}
+		s := make([]interface{}, rv.Len())
+		for i := range s {
+			s[i] = renderValue(rv.Index(i).Interface())
+		}
+		return s
+	}
+	return value
+}
+
+// safeString 调用 nil 指针的 Error/String 可能 panic, 此时返回 "null"
+func safeString(v interface{}, f func() string) (s string) {
+	defer func() {
+		if r := recover(); r != nil {
+			if rv := reflect.ValueOf(v); rv.Kind() == reflect.Ptr && rv.IsNil() {
+				s = "null"
+				return
+			}
+			s = fmt.Sprintf("PANIC=%v", r)
+		}
+	}()
+	return f()
+}
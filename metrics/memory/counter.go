@@ -0,0 +1,47 @@
+package memory
+
+import "github.com/songzhibin97/gkit/metrics"
+
+var _ metrics.Counter = (*Counter)(nil)
+
+// Counter 内存实现的 metrics.Counter
+// With 返回的 Counter 与原 Counter 共享数据, 均可读取全部标签值的数据
+type Counter struct {
+	values *values
+	lvs    []string
+}
+
+// NewCounter 实例化内存 Counter
+func NewCounter() *Counter {
+	return &Counter{values: newValues()}
+}
+
+// With 返回绑定标签值的 Counter
+func (c *Counter) With(lvs ...string) metrics.Counter {
+	return &Counter{values: c.values, lvs: lvs}
+}
+
+// Inc 加一
+func (c *Counter) Inc() {
+	c.Add(1)
+}
+
+// Add 增加 delta
+func (c *Counter) Add(delta float64) {
+	c.values.update(c.lvs, func(v float64) float64 { return v + delta })
+}
+
+// Value 返回标签值 lvs 对应的值
+func (c *Counter) Value(lvs ...string) float64 {
+	return c.values.get(lvs)
+}
+
+// Snapshot 返回全部标签值对应的值, 按标签值排序
+func (c *Counter) Snapshot() []Sample {
+	return c.values.snapshot()
+}
+
+// Reset 清空数据
+func (c *Counter) Reset() {
+	c.values.reset()
+}
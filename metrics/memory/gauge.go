@@ -0,0 +1,51 @@
+package memory
+
+import "github.com/songzhibin97/gkit/metrics"
+
+var _ metrics.Gauge = (*Gauge)(nil)
+
+// Gauge 内存实现的 metrics.Gauge
+type Gauge struct {
+	values *values
+	lvs    []string
+}
+
+// NewGauge 实例化内存 Gauge
+func NewGauge() *Gauge {
+	return &Gauge{values: newValues()}
+}
+
+// With 返回绑定标签值的 Gauge
+func (g *Gauge) With(lvs ...string) metrics.Gauge {
+	return &Gauge{values: g.values, lvs: lvs}
+}
+
+// Set 设置值
+func (g *Gauge) Set(value float64) {
+	g.values.update(g.lvs, func(float64) float64 { return value })
+}
+
+// Add 增加 delta
+func (g *Gauge) Add(delta float64) {
+	g.values.update(g.lvs, func(v float64) float64 { return v + delta })
+}
+
+// Sub 减少 delta
+func (g *Gauge) Sub(delta float64) {
+	g.Add(-delta)
+}
+
+// Value 返回标签值 lvs 对应的值
+func (g *Gauge) Value(lvs ...string) float64 {
+	return g.values.get(lvs)
+}
+
+// Snapshot 返回全部标签值对应的值, 按标签值排序
+func (g *Gauge) Snapshot() []Sample {
+	return g.values.snapshot()
+}
+
+// Reset 清空数据
+func (g *Gauge) Reset() {
+	g.values.reset()
+}
@@ -0,0 +1,83 @@
+package memory
+
+// package memory: 内存实现的指标, 用于单元测试中断言指标
+
+import (
+	"sort"
+	"strings"
+	"sync"
+)
+
+// separator 拼接标签值的分隔符
+const separator = "\xff"
+
+// Sample 一组标签值对应的指标值
+type Sample struct {
+	LabelValues []string
+	Value       float64
+}
+
+// key 标签值拼接为 map 的键
+func key(lvs []string) string {
+	return strings.Join(lvs, separator)
+}
+
+// values 按标签值存储的 float64
+type values struct {
+	mu sync.RWMutex
+	m  map[string]float64
+}
+
+func newValues() *values {
+	return &values{m: make(map[string]float64)}
+}
+
+func (v *values) update(lvs []string, f func(float64) float64) {
+	k := key(lvs)
+	v.mu.Lock()
+	v.m[k] = f(v.m[k])
+	v.mu.Unlock()
+}
+
+func (v *values) get(lvs []string) float64 {
+	v.mu.RLock()
+	defer v.mu.RUnlock()
+	return v.m[key(lvs)]
+}
+
+func (v *values) snapshot() []Sample {
+	v.mu.RLock()
+	samples := make([]Sample, 0, len(v.m))
+	for k, value := range v.m {
+		samples = append(samples, Sample{LabelValues: split(k), Value: value})
+	}
+	v.mu.RUnlock()
+	sort.Slice(samples, func(i, j int) bool {
+		return less(samples[i].LabelValues, samples[j].LabelValues)
+	})
+	return samples
+}
+
+func (v *values) reset() {
+	v.mu.Lock()
+	v.m = make(map[string]float64)
+	v.mu.Unlock()
+}
+
+// less 按标签值逐个比较
+func less(a, b []string) bool {
+	for i := 0; i < len(a) && i < len(b); i++ {
+		if a[i] != b[i] {
+			return a[i] < b[i]
+		}
+	}
+	return len(a) < len(b)
+}
+
+// split 还原标签值
+func split(k string) []string {
+	if k == "" {
+		return nil
+	}
+	return strings.Split(k, separator)
+}
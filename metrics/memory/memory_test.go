@@ -0,0 +1,69 @@
+package memory
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCounter(t *testing.T) {
+	c := NewCounter()
+	c.Inc()
+	c.With("200", "/b").Add(2)
+	c.With("200", "/a").Inc()
+	c.With("200", "/a").Inc()
+
+	assert.Equal(t, float64(1), c.Value())
+	assert.Equal(t, float64(2), c.Value("200", "/a"))
+	assert.Equal(t, float64(0), c.Value("500", "/a"))
+	assert.Equal(t, []Sample{
+		{LabelValues: nil, Value: 1},
+		{LabelValues: []string{"200", "/a"}, Value: 2},
+		{LabelValues: []string{"200", "/b"}, Value: 2},
+	}, c.Snapshot())
+
+	c.Reset()
+	assert.Empty(t, c.Snapshot())
+}
+
+func TestGauge(t *testing.T) {
+	g := NewGauge()
+	db := g.With("db")
+	db.Set(10)
+	db.Add(5)
+	db.Sub(3)
+	assert.Equal(t, float64(12), g.Value("db"))
+	assert.Equal(t, []Sample{{LabelValues: []string{"db"}, Value: 12}}, g.Snapshot())
+}
+
+func TestObserver(t *testing.T) {
+	o := NewObserver()
+	get := o.With("get")
+	get.Observe(1)
+	get.Observe(2)
+	o.With("put").Observe(3)
+
+	assert.Equal(t, []float64{1, 2}, o.Observations("get"))
+	snapshot := o.Snapshot()
+	assert.Len(t, snapshot, 2)
+	assert.Equal(t, []string{"get"}, snapshot[0].LabelValues)
+	assert.Equal(t, 2, snapshot[0].Count())
+	assert.Equal(t, float64(3), snapshot[0].Sum())
+}
+
+func TestConcurrent(t *testing.T) {
+	c := NewCounter()
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				c.With("k").Inc()
+			}
+		}()
+	}
+	wg.Wait()
+	assert.Equal(t, float64(1000), c.Value("k"))
+}
@@ -0,0 +1,90 @@
+package memory
+
+import (
+	"sort"
+	"sync"
+
+	"github.com/songzhibin97/gkit/metrics"
+)
+
+var _ metrics.Observer = (*Observer)(nil)
+
+// ObserverSample 一组标签值对应的全部观测值
+type ObserverSample struct {
+	LabelValues  []string
+	Observations []float64
+}
+
+// Count 观测次数
+func (s ObserverSample) Count() int {
+	return len(s.Observations)
+}
+
+// Sum 观测值之和
+func (s ObserverSample) Sum() float64 {
+	var sum float64
+	for _, v := range s.Observations {
+		sum += v
+	}
+	return sum
+}
+
+type observations struct {
+	mu sync.RWMutex
+	m  map[string][]float64
+}
+
+// Observer 内存实现的 metrics.Observer, 按顺序保存全部观测值
+type Observer struct {
+	data *observations
+	lvs  []string
+}
+
+// NewObserver 实例化内存 Observer
+func NewObserver() *Observer {
+	return &Observer{data: &observations{m: make(map[string][]float64)}}
+}
+
+// With 返回绑定标签值的 Observer
+func (o *Observer) With(lvs ...string) metrics.Observer {
+	return &Observer{data: o.data, lvs: lvs}
+}
+
+// Observe 记录观测值
+func (o *Observer) Observe(value float64) {
+	k := key(o.lvs)
+	o.data.mu.Lock()
+	o.data.m[k] = append(o.data.m[k], value)
+	o.data.mu.Unlock()
+}
+
+// Observations 返回标签值 lvs 对应的全部观测值
+func (o *Observer) Observations(lvs ...string) []float64 {
+	o.data.mu.RLock()
+	defer o.data.mu.RUnlock()
+	return append([]float64(nil), o.data.m[key(lvs)]...)
+}
+
+// Snapshot 返回全部标签值对应的观测值, 按标签值排序
+func (o *Observer) Snapshot() []ObserverSample {
+	o.data.mu.RLock()
+	samples := make([]ObserverSample, 0, len(o.data.m))
+	for k, values := range o.data.m {
+		samples = append(samples, ObserverSample{
+			LabelValues:  split(k),
+			Observations: append([]float64(nil), values...),
+		})
+	}
+	o.data.mu.RUnlock()
+	sort.Slice(samples, func(i, j int) bool {
+		return less(samples[i].LabelValues, samples[j].LabelValues)
+	})
+	return samples
+}
+
+// Reset 清空数据
+func (o *Observer) Reset() {
+	o.data.mu.Lock()
+	o.data.m = make(map[string][]float64)
+	o.data.mu.Unlock()
+}
@@ -0,0 +1,84 @@
+package metrics
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	"github.com/songzhibin97/gkit/errors"
+	"github.com/songzhibin97/gkit/middleware"
+	"github.com/songzhibin97/gkit/options"
+	"github.com/songzhibin97/gkit/trace"
+)
+
+// config 请求指标配置
+// 标签值依次为 kind, operation, code, reason
+type config struct {
+	// requests: 请求次数
+	requests Counter
+
+	// seconds: 请求耗时, 单位秒
+	seconds Observer
+}
+
+// WithRequests 设置记录请求次数的 Counter
+func WithRequests(c Counter) options.Option {
+	return func(o interface{}) {
+		o.(*config).requests = c
+	}
+}
+
+// WithSeconds 设置记录请求耗时的 Observer
+func WithSeconds(c Observer) options.Option {
+	return func(o interface{}) {
+		o.(*config).seconds = c
+	}
+}
+
+// Server 服务端请求指标中间件
+// 标签值依次为 kind, operation, code, reason, 其中 kind 与 operation 取自 trace.Transporter
+func Server(opts ...options.Option) middleware.MiddleWare {
+	return newMiddleware(trace.FromServerTransportContext, opts...)
+}
+
+// Client 客户端请求指标中间件, 标签同 Server
+func Client(opts ...options.Option) middleware.MiddleWare {
+	return newMiddleware(trace.FromClientTransportContext, opts...)
+}
+
+func newMiddleware(from func(context.Context) (trace.Transporter, bool), opts ...options.Option) middleware.MiddleWare {
+	conf := &config{}
+	for _, opt := range opts {
+		opt(conf)
+	}
+	return func(handler middleware.Endpoint) middleware.Endpoint {
+		if conf.requests == nil && conf.seconds == nil {
+			return handler
+		}
+		return func(ctx context.Context, req interface{}) (interface{}, error) {
+			var kind, operation string
+			if tr, ok := from(ctx); ok {
+				kind = tr.Kind().String()
+				operation = tr.Operation()
+			}
+			start := time.Now()
+			reply, err := handler(ctx, req)
+			var (
+				code   int
+				reason string
+			)
+			if err != nil {
+				se := errors.FromError(err)
+				code, reason = int(se.Code), se.Reason
+			}
+			lvs := []string{kind, operation, strconv.Itoa(code), reason}
+			if conf.requests != nil {
+				conf.requests.With(lvs...).Inc()
+			}
+			if conf.seconds != nil {
+				conf.seconds.With(lvs...).Observe(time.Since(start).Seconds())
+			}
+			return reply, err
+		}
+	}
+}
@@ -0,0 +1,66 @@
+package metrics_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/songzhibin97/gkit/errors"
+	"github.com/songzhibin97/gkit/metrics"
+	"github.com/songzhibin97/gkit/metrics/memory"
+	"github.com/songzhibin97/gkit/trace"
+	"github.com/stretchr/testify/assert"
+)
+
+type transport struct {
+	trace.Transport
+	kind trace.Kind
+}
+
+func (tr *transport) Kind() trace.Kind  { return tr.kind }
+func (tr *transport) Operation() string { return "/helloworld.Greeter/SayHello" }
+
+func TestServer(t *testing.T) {
+	requests := memory.NewCounter()
+	seconds := memory.NewObserver()
+	handler := metrics.Server(metrics.WithRequests(requests), metrics.WithSeconds(seconds))(
+		func(ctx context.Context, req interface{}) (interface{}, error) {
+			time.Sleep(10 * time.Millisecond)
+			if req == "bad" {
+				return nil, errors.BadRequest("INVALID_NAME", "invalid name")
+			}
+			return "ok", nil
+		})
+
+	ctx := trace.NewServerTransportContext(context.Background(), &trace.Transport{})
+	trace.SetOperation(ctx, "/hello")
+	reply, err := handler(ctx, "good")
+	assert.NoError(t, err)
+	assert.Equal(t, "ok", reply)
+	_, err = handler(ctx, "bad")
+	assert.True(t, errors.IsBadRequest(err))
+
+	assert.Equal(t, float64(1), requests.Value("HTTP", "/hello", "0", ""))
+	assert.Equal(t, float64(1), requests.Value("HTTP", "/hello", "400", "INVALID_NAME"))
+	observations := seconds.Observations("HTTP", "/hello", "0", "")
+	assert.Len(t, observations, 1)
+	assert.GreaterOrEqual(t, observations[0], 0.01)
+}
+
+func TestClient(t *testing.T) {
+	requests := memory.NewCounter()
+	handler := metrics.Client(metrics.WithRequests(requests))(
+		func(ctx context.Context, req interface{}) (interface{}, error) {
+			return nil, context.DeadlineExceeded
+		})
+
+	ctx := trace.NewClientTransportContext(context.Background(), &transport{kind: trace.KindGRPC})
+	_, _ = handler(ctx, nil)
+	// 未携带 Transporter 时 kind 与 operation 为空
+	_, _ = handler(context.Background(), nil)
+
+	assert.Equal(t, []memory.Sample{
+		{LabelValues: []string{"", "", "500", errors.UnknownReason}, Value: 1},
+		{LabelValues: []string{"GRPC", "/helloworld.Greeter/SayHello", "500", errors.UnknownReason}, Value: 1},
+	}, requests.Snapshot())
+}
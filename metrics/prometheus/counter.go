@@ -0,0 +1,34 @@
+package prometheus
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/songzhibin97/gkit/metrics"
+)
+
+var _ metrics.Counter = (*counter)(nil)
+
+type counter struct {
+	cv  *prometheus.CounterVec
+	lvs []string
+}
+
+// NewCounter 基于 prometheus.CounterVec 实现 metrics.Counter
+// With 传入的 lvs 需与 CounterVec 的标签按顺序一一对应
+func NewCounter(cv *prometheus.CounterVec) metrics.Counter {
+	return &counter{cv: cv}
+}
+
+// With 返回绑定标签值的 Counter
+func (c *counter) With(lvs ...string) metrics.Counter {
+	return &counter{cv: c.cv, lvs: lvs}
+}
+
+// Inc 加一
+func (c *counter) Inc() {
+	c.cv.WithLabelValues(c.lvs...).Inc()
+}
+
+// Add 增加 delta, delta 不能为负
+func (c *counter) Add(delta float64) {
+	c.cv.WithLabelValues(c.lvs...).Add(delta)
+}
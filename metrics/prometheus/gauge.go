@@ -0,0 +1,38 @@
+package prometheus
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/songzhibin97/gkit/metrics"
+)
+
+var _ metrics.Gauge = (*gauge)(nil)
+
+type gauge struct {
+	gv  *prometheus.GaugeVec
+	lvs []string
+}
+
+// NewGauge 基于 prometheus.GaugeVec 实现 metrics.Gauge
+func NewGauge(gv *prometheus.GaugeVec) metrics.Gauge {
+	return &gauge{gv: gv}
+}
+
+// With 返回绑定标签值的 Gauge
+func (g *gauge) With(lvs ...string) metrics.Gauge {
+	return &gauge{gv: g.gv, lvs: lvs}
+}
+
+// Set 设置值
+func (g *gauge) Set(value float64) {
+	g.gv.WithLabelValues(g.lvs...).Set(value)
+}
+
+// Add 增加 delta
+func (g *gauge) Add(delta float64) {
+	g.gv.WithLabelValues(g.lvs...).Add(delta)
+}
+
+// Sub 减少 delta
+func (g *gauge) Sub(delta float64) {
+	g.gv.WithLabelValues(g.lvs...).Sub(delta)
+}
@@ -0,0 +1,28 @@
+package prometheus
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/songzhibin97/gkit/metrics"
+)
+
+var _ metrics.Observer = (*histogram)(nil)
+
+type histogram struct {
+	hv  *prometheus.HistogramVec
+	lvs []string
+}
+
+// NewHistogram 基于 prometheus.HistogramVec 实现 metrics.Observer
+func NewHistogram(hv *prometheus.HistogramVec) metrics.Observer {
+	return &histogram{hv: hv}
+}
+
+// With 返回绑定标签值的 Observer
+func (h *histogram) With(lvs ...string) metrics.Observer {
+	return &histogram{hv: h.hv, lvs: lvs}
+}
+
+// Observe 记录观测值
+func (h *histogram) Observe(value float64) {
+	h.hv.WithLabelValues(h.lvs...).Observe(value)
+}
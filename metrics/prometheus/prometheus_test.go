@@ -0,0 +1,64 @@
+package prometheus
+
+import (
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCounter(t *testing.T) {
+	cv := prometheus.NewCounterVec(prometheus.CounterOpts{Name: "test_counter"}, []string{"code", "path"})
+	c := NewCounter(cv)
+	c.With("200", "/a").Inc()
+	c.With("200", "/a").Add(2)
+	c.With("500", "/a").Inc()
+
+	m := &dto.Metric{}
+	assert.NoError(t, cv.WithLabelValues("200", "/a").Write(m))
+	assert.Equal(t, float64(3), m.GetCounter().GetValue())
+	assert.NoError(t, cv.WithLabelValues("500", "/a").Write(m))
+	assert.Equal(t, float64(1), m.GetCounter().GetValue())
+}
+
+func TestGauge(t *testing.T) {
+	gv := prometheus.NewGaugeVec(prometheus.GaugeOpts{Name: "test_gauge"}, []string{"pool"})
+	g := NewGauge(gv).With("db")
+	g.Set(10)
+	g.Add(5)
+	g.Sub(3)
+
+	m := &dto.Metric{}
+	assert.NoError(t, gv.WithLabelValues("db").Write(m))
+	assert.Equal(t, float64(12), m.GetGauge().GetValue())
+}
+
+func TestHistogram(t *testing.T) {
+	hv := prometheus.NewHistogramVec(prometheus.HistogramOpts{Name: "test_histogram", Buckets: []float64{1, 5}}, []string{"op"})
+	h := NewHistogram(hv).With("get")
+	h.Observe(0.5)
+	h.Observe(3)
+	h.Observe(10)
+
+	m := &dto.Metric{}
+	assert.NoError(t, hv.WithLabelValues("get").(prometheus.Metric).Write(m))
+	assert.Equal(t, uint64(3), m.GetHistogram().GetSampleCount())
+	assert.Equal(t, 13.5, m.GetHistogram().GetSampleSum())
+	assert.Equal(t, uint64(1), m.GetHistogram().GetBucket()[0].GetCumulativeCount())
+	assert.Equal(t, uint64(2), m.GetHistogram().GetBucket()[1].GetCumulativeCount())
+}
+
+func TestSummary(t *testing.T) {
+	sv := prometheus.NewSummaryVec(prometheus.SummaryOpts{Name: "test_summary", Objectives: map[float64]float64{0.5: 0.05}}, []string{"op"})
+	s := NewSummary(sv).With("get")
+	for i := 1; i <= 3; i++ {
+		s.Observe(float64(i))
+	}
+
+	m := &dto.Metric{}
+	assert.NoError(t, sv.WithLabelValues("get").(prometheus.Metric).Write(m))
+	assert.Equal(t, uint64(3), m.GetSummary().GetSampleCount())
+	assert.Equal(t, float64(6), m.GetSummary().GetSampleSum())
+	assert.Equal(t, float64(2), m.GetSummary().GetQuantile()[0].GetValue())
+}
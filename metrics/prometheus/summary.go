@@ -0,0 +1,28 @@
+package prometheus
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/songzhibin97/gkit/metrics"
+)
+
+var _ metrics.Observer = (*summary)(nil)
+
+type summary struct {
+	sv  *prometheus.SummaryVec
+	lvs []string
+}
+
+// NewSummary 基于 prometheus.SummaryVec 实现 metrics.Observer
+func NewSummary(sv *prometheus.SummaryVec) metrics.Observer {
+	return &summary{sv: sv}
+}
+
+// With 返回绑定标签值的 Observer
+func (s *summary) With(lvs ...string) metrics.Observer {
+	return &summary{sv: s.sv, lvs: lvs}
+}
+
+// Observe 记录观测值
+func (s *summary) Observe(value float64) {
+	s.sv.WithLabelValues(s.lvs...).Observe(value)
+}
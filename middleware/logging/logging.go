@@ -0,0 +1,62 @@
+package logging
+
+import (
+	"context"
+	"time"
+
+	"github.com/songzhibin97/gkit/errors"
+	"github.com/songzhibin97/gkit/log"
+	"github.com/songzhibin97/gkit/middleware"
+	"github.com/songzhibin97/gkit/trace"
+)
+
+// package logging: 访问日志中间件
+
+// Server 服务端访问日志中间件
+// 记录 kind, operation, code, reason, latency(秒), 失败时记录 error 并以 LevelError 输出
+func Server(logger log.Logger) middleware.MiddleWare {
+	return newMiddleware(logger, "server", trace.FromServerTransportContext)
+}
+
+// Client 客户端访问日志中间件, 字段同 Server
+func Client(logger log.Logger) middleware.MiddleWare {
+	return newMiddleware(logger, "client", trace.FromClientTransportContext)
+}
+
+func newMiddleware(logger log.Logger, component string, from func(context.Context) (trace.Transporter, bool)) middleware.MiddleWare {
+	return func(handler middleware.Endpoint) middleware.Endpoint {
+		return func(ctx context.Context, req interface{}) (interface{}, error) {
+			var kind, operation string
+			if tr, ok := from(ctx); ok {
+				kind = tr.Kind().String()
+				operation = tr.Operation()
+			}
+			start := time.Now()
+			reply, err := handler(ctx, req)
+			var (
+				code   int32
+				reason string
+				level  = log.LevelInfo
+			)
+			kvs := []interface{}{
+				"component", component,
+				"kind", kind,
+				"operation", operation,
+			}
+			if err != nil {
+				se := errors.FromError(err)
+				code, reason, level = se.Code, se.Reason, log.LevelError
+			}
+			kvs = append(kvs,
+				"code", code,
+				"reason", reason,
+				"latency", time.Since(start).Seconds(),
+			)
+			if err != nil {
+				kvs = append(kvs, "error", err.Error())
+			}
+			_ = log.WithContext(ctx, logger).Log(level, kvs...)
+			return reply, err
+		}
+	}
+}
@@ -0,0 +1,55 @@
+package logging
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/songzhibin97/gkit/errors"
+	"github.com/songzhibin97/gkit/log"
+	"github.com/songzhibin97/gkit/trace"
+)
+
+func TestServer(t *testing.T) {
+	tests := []struct {
+		name       string
+		err        error
+		wantLevel  string
+		wantCode   float64
+		wantReason string
+	}{
+		{name: "success", wantLevel: "info"},
+		{name: "failure", err: errors.NotFound("USER_NOT_FOUND", "user not found"), wantLevel: "error", wantCode: 404, wantReason: "USER_NOT_FOUND"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			h := Server(log.NewJSONLogger(&buf))(func(ctx context.Context, req interface{}) (interface{}, error) {
+				return "reply", tt.err
+			})
+			req := httptest.NewRequest("GET", "/users/1", nil)
+			ctx := trace.NewServerTransportContext(context.Background(),
+				trace.NewTransport("", "/users/1", "/users/{id}", req, nil))
+			reply, err := h(ctx, nil)
+			if reply != "reply" || err != tt.err {
+				t.Fatalf("got (%v, %v), want (reply, %v)", reply, err, tt.err)
+			}
+			var entry map[string]interface{}
+			if err := json.Unmarshal(buf.Bytes(), &entry); err != nil {
+				t.Fatalf("decode log %q: %v", buf.String(), err)
+			}
+			if entry["level"] != tt.wantLevel || entry["component"] != "server" || entry["kind"] != "HTTP" ||
+				entry["operation"] != "/users/1" || entry["code"] != tt.wantCode || entry["reason"] != tt.wantReason {
+				t.Fatalf("log = %v", entry)
+			}
+			if _, ok := entry["latency"].(float64); !ok {
+				t.Fatalf("latency = %v, want seconds", entry["latency"])
+			}
+			if _, ok := entry["error"]; ok != (tt.err != nil) {
+				t.Fatalf("error field present = %v, want %v", ok, tt.err != nil)
+			}
+		})
+	}
+}
@@ -0,0 +1,88 @@
+package recovery
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"runtime"
+
+	"github.com/songzhibin97/gkit/errors"
+	"github.com/songzhibin97/gkit/log"
+	"github.com/songzhibin97/gkit/middleware"
+	"github.com/songzhibin97/gkit/options"
+)
+
+// package recovery: 捕获 Endpoint 中的 panic, 记录堆栈并转换为错误
+
+// ErrUnknownRequest 默认 panic 转换后的错误
+var ErrUnknownRequest = errors.InternalServer("UNKNOWN", "unknown request error")
+
+// stackSize 记录堆栈的最大字节数
+const stackSize = 64 << 10
+
+// Handler panic 处理函数, 返回值作为 Endpoint 的错误返回
+type Handler func(ctx context.Context, req, err interface{}) error
+
+type config struct {
+	// logger: 记录 panic 及堆栈
+	logger log.Logger
+
+	// handler: 将 panic 转换为错误
+	handler Handler
+}
+
+// WithLogger 设置记录 panic 的 Logger, 默认 log.DefaultLogger
+func WithLogger(logger log.Logger) options.Option {
+	return func(o interface{}) {
+		o.(*config).logger = logger
+	}
+}
+
+// WithHandler 设置 panic 处理函数, 默认返回 ErrUnknownRequest
+func WithHandler(h Handler) options.Option {
+	return func(o interface{}) {
+		o.(*config).handler = h
+	}
+}
+
+// Recovery panic 恢复中间件
+// panic 时以 LevelError 记录 panic 值及堆栈, 并返回 handler 转换后的错误
+// http.ErrAbortHandler 不做恢复, 继续向上 panic
+func Recovery(opts ...options.Option) middleware.MiddleWare {
+	conf := &config{
+		logger: log.DefaultLogger,
+		handler: func(ctx context.Context, req, err interface{}) error {
+			return ErrUnknownRequest
+		},
+	}
+	for _, opt := range opts {
+		opt(conf)
+	}
+	return func(handler middleware.Endpoint) middleware.Endpoint {
+		return func(ctx context.Context, req interface{}) (reply interface{}, err error) {
+			completed := false
+			// 使用完成标记而不是 recover() 的返回值判断, 保证 panic(nil) 同样被处理
+			defer func() {
+				if completed {
+					return
+				}
+				rerr := recover()
+				// http.ErrAbortHandler 用于中止响应, 原样抛出交由 net/http 处理
+				if rerr == http.ErrAbortHandler {
+					panic(rerr)
+				}
+				buf := make([]byte, stackSize)
+				buf = buf[:runtime.Stack(buf, false)]
+				_ = log.WithContext(ctx, conf.logger).Log(log.LevelError,
+					"msg", "panic recovered",
+					"panic", fmt.Sprintf("%v", rerr),
+					"stack", string(buf),
+				)
+				reply, err = nil, conf.handler(ctx, req, rerr)
+			}()
+			reply, err = handler(ctx, req)
+			completed = true
+			return reply, err
+		}
+	}
+}
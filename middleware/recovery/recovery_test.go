@@ -0,0 +1,85 @@
+package recovery
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/songzhibin97/gkit/errors"
+	"github.com/songzhibin97/gkit/log"
+)
+
+func TestRecovery(t *testing.T) {
+	var buf bytes.Buffer
+	h := Recovery(WithLogger(log.NewJSONLogger(&buf)))(func(ctx context.Context, req interface{}) (interface{}, error) {
+		panic("boom")
+	})
+	reply, err := h(context.Background(), "req")
+	if reply != nil {
+		t.Fatalf("reply = %v, want nil", reply)
+	}
+	if !errors.IsInternalServer(err) || errors.Reason(err) != "UNKNOWN" {
+		t.Fatalf("err = %v, want ErrUnknownRequest", err)
+	}
+	var entry map[string]interface{}
+	if err := json.Unmarshal(buf.Bytes(), &entry); err != nil {
+		t.Fatalf("decode log %q: %v", buf.String(), err)
+	}
+	if entry["level"] != "error" || entry["panic"] != "boom" {
+		t.Fatalf("log = %v, want error level with panic value", entry)
+	}
+	if stack, _ := entry["stack"].(string); !strings.Contains(stack, "recovery.TestRecovery") {
+		t.Fatalf("stack = %q, want caller frames", stack)
+	}
+}
+
+func TestRecoveryHandler(t *testing.T) {
+	want := errors.New(503, "PANIC", "custom")
+	var got interface{}
+	h := Recovery(
+		WithLogger(log.NewJSONLogger(&bytes.Buffer{})),
+		WithHandler(func(ctx context.Context, req, err interface{}) error {
+			got = err
+			return want
+		}),
+	)(func(ctx context.Context, req interface{}) (interface{}, error) {
+		panic(42)
+	})
+	if _, err := h(context.Background(), nil); err != want {
+		t.Fatalf("err = %v, want %v", err, want)
+	}
+	if got != 42 {
+		t.Fatalf("handler got %v, want 42", got)
+	}
+}
+
+func TestRecoveryPassThrough(t *testing.T) {
+	want := errors.BadRequest("BAD", "bad")
+	h := Recovery()(func(ctx context.Context, req interface{}) (interface{}, error) {
+		return req, want
+	})
+	reply, err := h(context.Background(), "req")
+	if reply != "req" || err != want {
+		t.Fatalf("got (%v, %v), want (req, %v)", reply, err, want)
+	}
+}
+
+func TestRecoveryAbortHandler(t *testing.T) {
+	var buf bytes.Buffer
+	h := Recovery(WithLogger(log.NewJSONLogger(&buf)))(func(ctx context.Context, req interface{}) (interface{}, error) {
+		panic(http.ErrAbortHandler)
+	})
+	defer func() {
+		if r := recover(); r != http.ErrAbortHandler {
+			t.Fatalf("recovered %v, want http.ErrAbortHandler", r)
+		}
+		if buf.Len() != 0 {
+			t.Fatalf("log = %q, want nothing", buf.String())
+		}
+	}()
+	_, _ = h(context.Background(), nil)
+	t.Fatal("http.ErrAbortHandler was swallowed")
+}
@@ -0,0 +1,83 @@
+package timeout
+
+import (
+	"context"
+	stderrors "errors"
+	"time"
+
+	"github.com/songzhibin97/gkit/errors"
+	"github.com/songzhibin97/gkit/middleware"
+	"github.com/songzhibin97/gkit/options"
+	ctime "github.com/songzhibin97/gkit/timeout"
+	"github.com/songzhibin97/gkit/trace"
+)
+
+// package timeout: 按 Endpoint 设置超时的中间件
+// 超时通过 ctx 传递, 需要 Endpoint 自身响应 ctx.Done()
+
+// ErrTimeout 超时后返回的错误
+var ErrTimeout = errors.GatewayTimeout("TIMEOUT", "request timeout")
+
+type config struct {
+	// timeout: 默认超时时间, <= 0 不设置
+	timeout time.Duration
+
+	// operations: 按 operation 设置的超时时间, 优先于 timeout
+	operations map[string]time.Duration
+}
+
+// WithTimeout 设置默认超时时间
+func WithTimeout(d time.Duration) options.Option {
+	return func(o interface{}) {
+		o.(*config).timeout = d
+	}
+}
+
+// WithOperation 设置指定 operation 的超时时间, operation 取自 trace.Transporter
+func WithOperation(operation string, d time.Duration) options.Option {
+	return func(o interface{}) {
+		o.(*config).operations[operation] = d
+	}
+}
+
+// Timeout 超时中间件
+// 通过 timeout.Shrink 与上游链路的剩余时间取较小值, 超时时返回 ErrTimeout
+func Timeout(opts ...options.Option) middleware.MiddleWare {
+	conf := &config{
+		operations: make(map[string]time.Duration),
+	}
+	for _, opt := range opts {
+		opt(conf)
+	}
+	return func(handler middleware.Endpoint) middleware.Endpoint {
+		return func(ctx context.Context, req interface{}) (interface{}, error) {
+			d := conf.timeout
+			if len(conf.operations) > 0 {
+				if v, ok := conf.operations[operation(ctx)]; ok {
+					d = v
+				}
+			}
+			if d <= 0 {
+				return handler(ctx, req)
+			}
+			_, ctx, cancel := ctime.Shrink(ctx, d)
+			defer cancel()
+			reply, err := handler(ctx, req)
+			if err != nil && stderrors.Is(err, context.DeadlineExceeded) {
+				return reply, ErrTimeout
+			}
+			return reply, err
+		}
+	}
+}
+
+// operation 返回 ctx 中 trace.Transporter 的 operation, 优先服务端
+func operation(ctx context.Context) string {
+	if tr, ok := trace.FromServerTransportContext(ctx); ok {
+		return tr.Operation()
+	}
+	if tr, ok := trace.FromClientTransportContext(ctx); ok {
+		return tr.Operation()
+	}
+	return ""
+}
@@ -0,0 +1,73 @@
+package timeout
+
+import (
+	"context"
+	"errors"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/songzhibin97/gkit/trace"
+)
+
+func waitDeadline(ctx context.Context, req interface{}) (interface{}, error) {
+	deadline, ok := ctx.Deadline()
+	if !ok {
+		return time.Duration(0), nil
+	}
+	<-ctx.Done()
+	return time.Until(deadline), ctx.Err()
+}
+
+func TestTimeout(t *testing.T) {
+	h := Timeout(WithTimeout(10 * time.Millisecond))(waitDeadline)
+	if _, err := h(context.Background(), nil); err != ErrTimeout {
+		t.Fatalf("err = %v, want ErrTimeout", err)
+	}
+}
+
+func TestTimeoutKeepsShorterUpstreamDeadline(t *testing.T) {
+	var got time.Duration
+	h := Timeout(WithTimeout(time.Hour))(func(ctx context.Context, req interface{}) (interface{}, error) {
+		deadline, _ := ctx.Deadline()
+		got = time.Until(deadline)
+		return nil, nil
+	})
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if _, err := h(ctx, nil); err != nil {
+		t.Fatal(err)
+	}
+	if got > time.Second {
+		t.Fatalf("deadline in %v, want upstream deadline <= 1s", got)
+	}
+}
+
+func TestTimeoutOperation(t *testing.T) {
+	var deadlines []bool
+	h := Timeout(WithOperation("/slow", 0), WithOperation("/fast", time.Second))(func(ctx context.Context, req interface{}) (interface{}, error) {
+		_, ok := ctx.Deadline()
+		deadlines = append(deadlines, ok)
+		return nil, nil
+	})
+	for _, op := range []string{"/slow", "/fast", "/other"} {
+		ctx := trace.NewServerTransportContext(context.Background(),
+			trace.NewTransport("", op, op, httptest.NewRequest("GET", op, nil), nil))
+		if _, err := h(ctx, nil); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if want := []bool{false, true, false}; deadlines[0] != want[0] || deadlines[1] != want[1] || deadlines[2] != want[2] {
+		t.Fatalf("deadlines = %v, want %v", deadlines, want)
+	}
+}
+
+func TestTimeoutPassThroughError(t *testing.T) {
+	want := errors.New("failed")
+	h := Timeout(WithTimeout(time.Second))(func(ctx context.Context, req interface{}) (interface{}, error) {
+		return nil, want
+	})
+	if _, err := h(context.Background(), nil); err != want {
+		t.Fatalf("err = %v, want %v", err, want)
+	}
+}
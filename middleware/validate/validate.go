@@ -0,0 +1,70 @@
+package validate
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/songzhibin97/gkit/errors"
+	"github.com/songzhibin97/gkit/middleware"
+	"github.com/songzhibin97/gkit/options"
+	"github.com/songzhibin97/gkit/tools/bind"
+)
+
+// package validate: 请求参数校验中间件
+
+// Reason 校验失败时错误的 reason
+const Reason = "VALIDATOR"
+
+// validator 自带校验方法的请求
+type validator interface {
+	Validate() error
+}
+
+// transport 判断 req 是否为传输层对象, 此类请求不做结构体校验
+func transport(req interface{}) bool {
+	switch req.(type) {
+	case *http.Request, http.Request:
+		return true
+	}
+	return false
+}
+
+type config struct {
+	// validator: 结构体校验器
+	validator bind.StructValidator
+}
+
+// WithValidator 设置结构体校验器, 默认 bind.Validator
+func WithValidator(v bind.StructValidator) options.Option {
+	return func(o interface{}) {
+		o.(*config).validator = v
+	}
+}
+
+// Validator 参数校验中间件
+// 请求实现 Validate() error 时先调用该方法, 再使用 StructValidator 校验结构体 tag
+// *http.Request 等传输层对象不做结构体校验
+// 校验失败返回 errors.BadRequest
+func Validator(opts ...options.Option) middleware.MiddleWare {
+	conf := &config{
+		validator: bind.Validator,
+	}
+	for _, opt := range opts {
+		opt(conf)
+	}
+	return func(handler middleware.Endpoint) middleware.Endpoint {
+		return func(ctx context.Context, req interface{}) (interface{}, error) {
+			if v, ok := req.(validator); ok {
+				if err := v.Validate(); err != nil {
+					return nil, errors.BadRequest(Reason, err.Error())
+				}
+			}
+			if conf.validator != nil && !transport(req) {
+				if err := conf.validator.ValidateStruct(req); err != nil {
+					return nil, errors.BadRequest(Reason, err.Error())
+				}
+			}
+			return handler(ctx, req)
+		}
+	}
+}
@@ -0,0 +1,83 @@
+package validate
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	gerrors "github.com/songzhibin97/gkit/errors"
+)
+
+type tagRequest struct {
+	Name string `binding:"required"`
+}
+
+// rejectValidator 拒绝所有请求, 用于确认跳过结构体校验
+type rejectValidator struct{}
+
+func (rejectValidator) ValidateStruct(interface{}) error {
+	return errors.New("rejected")
+}
+
+func (rejectValidator) Engine() interface{} {
+	return nil
+}
+
+type methodRequest struct {
+	err error
+}
+
+func (r methodRequest) Validate() error {
+	return r.err
+}
+
+func TestValidator(t *testing.T) {
+	tests := []struct {
+		name    string
+		req     interface{}
+		wantErr bool
+	}{
+		{name: "tag ok", req: &tagRequest{Name: "gkit"}},
+		{name: "tag missing", req: &tagRequest{}, wantErr: true},
+		{name: "method ok", req: methodRequest{}},
+		{name: "method failed", req: methodRequest{err: errors.New("invalid")}, wantErr: true},
+		{name: "not struct", req: "raw"},
+		{name: "http request", req: httptest.NewRequest(http.MethodGet, "/", nil)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			called := false
+			h := Validator()(func(ctx context.Context, req interface{}) (interface{}, error) {
+				called = true
+				return req, nil
+			})
+			_, err := h(context.Background(), tt.req)
+			if tt.wantErr {
+				if !gerrors.IsBadRequest(err) || gerrors.Reason(err) != Reason {
+					t.Fatalf("err = %v, want BadRequest %s", err, Reason)
+				}
+				if called {
+					t.Fatal("handler called for invalid request")
+				}
+				return
+			}
+			if err != nil || !called {
+				t.Fatalf("err = %v, called = %v, want nil and true", err, called)
+			}
+		})
+	}
+}
+
+func TestValidatorSkipTransport(t *testing.T) {
+	h := Validator(WithValidator(rejectValidator{}))(func(ctx context.Context, req interface{}) (interface{}, error) {
+		return req, nil
+	})
+	if _, err := h(context.Background(), httptest.NewRequest(http.MethodGet, "/", nil)); err != nil {
+		t.Fatalf("err = %v, want nil for *http.Request", err)
+	}
+	if _, err := h(context.Background(), &tagRequest{Name: "gkit"}); !gerrors.IsBadRequest(err) {
+		t.Fatalf("err = %v, want BadRequest", err)
+	}
+}
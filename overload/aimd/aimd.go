@@ -0,0 +1,177 @@
+package aimd
+
+import (
+	"sync"
+	"time"
+
+	"github.com/songzhibin97/gkit/container/group"
+	"github.com/songzhibin97/gkit/options"
+	"github.com/songzhibin97/gkit/overload"
+	"github.com/songzhibin97/gkit/overload/internal/limit"
+)
+
+// package aimd: 加性增/乘性减 (AIMD) 自适应并发限制
+// 请求被丢弃或耗时超过 timeout 时 limit *= backoffRatio
+// 否则在途请求达到上限一半时 limit += 1
+
+// config: aimd 配置
+type config struct {
+	initialLimit int64
+	minLimit     int64
+	maxLimit     int64
+	backoffRatio float64
+	timeout      time.Duration
+}
+
+// Stat aimd 指标信息, MinRt 为观测到的最小延迟
+type Stat = limit.Stat
+
+// AIMD 实现 AIMD 算法的限制器
+type AIMD struct {
+	*limit.Limiter
+	algo *algorithm
+}
+
+// Group 表示 AIMD 限制器的类，并形成其中的命名空间
+type Group struct {
+	group group.LazyLoadGroup
+}
+
+// algorithm AIMD 算法
+type algorithm struct {
+	sync.Mutex
+	conf *config
+
+	// limit: 当前并发上限
+	limit float64
+
+	// minRtt: 观测到的最小延迟
+	minRtt time.Duration
+}
+
+// Update 上报样本
+func (a *algorithm) Update(sample limit.Sample) {
+	a.Lock()
+	defer a.Unlock()
+
+	if sample.Rtt > 0 && (a.minRtt == 0 || sample.Rtt < a.minRtt) {
+		a.minRtt = sample.Rtt
+	}
+	switch {
+	case sample.Dropped || sample.Rtt > a.conf.timeout:
+		a.limit *= a.conf.backoffRatio
+	case float64(sample.InFlight)*2 >= a.limit:
+		a.limit++
+	default:
+		return
+	}
+	a.limit = limit.Clamp(a.limit, float64(a.conf.minLimit), float64(a.conf.maxLimit))
+}
+
+// Limit 当前并发上限
+func (a *algorithm) Limit() int64 {
+	a.Lock()
+	defer a.Unlock()
+	return int64(a.limit)
+}
+
+// Rtt 观测到的最小延迟
+func (a *algorithm) Rtt() time.Duration {
+	a.Lock()
+	defer a.Unlock()
+	return a.minRtt
+}
+
+// defaultConf 默认配置
+func defaultConf() *config {
+	return &config{
+		initialLimit: 20,
+		minLimit:     20,
+		maxLimit:     200,
+		backoffRatio: 0.9,
+		timeout:      time.Second * 5,
+	}
+}
+
+// Option
+
+// SetInitialLimit 初始并发上限
+func SetInitialLimit(initialLimit int64) options.Option {
+	return func(c interface{}) {
+		c.(*config).initialLimit = initialLimit
+	}
+}
+
+// SetMinLimit 最小并发上限
+func SetMinLimit(minLimit int64) options.Option {
+	return func(c interface{}) {
+		c.(*config).minLimit = minLimit
+	}
+}
+
+// SetMaxLimit 最大并发上限
+func SetMaxLimit(maxLimit int64) options.Option {
+	return func(c interface{}) {
+		c.(*config).maxLimit = maxLimit
+	}
+}
+
+// SetBackoffRatio 乘性减系数, 取值 [0.5, 1)
+func SetBackoffRatio(backoffRatio float64) options.Option {
+	return func(c interface{}) {
+		c.(*config).backoffRatio = backoffRatio
+	}
+}
+
+// SetTimeout 耗时超过 timeout 的请求视为丢弃
+func SetTimeout(timeout time.Duration) options.Option {
+	return func(c interface{}) {
+		c.(*config).timeout = timeout
+	}
+}
+
+// newLimiter 实例化限制器
+func newLimiter(options ...options.Option) overload.Limiter {
+	conf := defaultConf()
+	for _, opt := range options {
+		opt(conf)
+	}
+	// 非法配置回退到默认值, 与 bbr 保持一致不 panic
+	def := defaultConf()
+	if conf.minLimit <= 0 {
+		conf.minLimit = 1
+	}
+	if conf.maxLimit < conf.minLimit {
+		conf.maxLimit = conf.minLimit
+	}
+	if conf.backoffRatio < 0.5 || conf.backoffRatio >= 1 {
+		conf.backoffRatio = def.backoffRatio
+	}
+	if conf.timeout <= 0 {
+		conf.timeout = def.timeout
+	}
+	algo := &algorithm{
+		conf:  conf,
+		limit: limit.Clamp(float64(conf.initialLimit), float64(conf.minLimit), float64(conf.maxLimit)),
+	}
+	return &AIMD{
+		Limiter: limit.NewLimiter(algo, LimitExceed),
+		algo:    algo,
+	}
+}
+
+// NewGroup 实例化限制器容器
+func NewGroup(options ...options.Option) *Group {
+	_group := group.NewGroup(func() interface{} {
+		return newLimiter(options...)
+	})
+	return &Group{
+		group: _group,
+	}
+}
+
+// Get 通过指定的键获取一个限制器，如果不存在限制器，则重新创建一个限制器。
+func (g *Group) Get(key string) overload.Limiter {
+	limiter := g.group.Get(key)
+	return limiter.(overload.Limiter)
+}
@@ -0,0 +1,43 @@
+package aimd
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/songzhibin97/gkit/overload"
+	"github.com/songzhibin97/gkit/overload/internal/limit"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAIMD(t *testing.T) {
+	a := newLimiter(SetInitialLimit(10), SetMinLimit(1), SetMaxLimit(12), SetTimeout(time.Second)).(*AIMD)
+
+	// 应用流量不足时不增长
+	a.algo.Update(limit.Sample{Rtt: time.Millisecond, InFlight: 1})
+	assert.Equal(t, int64(10), a.Stat().MaxInFlight)
+	assert.Equal(t, int64(1), a.Stat().MinRt)
+
+	for i := 0; i < 5; i++ {
+		a.algo.Update(limit.Sample{Rtt: time.Millisecond, InFlight: 10})
+	}
+	assert.Equal(t, int64(12), a.Stat().MaxInFlight)
+
+	a.algo.Update(limit.Sample{Rtt: time.Millisecond, InFlight: 10, Dropped: true})
+	assert.Equal(t, int64(10), a.Stat().MaxInFlight)
+
+	a.algo.Update(limit.Sample{Rtt: time.Second * 2, InFlight: 10})
+	assert.Equal(t, int64(9), a.Stat().MaxInFlight)
+}
+
+func TestNewGroup(t *testing.T) {
+	group := NewGroup(SetInitialLimit(1), SetMinLimit(1))
+	limiter := group.Get("key")
+	assert.Equal(t, limiter, group.Get("key"))
+	done, err := limiter.Allow(context.TODO())
+	assert.NoError(t, err)
+	_, err = limiter.Allow(context.TODO())
+	assert.Equal(t, LimitExceed, err)
+	done(overload.DoneInfo{Op: overload.Drop})
+	assert.Equal(t, int64(1), limiter.(*AIMD).Stat().MaxInFlight)
+}
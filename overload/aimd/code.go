@@ -0,0 +1,5 @@
+package aimd
+
+import "errors"
+
+var LimitExceed = errors.New("509:过载保护")
@@ -0,0 +1,5 @@
+package gradient
+
+import "errors"
+
+var LimitExceed = errors.New("509:过载保护")
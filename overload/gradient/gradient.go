@@ -0,0 +1,217 @@
+package gradient
+
+import (
+	"math"
+	"sync"
+	"time"
+
+	"github.com/songzhibin97/gkit/container/group"
+	"github.com/songzhibin97/gkit/options"
+	"github.com/songzhibin97/gkit/overload"
+	"github.com/songzhibin97/gkit/overload/internal/limit"
+)
+
+// package gradient: Gradient2 自适应并发限制
+// 比较短期延迟与长期延迟的梯度调整并发上限, 不依赖 cpu 采样
+// newLimit = limit * gradient + sqrt(limit)
+// gradient = max(0.5, min(1.0, tolerance * longRtt / shortRtt))
+
+// warmupWindow: 长期延迟在前 warmupWindow 个样本内使用算术平均
+const warmupWindow = 10
+
+// config: gradient 配置
+type config struct {
+	initialLimit int64
+	minLimit     int64
+	maxLimit     int64
+	smoothing    float64
+	rttTolerance float64
+	longWindow   int
+}
+
+// Stat gradient 指标信息, MinRt 为长期延迟
+type Stat = limit.Stat
+
+// Gradient 实现 Gradient2 算法的限制器
+type Gradient struct {
+	*limit.Limiter
+	algo *algorithm
+}
+
+// Group 表示 Gradient 限制器的类，并形成其中的命名空间
+type Group struct {
+	group group.LazyLoadGroup
+}
+
+// algorithm Gradient2 算法
+type algorithm struct {
+	sync.Mutex
+	conf *config
+
+	// estimatedLimit: 当前估算的并发上限
+	estimatedLimit float64
+
+	// longRtt: 长期延迟指数平均, 单位纳秒
+	longRtt float64
+
+	// count: 已累计的样本数, 用于预热
+	count int
+}
+
+// Update 上报样本
+func (a *algorithm) Update(sample limit.Sample) {
+	if sample.Rtt <= 0 {
+		return
+	}
+	a.Lock()
+	defer a.Unlock()
+
+	shortRtt := float64(sample.Rtt)
+	longRtt := a.addLongRtt(shortRtt)
+
+	// 长期延迟明显大于短期延迟时说明负载已下降, 加速长期延迟回落
+	if longRtt/shortRtt > 2 {
+		a.longRtt *= 0.95
+	}
+
+	// 在途请求不足上限一半时不调整, 避免应用自身流量不足导致上限无限增长
+	if float64(sample.InFlight) < a.estimatedLimit/2 {
+		return
+	}
+
+	gradient := limit.Clamp(a.conf.rttTolerance*longRtt/shortRtt, 0.5, 1.0)
+	newLimit := a.estimatedLimit*gradient + math.Sqrt(a.estimatedLimit)
+	newLimit = a.estimatedLimit*(1-a.conf.smoothing) + newLimit*a.conf.smoothing
+	a.estimatedLimit = limit.Clamp(newLimit, float64(a.conf.minLimit), float64(a.conf.maxLimit))
+}
+
+// addLongRtt 累加长期延迟, 预热期内使用算术平均, 之后使用指数平均
+func (a *algorithm) addLongRtt(rtt float64) float64 {
+	if a.count < warmupWindow {
+		a.count++
+		a.longRtt += (rtt - a.longRtt) / float64(a.count)
+		return a.longRtt
+	}
+	factor := 2.0 / float64(a.conf.longWindow+1)
+	a.longRtt = a.longRtt*(1-factor) + rtt*factor
+	return a.longRtt
+}
+
+// Limit 当前并发上限
+func (a *algorithm) Limit() int64 {
+	a.Lock()
+	defer a.Unlock()
+	return int64(a.estimatedLimit)
+}
+
+// Rtt 长期延迟
+func (a *algorithm) Rtt() time.Duration {
+	a.Lock()
+	defer a.Unlock()
+	return time.Duration(a.longRtt)
+}
+
+// defaultConf 默认配置
+func defaultConf() *config {
+	return &config{
+		initialLimit: 20,
+		minLimit:     20,
+		maxLimit:     200,
+		smoothing:    0.2,
+		rttTolerance: 1.5,
+		longWindow:   600,
+	}
+}
+
+// Option
+
+// SetInitialLimit 初始并发上限
+func SetInitialLimit(initialLimit int64) options.Option {
+	return func(c interface{}) {
+		c.(*config).initialLimit = initialLimit
+	}
+}
+
+// SetMinLimit 最小并发上限
+func SetMinLimit(minLimit int64) options.Option {
+	return func(c interface{}) {
+		c.(*config).minLimit = minLimit
+	}
+}
+
+// SetMaxLimit 最大并发上限
+func SetMaxLimit(maxLimit int64) options.Option {
+	return func(c interface{}) {
+		c.(*config).maxLimit = maxLimit
+	}
+}
+
+// SetSmoothing 新旧上限的平滑系数, 取值 (0, 1]
+func SetSmoothing(smoothing float64) options.Option {
+	return func(c interface{}) {
+		c.(*config).smoothing = smoothing
+	}
+}
+
+// SetRttTolerance 允许短期延迟超过长期延迟的倍数, 不小于 1
+func SetRttTolerance(rttTolerance float64) options.Option {
+	return func(c interface{}) {
+		c.(*config).rttTolerance = rttTolerance
+	}
+}
+
+// SetLongWindow 长期延迟指数平均的窗口样本数
+func SetLongWindow(longWindow int) options.Option {
+	return func(c interface{}) {
+		c.(*config).longWindow = longWindow
+	}
+}
+
+// newLimiter 实例化限制器
+func newLimiter(options ...options.Option) overload.Limiter {
+	conf := defaultConf()
+	for _, opt := range options {
+		opt(conf)
+	}
+	// 非法配置回退到默认值, 与 bbr 保持一致不 panic
+	def := defaultConf()
+	if conf.minLimit <= 0 {
+		conf.minLimit = 1
+	}
+	if conf.maxLimit < conf.minLimit {
+		conf.maxLimit = conf.minLimit
+	}
+	if conf.smoothing <= 0 || conf.smoothing > 1 {
+		conf.smoothing = def.smoothing
+	}
+	if conf.rttTolerance < 1 {
+		conf.rttTolerance = def.rttTolerance
+	}
+	if conf.longWindow <= 0 {
+		conf.longWindow = def.longWindow
+	}
+	algo := &algorithm{
+		conf:           conf,
+		estimatedLimit: limit.Clamp(float64(conf.initialLimit), float64(conf.minLimit), float64(conf.maxLimit)),
+	}
+	return &Gradient{
+		Limiter: limit.NewLimiter(algo, LimitExceed),
+		algo:    algo,
+	}
+}
+
+// NewGroup 实例化限制器容器
+func NewGroup(options ...options.Option) *Group {
+	_group := group.NewGroup(func() interface{} {
+		return newLimiter(options...)
+	})
+	return &Group{
+		group: _group,
+	}
+}
+
+// Get 通过指定的键获取一个限制器，如果不存在限制器，则重新创建一个限制器。
+func (g *Group) Get(key string) overload.Limiter {
+	limiter := g.group.Get(key)
+	return limiter.(overload.Limiter)
+}
@@ -0,0 +1,50 @@
+package gradient
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/songzhibin97/gkit/overload"
+	"github.com/songzhibin97/gkit/overload/internal/limit"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGradientIncrease(t *testing.T) {
+	g := newLimiter(SetInitialLimit(20), SetMinLimit(1), SetMaxLimit(100)).(*Gradient)
+	for i := 0; i < 200; i++ {
+		g.algo.Update(limit.Sample{Rtt: time.Millisecond * 10, InFlight: g.Stat().MaxInFlight})
+	}
+	assert.Equal(t, int64(100), g.Stat().MaxInFlight)
+	assert.Equal(t, int64(10), g.Stat().MinRt)
+}
+
+func TestGradientDecrease(t *testing.T) {
+	g := newLimiter(SetInitialLimit(100), SetMinLimit(1), SetMaxLimit(100)).(*Gradient)
+	for i := 0; i < warmupWindow; i++ {
+		g.algo.Update(limit.Sample{Rtt: time.Millisecond * 10, InFlight: 100})
+	}
+	for i := 0; i < 50; i++ {
+		g.algo.Update(limit.Sample{Rtt: time.Millisecond * 100, InFlight: g.Stat().MaxInFlight})
+	}
+	assert.Less(t, g.Stat().MaxInFlight, int64(50))
+}
+
+func TestGradientAppLimited(t *testing.T) {
+	g := newLimiter(SetInitialLimit(50), SetMinLimit(1)).(*Gradient)
+	for i := 0; i < 50; i++ {
+		g.algo.Update(limit.Sample{Rtt: time.Millisecond * 10, InFlight: 1})
+	}
+	assert.Equal(t, int64(50), g.Stat().MaxInFlight)
+}
+
+func TestNewGroup(t *testing.T) {
+	group := NewGroup(SetInitialLimit(1), SetMinLimit(1))
+	limiter := group.Get("key")
+	assert.Equal(t, limiter, group.Get("key"))
+	done, err := limiter.Allow(context.TODO())
+	assert.NoError(t, err)
+	_, err = limiter.Allow(context.TODO())
+	assert.Equal(t, LimitExceed, err)
+	done(overload.DoneInfo{Op: overload.Success})
+}
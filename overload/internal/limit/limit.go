@@ -0,0 +1,109 @@
+package limit
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/songzhibin97/gkit/overload"
+)
+
+// package limit: 基于延迟样本的自适应并发限制公共实现
+
+// Sample 单次请求的样本
+type Sample struct {
+	// Rtt 请求耗时
+	Rtt time.Duration
+
+	// InFlight 请求放行时的在途请求数(包含自身)
+	InFlight int64
+
+	// Dropped 请求是否被下游丢弃/失败
+	Dropped bool
+}
+
+// Algorithm 根据样本调整并发上限的算法
+// 实现需要保证并发安全
+type Algorithm interface {
+	// Update 上报一次样本
+	Update(sample Sample)
+
+	// Limit 当前允许的最大在途请求数
+	Limit() int64
+
+	// Rtt 算法当前参考的无负载延迟
+	Rtt() time.Duration
+}
+
+// Stat 限制器指标信息
+// 字段与 bbr.Stat 一致, 可以直接转换为 bbr.Stat, MinRt 单位为毫秒
+// 自适应限制器不采样 cpu 与通过量, Cpu、MaxPass 恒为 0
+type Stat struct {
+	Cpu         int64
+	InFlight    int64
+	MaxInFlight int64
+	MinRt       int64
+	MaxPass     int64
+}
+
+// Limiter 根据 Algorithm 给出的上限限制在途请求数
+type Limiter struct {
+	algo     Algorithm
+	err      error
+	inFlight int64
+}
+
+// Stat 状态信息
+func (l *Limiter) Stat() Stat {
+	return Stat{
+		InFlight:    atomic.LoadInt64(&l.inFlight),
+		MaxInFlight: l.algo.Limit(),
+		MinRt:       int64(l.algo.Rtt() / time.Millisecond),
+	}
+}
+
+// Allow 检查所有入站流量
+// 在途请求数达到算法给出的上限时返回 err, overload.AllowOption 目前没有可用选项, opts 被忽略
+func (l *Limiter) Allow(ctx context.Context, opts ...overload.AllowOption) (func(info overload.DoneInfo), error) {
+	inFlight := atomic.AddInt64(&l.inFlight, 1)
+	if inFlight > l.algo.Limit() {
+		atomic.AddInt64(&l.inFlight, -1)
+		return nil, l.err
+	}
+	start := time.Now()
+	var once sync.Once
+	return func(do overload.DoneInfo) {
+		// 与 bbr 一致: 重复调用为空操作, Ignore 不参与采样
+		once.Do(func() {
+			atomic.AddInt64(&l.inFlight, -1)
+			if do.Op == overload.Ignore {
+				return
+			}
+			l.algo.Update(Sample{
+				Rtt:      time.Since(start),
+				InFlight: inFlight,
+				Dropped:  do.Op == overload.Drop,
+			})
+		})
+	}, nil
+}
+
+// NewLimiter 实例化限制器, 过载时 Allow 返回 err
+func NewLimiter(algo Algorithm, err error) *Limiter {
+	return &Limiter{
+		algo: algo,
+		err:  err,
+	}
+}
+
+// Clamp 将 v 限制在 [min, max] 区间
+func Clamp(v, min, max float64) float64 {
+	if v < min {
+		return min
+	}
+	if v > max {
+		return max
+	}
+	return v
+}
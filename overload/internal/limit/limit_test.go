@@ -0,0 +1,68 @@
+package limit
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/songzhibin97/gkit/overload"
+	"github.com/stretchr/testify/assert"
+)
+
+var errLimit = errors.New("limit")
+
+type fixedAlgorithm struct {
+	sync.Mutex
+	limit   int64
+	samples []Sample
+}
+
+func (f *fixedAlgorithm) Update(sample Sample) {
+	f.Lock()
+	defer f.Unlock()
+	f.samples = append(f.samples, sample)
+}
+
+func (f *fixedAlgorithm) Limit() int64 {
+	return f.limit
+}
+
+func (f *fixedAlgorithm) Rtt() time.Duration {
+	return time.Millisecond * 5
+}
+
+func TestLimiterAllow(t *testing.T) {
+	algo := &fixedAlgorithm{limit: 2}
+	l := NewLimiter(algo, errLimit)
+
+	d1, err := l.Allow(context.TODO())
+	assert.NoError(t, err)
+	d2, err := l.Allow(context.TODO())
+	assert.NoError(t, err)
+	_, err = l.Allow(context.TODO())
+	assert.Equal(t, errLimit, err)
+	assert.Equal(t, Stat{InFlight: 2, MaxInFlight: 2, MinRt: 5}, l.Stat())
+
+	d1(overload.DoneInfo{Op: overload.Success})
+	d1(overload.DoneInfo{Op: overload.Success})
+	d2(overload.DoneInfo{Op: overload.Drop})
+	assert.Equal(t, int64(0), l.Stat().InFlight)
+
+	assert.Len(t, algo.samples, 2)
+	assert.Equal(t, int64(1), algo.samples[0].InFlight)
+	assert.False(t, algo.samples[0].Dropped)
+	assert.Equal(t, int64(2), algo.samples[1].InFlight)
+	assert.True(t, algo.samples[1].Dropped)
+}
+
+func TestLimiterIgnore(t *testing.T) {
+	algo := &fixedAlgorithm{limit: 1}
+	l := NewLimiter(algo, errLimit)
+	done, err := l.Allow(context.TODO())
+	assert.NoError(t, err)
+	done(overload.DoneInfo{Op: overload.Ignore})
+	assert.Len(t, algo.samples, 0)
+	assert.Equal(t, int64(0), l.Stat().InFlight)
+}
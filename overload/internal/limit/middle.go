@@ -0,0 +1,77 @@
+package limit
+
+import (
+	"context"
+
+	"github.com/songzhibin97/gkit/middleware"
+	"github.com/songzhibin97/gkit/overload"
+)
+
+const (
+	// legacyLimitKey 兼容 bbr.LimitKey 的字符串 context key
+	legacyLimitKey = "LimitKey"
+
+	// legacyLimitOp 兼容 bbr.LimitOp 的字符串 context key
+	legacyLimitOp = "LimitLoad"
+)
+
+type contextKey uint8
+
+const (
+	limitKeyContextKey contextKey = iota
+	limitOpContextKey
+)
+
+// WithLimitKey 返回选择指定 key 限制器的子 context
+func WithLimitKey(ctx context.Context, key string) context.Context {
+	return context.WithValue(ctx, limitKeyContextKey, key)
+}
+
+// WithLimitOp 返回 endpoint 正常返回时上报 op 的子 context
+func WithLimitOp(ctx context.Context, op overload.Op) context.Context {
+	return context.WithValue(ctx, limitOpContextKey, op)
+}
+
+// Middleware 限制器中间件, 通过 get 按 WithLimitKey 选择的 key 获取限制器
+// bbr、gradient、vegas、aimd 共用, 切换限制器时 context 的设置方式不变
+func Middleware(get func(key string) overload.Limiter) middleware.MiddleWare {
+	return func(next middleware.Endpoint) middleware.Endpoint {
+		return func(ctx context.Context, i interface{}) (resp interface{}, err error) {
+			defaultKey := "default"
+			defaultOp := overload.Success
+			if v, ok := ctx.Value(limitKeyContextKey).(string); ok {
+				defaultKey = v
+			} else if v, ok := ctx.Value(legacyLimitKey).(string); ok {
+				defaultKey = v
+			}
+			if v, ok := ctx.Value(limitOpContextKey).(overload.Op); ok {
+				defaultOp = v
+			} else if v, ok := ctx.Value(legacyLimitOp).(overload.Op); ok {
+				defaultOp = v
+			}
+			limiter := get(defaultKey)
+			f, allowErr := limiter.Allow(ctx)
+			if allowErr != nil {
+				return nil, allowErr
+			}
+			completed := false
+			// Do not use recover() to detect this state: on Go 1.20 a
+			// panic(nil) is indistinguishable from no panic by its recovered
+			// value. A normal-completion flag lets every panic propagate while
+			// the defer still releases the in-flight slot as Drop.
+			defer func() {
+				if !completed {
+					f(overload.DoneInfo{Op: overload.Drop})
+					return
+				}
+				// A normally-returning handler completed real work even when it
+				// reports a business error. Preserve the configured operation so
+				// only fail-fast or an explicit Drop skips success stats.
+				f(overload.DoneInfo{Op: defaultOp})
+			}()
+			resp, err = next(ctx, i)
+			completed = true
+			return resp, err
+		}
+	}
+}
@@ -0,0 +1,40 @@
+package limit
+
+import (
+	"context"
+	"testing"
+
+	"github.com/songzhibin97/gkit/overload"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMiddleware(t *testing.T) {
+	limiters := map[string]*fixedAlgorithm{}
+	get := func(key string) overload.Limiter {
+		algo, ok := limiters[key]
+		if !ok {
+			algo = &fixedAlgorithm{limit: 1}
+			limiters[key] = algo
+		}
+		return NewLimiter(algo, errLimit)
+	}
+	endpoint := Middleware(get)(func(context.Context, interface{}) (interface{}, error) {
+		return "ok", nil
+	})
+
+	resp, err := endpoint(context.TODO(), nil)
+	assert.NoError(t, err)
+	assert.Equal(t, "ok", resp)
+	assert.Len(t, limiters["default"].samples, 1)
+
+	ctx := WithLimitOp(WithLimitKey(context.TODO(), "key"), overload.Drop)
+	_, err = endpoint(ctx, nil)
+	assert.NoError(t, err)
+	assert.True(t, limiters["key"].samples[0].Dropped)
+
+	// 兼容 bbr 的字符串 key
+	ctx = context.WithValue(context.TODO(), legacyLimitKey, "legacy") //nolint:staticcheck
+	_, err = endpoint(ctx, nil)
+	assert.NoError(t, err)
+	assert.Len(t, limiters["legacy"].samples, 1)
+}
@@ -0,0 +1,25 @@
+package vegas
+
+import (
+	"context"
+
+	"github.com/songzhibin97/gkit/middleware"
+	"github.com/songzhibin97/gkit/options"
+	"github.com/songzhibin97/gkit/overload"
+	"github.com/songzhibin97/gkit/overload/internal/limit"
+)
+
+// WithLimitKey 返回选择指定 key 限制器的子 context, 与 bbr.WithLimitKey 等价
+func WithLimitKey(ctx context.Context, key string) context.Context {
+	return limit.WithLimitKey(ctx, key)
+}
+
+// WithLimitOp 返回 endpoint 正常返回时上报 op 的子 context, 与 bbr.WithLimitOp 等价
+func WithLimitOp(ctx context.Context, op overload.Op) context.Context {
+	return limit.WithLimitOp(ctx, op)
+}
+
+// NewLimiter Vegas 限制器中间件, 用法与 bbr.NewLimiter 一致, 可以直接替换
+func NewLimiter(options ...options.Option) middleware.MiddleWare {
+	return limit.Middleware(NewGroup(options...).Get)
+}
@@ -0,0 +1,221 @@
+package vegas
+
+import (
+	"math"
+	"math/rand"
+	"sync"
+	"time"
+
+	"github.com/songzhibin97/gkit/container/group"
+	"github.com/songzhibin97/gkit/options"
+	"github.com/songzhibin97/gkit/overload"
+	"github.com/songzhibin97/gkit/overload/internal/limit"
+)
+
+// package vegas: TCP Vegas 自适应并发限制
+// 以观测到的最小延迟作为无负载延迟 rttNoLoad, 估算排队长度
+// queue = limit * (1 - rttNoLoad / rtt)
+// queue <= log10(limit):         limit += 6 * log10(limit)
+// queue <  3 * log10(limit):     limit += log10(limit)
+// queue >  6 * log10(limit):     limit -= log10(limit)
+
+// config: vegas 配置
+type config struct {
+	initialLimit    int64
+	maxLimit        int64
+	smoothing       float64
+	probeMultiplier int
+}
+
+// Stat vegas 指标信息, MinRt 为无负载延迟
+type Stat = limit.Stat
+
+// Vegas 实现 Vegas 算法的限制器
+type Vegas struct {
+	*limit.Limiter
+	algo *algorithm
+}
+
+// Group 表示 Vegas 限制器的类，并形成其中的命名空间
+type Group struct {
+	group group.LazyLoadGroup
+}
+
+// algorithm Vegas 算法
+type algorithm struct {
+	sync.Mutex
+	conf *config
+
+	// estimatedLimit: 当前估算的并发上限
+	estimatedLimit float64
+
+	// rttNoLoad: 无负载延迟
+	rttNoLoad time.Duration
+
+	// probeCount: 距离上次探测的样本数
+	probeCount int
+
+	// probeJitter: 探测周期抖动 [0.5, 1)
+	probeJitter float64
+}
+
+// log10 max(1, log10(limit))
+func log10(limit float64) float64 {
+	return math.Max(1, math.Log10(limit))
+}
+
+// resetProbeJitter 重置探测抖动, 避免多个实例同时探测
+func (a *algorithm) resetProbeJitter() {
+	a.probeJitter = 0.5 + rand.Float64()/2
+}
+
+// Update 上报样本
+func (a *algorithm) Update(sample limit.Sample) {
+	if sample.Rtt <= 0 {
+		return
+	}
+	a.Lock()
+	defer a.Unlock()
+
+	// 被丢弃的请求延迟不代表真实负载, 只用于降低上限, 不参与无负载延迟的估算
+	if sample.Dropped {
+		a.setLimit(a.estimatedLimit - log10(a.estimatedLimit))
+		return
+	}
+
+	a.probeCount++
+	// 周期性探测: 排队长期存在时 rttNoLoad 会被低估, 定期以当前延迟重置
+	if float64(a.probeCount) >= a.probeJitter*float64(a.conf.probeMultiplier)*a.estimatedLimit {
+		a.resetProbeJitter()
+		a.probeCount = 0
+		a.rttNoLoad = sample.Rtt
+		return
+	}
+	if a.rttNoLoad == 0 || sample.Rtt < a.rttNoLoad {
+		a.rttNoLoad = sample.Rtt
+		return
+	}
+
+	queueSize := math.Ceil(a.estimatedLimit * (1 - float64(a.rttNoLoad)/float64(sample.Rtt)))
+	step := log10(a.estimatedLimit)
+
+	var newLimit float64
+	switch {
+	case float64(sample.InFlight)*2 < a.estimatedLimit:
+		// 应用自身流量不足, 不调整
+		return
+	case queueSize <= step:
+		newLimit = a.estimatedLimit + 6*step
+	case queueSize < 3*step:
+		newLimit = a.estimatedLimit + step
+	case queueSize > 6*step:
+		newLimit = a.estimatedLimit - step
+	default:
+		return
+	}
+	a.setLimit(newLimit)
+}
+
+// setLimit 平滑更新并发上限
+func (a *algorithm) setLimit(newLimit float64) {
+	newLimit = limit.Clamp(newLimit, 1, float64(a.conf.maxLimit))
+	a.estimatedLimit = a.estimatedLimit*(1-a.conf.smoothing) + newLimit*a.conf.smoothing
+}
+
+// Limit 当前并发上限
+func (a *algorithm) Limit() int64 {
+	a.Lock()
+	defer a.Unlock()
+	return int64(a.estimatedLimit)
+}
+
+// Rtt 无负载延迟
+func (a *algorithm) Rtt() time.Duration {
+	a.Lock()
+	defer a.Unlock()
+	return a.rttNoLoad
+}
+
+// defaultConf 默认配置
+func defaultConf() *config {
+	return &config{
+		initialLimit:    20,
+		maxLimit:        1000,
+		smoothing:       1.0,
+		probeMultiplier: 30,
+	}
+}
+
+// Option
+
+// SetInitialLimit 初始并发上限
+func SetInitialLimit(initialLimit int64) options.Option {
+	return func(c interface{}) {
+		c.(*config).initialLimit = initialLimit
+	}
+}
+
+// SetMaxLimit 最大并发上限
+func SetMaxLimit(maxLimit int64) options.Option {
+	return func(c interface{}) {
+		c.(*config).maxLimit = maxLimit
+	}
+}
+
+// SetSmoothing 新旧上限的平滑系数, 取值 (0, 1]
+func SetSmoothing(smoothing float64) options.Option {
+	return func(c interface{}) {
+		c.(*config).smoothing = smoothing
+	}
+}
+
+// SetProbeMultiplier 探测周期, 约每 probeMultiplier * limit 个样本重置一次无负载延迟
+func SetProbeMultiplier(probeMultiplier int) options.Option {
+	return func(c interface{}) {
+		c.(*config).probeMultiplier = probeMultiplier
+	}
+}
+
+// newLimiter 实例化限制器
+func newLimiter(options ...options.Option) overload.Limiter {
+	conf := defaultConf()
+	for _, opt := range options {
+		opt(conf)
+	}
+	// 非法配置回退到默认值, 与 bbr 保持一致不 panic
+	def := defaultConf()
+	if conf.maxLimit <= 0 {
+		conf.maxLimit = def.maxLimit
+	}
+	if conf.smoothing <= 0 || conf.smoothing > 1 {
+		conf.smoothing = def.smoothing
+	}
+	if conf.probeMultiplier <= 0 {
+		conf.probeMultiplier = def.probeMultiplier
+	}
+	algo := &algorithm{
+		conf:           conf,
+		estimatedLimit: limit.Clamp(float64(conf.initialLimit), 1, float64(conf.maxLimit)),
+	}
+	algo.resetProbeJitter()
+	return &Vegas{
+		Limiter: limit.NewLimiter(algo, LimitExceed),
+		algo:    algo,
+	}
+}
+
+// NewGroup 实例化限制器容器
+func NewGroup(options ...options.Option) *Group {
+	_group := group.NewGroup(func() interface{} {
+		return newLimiter(options...)
+	})
+	return &Group{
+		group: _group,
+	}
+}
+
+// Get 通过指定的键获取一个限制器，如果不存在限制器，则重新创建一个限制器。
+func (g *Group) Get(key string) overload.Limiter {
+	limiter := g.group.Get(key)
+	return limiter.(overload.Limiter)
+}
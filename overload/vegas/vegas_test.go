@@ -0,0 +1,65 @@
+package vegas
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/songzhibin97/gkit/overload"
+	"github.com/songzhibin97/gkit/overload/internal/limit"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestVegasIncrease(t *testing.T) {
+	v := newLimiter(SetInitialLimit(10), SetMaxLimit(20), SetProbeMultiplier(1000)).(*Vegas)
+	v.algo.Update(limit.Sample{Rtt: time.Millisecond * 10, InFlight: 10})
+	assert.Equal(t, int64(10), v.Stat().MinRt)
+	v.algo.Update(limit.Sample{Rtt: time.Millisecond * 10, InFlight: 10})
+	assert.Equal(t, int64(16), v.Stat().MaxInFlight)
+	v.algo.Update(limit.Sample{Rtt: time.Millisecond * 10, InFlight: 16})
+	assert.Equal(t, int64(20), v.Stat().MaxInFlight)
+}
+
+func TestVegasDecrease(t *testing.T) {
+	v := newLimiter(SetInitialLimit(100), SetProbeMultiplier(1000)).(*Vegas)
+	v.algo.Update(limit.Sample{Rtt: time.Millisecond * 10, InFlight: 100})
+	v.algo.Update(limit.Sample{Rtt: time.Millisecond * 50, InFlight: 100})
+	assert.Equal(t, int64(98), v.Stat().MaxInFlight)
+	v.algo.Update(limit.Sample{Rtt: time.Millisecond, InFlight: 100, Dropped: true})
+	assert.Equal(t, int64(96), v.Stat().MaxInFlight)
+	// 被丢弃的样本不会降低无负载延迟
+	assert.Equal(t, int64(10), v.Stat().MinRt)
+}
+
+func TestVegasAppLimited(t *testing.T) {
+	v := newLimiter(SetInitialLimit(100), SetProbeMultiplier(1000)).(*Vegas)
+	v.algo.Update(limit.Sample{Rtt: time.Millisecond * 10, InFlight: 1})
+	v.algo.Update(limit.Sample{Rtt: time.Millisecond * 10, InFlight: 1})
+	assert.Equal(t, int64(100), v.Stat().MaxInFlight)
+}
+
+func TestNewGroup(t *testing.T) {
+	group := NewGroup(SetInitialLimit(1))
+	limiter := group.Get("key")
+	assert.Equal(t, limiter, group.Get("key"))
+	done, err := limiter.Allow(context.TODO())
+	assert.NoError(t, err)
+	_, err = limiter.Allow(context.TODO())
+	assert.Equal(t, LimitExceed, err)
+	done(overload.DoneInfo{Op: overload.Success})
+}
+
+func TestNewLimiter(t *testing.T) {
+	middle := NewLimiter(SetInitialLimit(1))
+	var inner error
+	endpoint := middle(func(ctx context.Context, i interface{}) (interface{}, error) {
+		// 同一 key 的限制器已被占用
+		_, inner = middle(func(context.Context, interface{}) (interface{}, error) {
+			return nil, nil
+		})(ctx, i)
+		return nil, nil
+	})
+	_, err := endpoint(WithLimitKey(context.TODO(), "key"), nil)
+	assert.NoError(t, err)
+	assert.Equal(t, LimitExceed, inner)
+}
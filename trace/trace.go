@@ -36,9 +36,7 @@ func NewTracer(kind trace.SpanKind, opts ...options.Option) *Tracer {
 	}
 
 	switch kind {
-	case trace.SpanKindClient:
-		return &Tracer{tracer: provider.Tracer("gkit"), kind: kind, opt: &cfg}
-	case trace.SpanKindServer:
+	case trace.SpanKindClient, trace.SpanKindServer, trace.SpanKindProducer, trace.SpanKindConsumer:
 		return &Tracer{tracer: provider.Tracer("gkit"), kind: kind, opt: &cfg}
 	default:
 		panic(fmt.Sprintf("unsupported span kind: %v", kind))
@@ -46,20 +44,26 @@ func NewTracer(kind trace.SpanKind, opts ...options.Option) *Tracer {
 }
 
 // Start 开始追踪
+// Server/Consumer 从 carrier 中提取上游链路, Client/Producer 将链路注入 carrier
 func (t *Tracer) Start(ctx context.Context, operation string, carrier propagation.TextMapCarrier) (context.Context, trace.Span) {
-	if t.kind == trace.SpanKindServer {
+	if t.kind == trace.SpanKindServer || t.kind == trace.SpanKindConsumer {
 		ctx = t.opt.propagator.Extract(ctx, carrier)
 	}
 	ctx, span := t.tracer.Start(ctx,
 		operation,
 		trace.WithSpanKind(t.kind),
 	)
-	if t.kind == trace.SpanKindClient {
+	if t.kind == trace.SpanKindClient || t.kind == trace.SpanKindProducer {
 		t.opt.propagator.Inject(ctx, carrier)
 	}
 	return ctx, span
 }
 
+// Inject 将 ctx 中的链路注入 carrier, 用于一个 span 对应多个 carrier 的场景
+func (t *Tracer) Inject(ctx context.Context, carrier propagation.TextMapCarrier) {
+	t.opt.propagator.Inject(ctx, carrier)
+}
+
 // End 完成追踪
 func (t *Tracer) End(ctx context.Context, span trace.Span, m interface{}, err error) {
 	if err != nil {
@@ -15,6 +15,23 @@ type Transport struct {
 	pathTemplate string
 }
 
+// NewTransport returns an HTTP transport, the request header is used as the
+// carrier for propagation and replyHeader collects the response header.
+func NewTransport(endpoint, operation, pathTemplate string, req *http.Request, replyHeader http.Header) *Transport {
+	var reqHeader http.Header
+	if req != nil {
+		reqHeader = req.Header
+	}
+	return &Transport{
+		endpoint:     endpoint,
+		operation:    operation,
+		reqHeader:    headerCarrier(reqHeader),
+		replyHeader:  headerCarrier(replyHeader),
+		request:      req,
+		pathTemplate: pathTemplate,
+	}
+}
+
 // Kind returns the transport kind.
 func (tr *Transport) Kind() Kind {
 	return KindHTTP
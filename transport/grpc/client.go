@@ -0,0 +1,68 @@
+package grpc
+
+import (
+	"context"
+
+	"github.com/songzhibin97/gkit/options"
+	"github.com/songzhibin97/gkit/trace"
+	"github.com/songzhibin97/gkit/transport"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+// newClientContext 构建客户端链路上下文
+func newClientContext(ctx context.Context, endpoint, method string) (context.Context, *Transport) {
+	tr := &Transport{
+		endpoint:    endpoint,
+		operation:   method,
+		reqHeader:   headerCarrier(metadata.MD{}),
+		replyHeader: headerCarrier(metadata.MD{}),
+	}
+	return trace.NewClientTransportContext(ctx, tr), tr
+}
+
+// outgoingContext 将中间件写入 RequestHeader 的内容合并到 outgoing metadata
+func outgoingContext(ctx context.Context, tr *Transport) context.Context {
+	if len(tr.reqHeader) == 0 {
+		return ctx
+	}
+	md, _ := metadata.FromOutgoingContext(ctx)
+	return metadata.NewOutgoingContext(ctx, metadata.Join(md, metadata.MD(tr.reqHeader)))
+}
+
+// UnaryClientInterceptor 执行中间件链的客户端一元拦截器
+// 中间件的 request 与 reply 为 gRPC 的请求与响应消息, 响应 header 写入 ResponseHeader
+func UnaryClientInterceptor(opts ...options.Option) grpc.UnaryClientInterceptor {
+	conf := newConfig(opts...)
+	chain := transport.Chain(conf.ms...)
+	return func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		ctx, tr := newClientContext(ctx, cc.Target(), method)
+		_, err := chain(func(ctx context.Context, req interface{}) (interface{}, error) {
+			var header metadata.MD
+			err := invoker(outgoingContext(ctx, tr), method, req, reply, cc, append(opts, grpc.Header(&header))...)
+			for k, v := range header {
+				tr.replyHeader[k] = v
+			}
+			return reply, err
+		})(ctx, req)
+		return err
+	}
+}
+
+// StreamClientInterceptor 执行中间件链的客户端流拦截器
+// 中间件的 request 为 *grpc.StreamDesc, reply 为 grpc.ClientStream
+func StreamClientInterceptor(opts ...options.Option) grpc.StreamClientInterceptor {
+	conf := newConfig(opts...)
+	chain := transport.Chain(conf.ms...)
+	return func(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, streamer grpc.Streamer, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+		ctx, tr := newClientContext(ctx, cc.Target(), method)
+		reply, err := chain(func(ctx context.Context, req interface{}) (interface{}, error) {
+			return streamer(outgoingContext(ctx, tr), desc, cc, method, opts...)
+		})(ctx, desc)
+		if err != nil {
+			return nil, err
+		}
+		cs, _ := reply.(grpc.ClientStream)
+		return cs, nil
+	}
+}
@@ -0,0 +1,125 @@
+package grpc
+
+import (
+	"context"
+	"net"
+	"testing"
+
+	"github.com/songzhibin97/gkit/middleware"
+	"github.com/songzhibin97/gkit/trace"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/health"
+	"google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+func TestInterceptors(t *testing.T) {
+	const method = "/grpc.health.v1.Health/Check"
+	var serverCalls, streamCalls int
+	server := func(next middleware.Endpoint) middleware.Endpoint {
+		return func(ctx context.Context, req interface{}) (interface{}, error) {
+			tr, ok := trace.FromServerTransportContext(ctx)
+			assert.True(t, ok)
+			assert.Equal(t, trace.KindGRPC, tr.Kind())
+			assert.Equal(t, "grpc://bufnet", tr.Endpoint())
+			if tr.Operation() == method {
+				serverCalls++
+				assert.Equal(t, "gkit", tr.RequestHeader().Get("x-token"))
+			} else {
+				streamCalls++
+			}
+			tr.ResponseHeader().Set("x-server", "gkit")
+			return next(ctx, req)
+		}
+	}
+
+	lis := bufconn.Listen(1 << 20)
+	srv := grpc.NewServer(
+		grpc.UnaryInterceptor(UnaryServerInterceptor(WithMiddleware(server), WithEndpoint("grpc://bufnet"))),
+		grpc.StreamInterceptor(StreamServerInterceptor(WithMiddleware(server), WithEndpoint("grpc://bufnet"))),
+	)
+	grpc_health_v1.RegisterHealthServer(srv, health.NewServer())
+	go func() { _ = srv.Serve(lis) }()
+	defer srv.Stop()
+
+	var replyHeader string
+	client := func(next middleware.Endpoint) middleware.Endpoint {
+		return func(ctx context.Context, req interface{}) (interface{}, error) {
+			tr, ok := trace.FromClientTransportContext(ctx)
+			assert.True(t, ok)
+			assert.Equal(t, "passthrough:///bufnet", tr.Endpoint())
+			tr.RequestHeader().Set("x-token", "gkit")
+			reply, err := next(ctx, req)
+			replyHeader = tr.ResponseHeader().Get("x-server")
+			return reply, err
+		}
+	}
+	conn, err := grpc.Dial("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, s string) (net.Conn, error) { return lis.DialContext(ctx) }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+		grpc.WithUnaryInterceptor(UnaryClientInterceptor(WithMiddleware(client))),
+		grpc.WithStreamInterceptor(StreamClientInterceptor(WithMiddleware(client))),
+	)
+	assert.NoError(t, err)
+	defer conn.Close()
+
+	hc := grpc_health_v1.NewHealthClient(conn)
+	var header metadata.MD
+	resp, err := hc.Check(context.Background(), &grpc_health_v1.HealthCheckRequest{}, grpc.Header(&header))
+	assert.NoError(t, err)
+	assert.Equal(t, grpc_health_v1.HealthCheckResponse_SERVING, resp.GetStatus())
+	assert.Equal(t, "gkit", replyHeader)
+	assert.Equal(t, []string{"gkit"}, header.Get("x-server"))
+	assert.Equal(t, 1, serverCalls)
+
+	stream, err := hc.Watch(context.Background(), &grpc_health_v1.HealthCheckRequest{})
+	assert.NoError(t, err)
+	watch, err := stream.Recv()
+	assert.NoError(t, err)
+	assert.Equal(t, grpc_health_v1.HealthCheckResponse_SERVING, watch.GetStatus())
+	assert.Equal(t, 1, streamCalls)
+}
+
+// sendHeaderHealth 在 handler 中主动发送 header
+type sendHeaderHealth struct {
+	grpc_health_v1.UnimplementedHealthServer
+}
+
+func (sendHeaderHealth) Check(ctx context.Context, _ *grpc_health_v1.HealthCheckRequest) (*grpc_health_v1.HealthCheckResponse, error) {
+	if err := grpc.SendHeader(ctx, metadata.Pairs("x-handler", "gkit")); err != nil {
+		return nil, err
+	}
+	return &grpc_health_v1.HealthCheckResponse{Status: grpc_health_v1.HealthCheckResponse_SERVING}, nil
+}
+
+func TestUnaryServerInterceptorHeaderSent(t *testing.T) {
+	server := func(next middleware.Endpoint) middleware.Endpoint {
+		return func(ctx context.Context, req interface{}) (interface{}, error) {
+			tr, _ := trace.FromServerTransportContext(ctx)
+			tr.ResponseHeader().Set("x-server", "gkit")
+			return next(ctx, req)
+		}
+	}
+	lis := bufconn.Listen(1 << 20)
+	srv := grpc.NewServer(grpc.UnaryInterceptor(UnaryServerInterceptor(WithMiddleware(server))))
+	grpc_health_v1.RegisterHealthServer(srv, sendHeaderHealth{})
+	go func() { _ = srv.Serve(lis) }()
+	defer srv.Stop()
+
+	conn, err := grpc.Dial("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, s string) (net.Conn, error) { return lis.DialContext(ctx) }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	assert.NoError(t, err)
+	defer conn.Close()
+
+	// handler 已发送 header 时 RPC 仍然成功
+	var header metadata.MD
+	resp, err := grpc_health_v1.NewHealthClient(conn).Check(context.Background(), &grpc_health_v1.HealthCheckRequest{}, grpc.Header(&header))
+	assert.NoError(t, err)
+	assert.Equal(t, grpc_health_v1.HealthCheckResponse_SERVING, resp.GetStatus())
+	assert.Equal(t, []string{"gkit"}, header.Get("x-handler"))
+}
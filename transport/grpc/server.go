@@ -0,0 +1,104 @@
+package grpc
+
+import (
+	"context"
+
+	"github.com/songzhibin97/gkit/middleware"
+	"github.com/songzhibin97/gkit/options"
+	"github.com/songzhibin97/gkit/trace"
+	"github.com/songzhibin97/gkit/transport"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+type config struct {
+	ms []middleware.MiddleWare
+	// endpoint: 服务端地址, 形如 grpc://127.0.0.1:9000
+	endpoint string
+}
+
+// WithMiddleware 设置中间件, 按顺序由外向内执行
+func WithMiddleware(ms ...middleware.MiddleWare) options.Option {
+	return func(o interface{}) {
+		o.(*config).ms = ms
+	}
+}
+
+// WithEndpoint 设置服务端 Transporter 的 Endpoint, 客户端使用连接的 Target
+func WithEndpoint(endpoint string) options.Option {
+	return func(o interface{}) {
+		o.(*config).endpoint = endpoint
+	}
+}
+
+func newConfig(opts ...options.Option) *config {
+	conf := &config{}
+	for _, opt := range opts {
+		opt(conf)
+	}
+	return conf
+}
+
+// newServerContext 根据 incoming metadata 构建服务端链路上下文
+func newServerContext(ctx context.Context, endpoint, method string) (context.Context, *Transport) {
+	md, _ := metadata.FromIncomingContext(ctx)
+	tr := &Transport{
+		endpoint:    endpoint,
+		operation:   method,
+		reqHeader:   headerCarrier(md.Copy()),
+		replyHeader: headerCarrier(metadata.MD{}),
+	}
+	return trace.NewServerTransportContext(ctx, tr), tr
+}
+
+// sendReplyHeader 将 ResponseHeader 作为 gRPC header 发送
+func sendReplyHeader(ctx context.Context, tr *Transport) error {
+	if len(tr.replyHeader) == 0 {
+		return nil
+	}
+	return grpc.SetHeader(ctx, metadata.MD(tr.replyHeader))
+}
+
+// UnaryServerInterceptor 执行中间件链的服务端一元拦截器
+// 中间件的 request 与 reply 为 gRPC 的请求与响应消息
+func UnaryServerInterceptor(opts ...options.Option) grpc.UnaryServerInterceptor {
+	conf := newConfig(opts...)
+	chain := transport.Chain(conf.ms...)
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		ctx, tr := newServerContext(ctx, conf.endpoint, info.FullMethod)
+		reply, err := chain(func(ctx context.Context, req interface{}) (interface{}, error) {
+			return handler(ctx, req)
+		})(ctx, req)
+		// handler 已调用 grpc.SendHeader 时 SetHeader 返回错误,
+		// 此时 header 已经发出, 忽略错误, 不影响 RPC 的结果
+		_ = sendReplyHeader(ctx, tr)
+		return reply, err
+	}
+}
+
+// serverStream 替换 Context 的 grpc.ServerStream
+type serverStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *serverStream) Context() context.Context {
+	return s.ctx
+}
+
+// StreamServerInterceptor 执行中间件链的服务端流拦截器
+// 中间件的 request 为 *grpc.StreamServerInfo, reply 为 nil
+func StreamServerInterceptor(opts ...options.Option) grpc.StreamServerInterceptor {
+	conf := newConfig(opts...)
+	chain := transport.Chain(conf.ms...)
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		ctx, tr := newServerContext(ss.Context(), conf.endpoint, info.FullMethod)
+		_, err := chain(func(ctx context.Context, req interface{}) (interface{}, error) {
+			if hErr := sendReplyHeader(ctx, tr); hErr != nil {
+				return nil, hErr
+			}
+			return nil, handler(srv, &serverStream{ServerStream: ss, ctx: ctx})
+		})(ctx, info)
+		return err
+	}
+}
@@ -0,0 +1,66 @@
+package grpc
+
+import (
+	"github.com/songzhibin97/gkit/trace"
+	"google.golang.org/grpc/metadata"
+)
+
+var _ trace.Transporter = (*Transport)(nil)
+
+// Transport gRPC 链路上下文
+type Transport struct {
+	endpoint    string
+	operation   string
+	reqHeader   headerCarrier
+	replyHeader headerCarrier
+}
+
+// Kind returns the transport kind.
+func (tr *Transport) Kind() trace.Kind {
+	return trace.KindGRPC
+}
+
+// Endpoint returns the transport endpoint.
+func (tr *Transport) Endpoint() string {
+	return tr.endpoint
+}
+
+// Operation returns the full method name, eg. /helloworld.Greeter/SayHello.
+func (tr *Transport) Operation() string {
+	return tr.operation
+}
+
+// RequestHeader returns the request metadata.
+func (tr *Transport) RequestHeader() trace.Header {
+	return tr.reqHeader
+}
+
+// ResponseHeader returns the reply metadata.
+func (tr *Transport) ResponseHeader() trace.Header {
+	return tr.replyHeader
+}
+
+type headerCarrier metadata.MD
+
+// Get returns the value associated with the passed key.
+func (mc headerCarrier) Get(key string) string {
+	vals := metadata.MD(mc).Get(key)
+	if len(vals) > 0 {
+		return vals[0]
+	}
+	return ""
+}
+
+// Set stores the key-value pair.
+func (mc headerCarrier) Set(key string, value string) {
+	metadata.MD(mc).Set(key, value)
+}
+
+// Keys lists the keys stored in this carrier.
+func (mc headerCarrier) Keys() []string {
+	keys := make([]string, 0, len(mc))
+	for k := range metadata.MD(mc) {
+		keys = append(keys, k)
+	}
+	return keys
+}
@@ -0,0 +1,107 @@
+package http
+
+import (
+	"context"
+	"io"
+	"mime"
+	"net/http"
+	"strings"
+
+	"github.com/songzhibin97/gkit/coding"
+	"github.com/songzhibin97/gkit/coding/json"
+	"github.com/songzhibin97/gkit/coding/proto"
+	"github.com/songzhibin97/gkit/coding/xml"
+	"github.com/songzhibin97/gkit/errors"
+)
+
+// maxErrorBodySize 解析错误响应体的最大字节数
+const maxErrorBodySize = 1 << 20
+
+// ErrorEncoder 中间件返回错误且尚未写入响应时写入响应
+type ErrorEncoder func(w http.ResponseWriter, r *http.Request, err error)
+
+// ErrorDecoder 将响应转换为错误, 返回 nil 表示响应正常
+type ErrorDecoder func(ctx context.Context, resp *http.Response) error
+
+// DefaultErrorEncoder 以 StatusCode 作为状态码, 不是合法的 HTTP 状态码时使用 500, 响应体为 errors.Error
+// 根据请求的 Accept(其次 Content-Type) 选择 json、xml 或 proto 格式, 默认 json
+func DefaultErrorEncoder(w http.ResponseWriter, r *http.Request, err error) {
+	se := errors.FromError(err)
+	code := se.StatusCode()
+	// WriteHeader 对 100-999 以外的状态码 panic
+	if code < 100 || code > 999 {
+		code = http.StatusInternalServerError
+	}
+	name := json.Name
+	if r != nil {
+		if n := codeName(r.Header.Get("Accept")); n != "" {
+			name = n
+		} else if n := codeName(r.Header.Get("Content-Type")); n != "" {
+			name = n
+		}
+	}
+	body, mErr := coding.GetCode(name).Marshal(se)
+	if mErr != nil {
+		http.Error(w, se.Message, code)
+		return
+	}
+	w.Header().Set("Content-Type", contentType(name))
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.WriteHeader(code)
+	_, _ = w.Write(body)
+}
+
+// DefaultErrorDecoder 将非 2xx 响应转换为 *errors.Error
+// 响应体按 Content-Type 解析, 无法解析时以状态码及响应体构造错误
+func DefaultErrorDecoder(_ context.Context, resp *http.Response) error {
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return nil
+	}
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxErrorBodySize))
+	if err != nil {
+		return errors.New(resp.StatusCode, errors.UnknownReason, err.Error()).WithCause(err)
+	}
+	if name := codeName(resp.Header.Get("Content-Type")); name != "" && len(body) > 0 {
+		se := new(errors.Error)
+		if uErr := coding.GetCode(name).Unmarshal(body, se); uErr == nil && se.Code != 0 {
+			return se
+		}
+	}
+	return errors.New(resp.StatusCode, errors.UnknownReason, strings.TrimSpace(string(body)))
+}
+
+// codeName 根据 media type 返回 coding 名称, 无法识别时返回空
+// 支持形如 application/json、application/problem+json 以及逗号分隔的 Accept
+func codeName(header string) string {
+	for _, part := range strings.Split(header, ",") {
+		mediaType, _, err := mime.ParseMediaType(strings.TrimSpace(part))
+		if err != nil {
+			continue
+		}
+		subtype := mediaType[strings.LastIndex(mediaType, "/")+1:]
+		if i := strings.LastIndex(subtype, "+"); i >= 0 {
+			subtype = subtype[i+1:]
+		}
+		switch subtype {
+		case "json":
+			return json.Name
+		case "xml":
+			return xml.Name
+		case "proto", "protobuf", "x-protobuf":
+			return proto.Name
+		}
+	}
+	return ""
+}
+
+// contentType coding 名称对应的 Content-Type
+func contentType(name string) string {
+	switch name {
+	case xml.Name:
+		return "application/xml"
+	case proto.Name:
+		return "application/x-protobuf"
+	default:
+		return "application/json"
+	}
+}
@@ -0,0 +1,103 @@
+package http
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/songzhibin97/gkit/errors"
+	"github.com/songzhibin97/gkit/middleware"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestErrorEncoderDecoder(t *testing.T) {
+	want := errors.NotFound("USER_NOT_FOUND", "user not found").
+		AddMetadata(map[string]string{"id": "1"}).
+		WithFieldViolation("id", "unknown")
+	tests := []struct {
+		accept      string
+		contentType string
+		details     bool
+	}{
+		{accept: "", contentType: "application/json", details: true},
+		{accept: "text/html, application/json;q=0.9", contentType: "application/json", details: true},
+		{accept: "application/problem+json", contentType: "application/json", details: true},
+		{accept: "application/xml", contentType: "application/xml"},
+		{accept: "application/x-protobuf", contentType: "application/x-protobuf", details: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.contentType+" "+tt.accept, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, "/users/1", nil)
+			if tt.accept != "" {
+				r.Header.Set("Accept", tt.accept)
+			}
+			w := httptest.NewRecorder()
+			DefaultErrorEncoder(w, r, want)
+			resp := w.Result()
+			assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+			assert.Equal(t, tt.contentType, resp.Header.Get("Content-Type"))
+
+			err := DefaultErrorDecoder(context.Background(), resp)
+			se := new(errors.Error)
+			if assert.True(t, errors.As(err, &se)) {
+				assert.Equal(t, int32(http.StatusNotFound), se.Code)
+				assert.Equal(t, "USER_NOT_FOUND", se.Reason)
+				assert.Equal(t, "user not found", se.Message)
+				assert.Equal(t, "1", se.Metadata["id"])
+				assert.Equal(t, tt.details, len(se.FieldViolations()) == 1)
+			}
+		})
+	}
+}
+
+func TestErrorEncoderInvalidCode(t *testing.T) {
+	for _, code := range []int{0, 42, 1000, -1} {
+		w := httptest.NewRecorder()
+		DefaultErrorEncoder(w, httptest.NewRequest(http.MethodGet, "/", nil), errors.New(code, "CUSTOM", "custom"))
+		assert.Equal(t, http.StatusInternalServerError, w.Code, "code %d", code)
+		assert.Contains(t, w.Body.String(), "CUSTOM")
+	}
+}
+
+func TestErrorDecoderPlainBody(t *testing.T) {
+	resp := &http.Response{
+		StatusCode: http.StatusBadGateway,
+		Header:     http.Header{"Content-Type": []string{"text/plain"}},
+		Body:       io.NopCloser(strings.NewReader("bad gateway\n")),
+	}
+	se := errors.FromError(DefaultErrorDecoder(context.Background(), resp))
+	assert.Equal(t, int32(http.StatusBadGateway), se.Code)
+	assert.Equal(t, errors.UnknownReason, se.Reason)
+	assert.Equal(t, "bad gateway", se.Message)
+
+	resp = &http.Response{StatusCode: http.StatusNoContent, Body: http.NoBody}
+	assert.NoError(t, DefaultErrorDecoder(context.Background(), resp))
+}
+
+func TestRoundTripperErrorDecoder(t *testing.T) {
+	srv := httptest.NewServer(NewHandler(http.NotFoundHandler(), WithMiddleware(
+		func(middleware.Endpoint) middleware.Endpoint {
+			return func(context.Context, interface{}) (interface{}, error) {
+				return nil, errors.Forbidden("DENIED", "denied")
+			}
+		},
+	)))
+	defer srv.Close()
+
+	var seen error
+	client := func(next middleware.Endpoint) middleware.Endpoint {
+		return func(ctx context.Context, req interface{}) (interface{}, error) {
+			reply, err := next(ctx, req)
+			seen = err
+			return reply, err
+		}
+	}
+	cli := &http.Client{Transport: NewRoundTripper(nil, WithMiddleware(client), WithErrorDecoder(DefaultErrorDecoder))}
+	_, err := cli.Get(srv.URL)
+	assert.True(t, errors.IsForbidden(err))
+	assert.Equal(t, "DENIED", errors.Reason(err))
+	assert.True(t, errors.IsForbidden(seen), "middleware should see the decoded error")
+}
@@ -0,0 +1,202 @@
+package http
+
+import (
+	"bufio"
+	"context"
+	"io"
+	"net"
+	"net/http"
+
+	"github.com/songzhibin97/gkit/errors"
+	"github.com/songzhibin97/gkit/middleware"
+	"github.com/songzhibin97/gkit/options"
+	"github.com/songzhibin97/gkit/trace"
+	"github.com/songzhibin97/gkit/transport"
+)
+
+// ErrNoResponse 中间件未返回错误也未返回 *http.Response
+var ErrNoResponse = errors.New(http.StatusInternalServerError, "NO_RESPONSE", "middleware returned no response")
+
+type config struct {
+	ms           []middleware.MiddleWare
+	errorEncoder ErrorEncoder
+	// errorDecoder: 客户端将非 2xx 响应转换为错误, 为 nil 时不转换
+	errorDecoder ErrorDecoder
+	// operation: 根据请求生成 Operation, 默认为 URL.Path
+	operation func(r *http.Request) string
+}
+
+func defaultConfig() *config {
+	return &config{
+		errorEncoder: DefaultErrorEncoder,
+		operation: func(r *http.Request) string {
+			return r.URL.Path
+		},
+	}
+}
+
+// WithMiddleware 设置中间件, 按顺序由外向内执行
+func WithMiddleware(ms ...middleware.MiddleWare) options.Option {
+	return func(o interface{}) {
+		o.(*config).ms = ms
+	}
+}
+
+// WithErrorEncoder 设置中间件返回错误时的响应方式
+func WithErrorEncoder(encoder ErrorEncoder) options.Option {
+	return func(o interface{}) {
+		o.(*config).errorEncoder = encoder
+	}
+}
+
+// WithErrorDecoder 设置客户端将响应转换为错误的方式, 例如 DefaultErrorDecoder
+// 转换出错误时响应体被关闭, 中间件及 RoundTrip 返回该错误
+func WithErrorDecoder(decoder ErrorDecoder) options.Option {
+	return func(o interface{}) {
+		o.(*config).errorDecoder = decoder
+	}
+}
+
+// WithOperation 设置根据请求生成 Operation 的方法, 例如使用路由模板
+func WithOperation(f func(r *http.Request) string) options.Option {
+	return func(o interface{}) {
+		o.(*config).operation = f
+	}
+}
+
+// NewHandler 将 http.Handler 包装为执行中间件链的 http.Handler
+// 中间件的 request 为 *http.Request, trace.Transporter 通过 trace.FromServerTransportContext 获取
+func NewHandler(h http.Handler, opts ...options.Option) http.Handler {
+	conf := defaultConfig()
+	for _, opt := range opts {
+		opt(conf)
+	}
+	chain := transport.Chain(conf.ms...)
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		tr := trace.NewTransport(endpoint(r), conf.operation(r), "", r, w.Header())
+		ctx := trace.NewServerTransportContext(r.Context(), tr)
+		rw := &responseWriter{ResponseWriter: w}
+		_, err := chain(func(ctx context.Context, req interface{}) (interface{}, error) {
+			h.ServeHTTP(rw, req.(*http.Request).WithContext(ctx))
+			return nil, nil
+		})(ctx, r)
+		// Handler 执行后返回的错误(如 recovery 捕获的 panic)在尚未写入响应时同样需要编码
+		if err != nil && !rw.written {
+			conf.errorEncoder(w, r, err)
+		}
+	})
+}
+
+// responseWriter 记录是否已写入响应
+// 转发 http.Flusher、http.Hijacker 与 io.ReaderFrom, 不影响 websocket 与 sendfile
+type responseWriter struct {
+	http.ResponseWriter
+	written bool
+}
+
+func (w *responseWriter) WriteHeader(statusCode int) {
+	w.written = true
+	w.ResponseWriter.WriteHeader(statusCode)
+}
+
+func (w *responseWriter) Write(p []byte) (int, error) {
+	w.written = true
+	return w.ResponseWriter.Write(p)
+}
+
+// Flush 实现 http.Flusher
+func (w *responseWriter) Flush() {
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		w.written = true
+		f.Flush()
+	}
+}
+
+// Hijack 实现 http.Hijacker, 接管连接后不再编码错误
+func (w *responseWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	h, ok := w.ResponseWriter.(http.Hijacker)
+	if !ok {
+		return nil, nil, http.ErrNotSupported
+	}
+	w.written = true
+	return h.Hijack()
+}
+
+// ReadFrom 实现 io.ReaderFrom, 原始 http.ResponseWriter 支持时使用 sendfile
+func (w *responseWriter) ReadFrom(r io.Reader) (int64, error) {
+	w.written = true
+	if rf, ok := w.ResponseWriter.(io.ReaderFrom); ok {
+		return rf.ReadFrom(r)
+	}
+	return io.Copy(struct{ io.Writer }{w.ResponseWriter}, r)
+}
+
+// Unwrap 供 http.ResponseController 获取原始 http.ResponseWriter
+func (w *responseWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// endpoint 服务端地址, 形如 http://127.0.0.1:8000
+func endpoint(r *http.Request) string {
+	scheme := "http"
+	if r.TLS != nil {
+		scheme = "https"
+	}
+	return scheme + "://" + r.Host
+}
+
+// roundTripper 执行中间件链的 http.RoundTripper
+type roundTripper struct {
+	base  http.RoundTripper
+	chain middleware.MiddleWare
+	conf  *config
+}
+
+// RoundTrip 实现 http.RoundTripper
+// 中间件写入 RequestHeader 的内容随请求发出, 响应头写入 ResponseHeader
+func (t *roundTripper) RoundTrip(r *http.Request) (*http.Response, error) {
+	r = r.Clone(r.Context())
+	if r.Header == nil {
+		r.Header = make(http.Header)
+	}
+	replyHeader := make(http.Header)
+	tr := trace.NewTransport(r.URL.Scheme+"://"+r.URL.Host, t.conf.operation(r), "", r, replyHeader)
+	ctx := trace.NewClientTransportContext(r.Context(), tr)
+	reply, err := t.chain(func(ctx context.Context, req interface{}) (interface{}, error) {
+		resp, err := t.base.RoundTrip(req.(*http.Request).WithContext(ctx))
+		if err != nil {
+			return nil, err
+		}
+		for k, v := range resp.Header {
+			replyHeader[k] = v
+		}
+		if t.conf.errorDecoder != nil {
+			if err := t.conf.errorDecoder(ctx, resp); err != nil {
+				_ = resp.Body.Close()
+				return nil, err
+			}
+		}
+		return resp, nil
+	})(ctx, r)
+	if err != nil {
+		return nil, err
+	}
+	resp, ok := reply.(*http.Response)
+	if !ok {
+		return nil, ErrNoResponse
+	}
+	return resp, nil
+}
+
+// NewRoundTripper 将 http.RoundTripper 包装为执行中间件链的 http.RoundTripper, base 为 nil 时使用 http.DefaultTransport
+// 中间件的 request 为 *http.Request, reply 为 *http.Response
+func NewRoundTripper(base http.RoundTripper, opts ...options.Option) http.RoundTripper {
+	conf := defaultConfig()
+	for _, opt := range opts {
+		opt(conf)
+	}
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	return &roundTripper{base: base, chain: transport.Chain(conf.ms...), conf: conf}
+}
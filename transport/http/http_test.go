@@ -0,0 +1,120 @@
+package http
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/songzhibin97/gkit/errors"
+	"github.com/songzhibin97/gkit/log"
+	"github.com/songzhibin97/gkit/middleware"
+	"github.com/songzhibin97/gkit/middleware/recovery"
+	"github.com/songzhibin97/gkit/trace"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHandlerAndRoundTripper(t *testing.T) {
+	server := func(next middleware.Endpoint) middleware.Endpoint {
+		return func(ctx context.Context, req interface{}) (interface{}, error) {
+			tr, ok := trace.FromServerTransportContext(ctx)
+			assert.True(t, ok)
+			assert.Equal(t, trace.KindHTTP, tr.Kind())
+			assert.Equal(t, "/hello", tr.Operation())
+			if tr.RequestHeader().Get("x-token") != "gkit" {
+				return nil, errors.Unauthorized("TOKEN_INVALID", "invalid token")
+			}
+			tr.ResponseHeader().Set("x-server", "gkit")
+			return next(ctx, req)
+		}
+	}
+	srv := httptest.NewServer(NewHandler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, ok := trace.FromServerTransportContext(r.Context())
+		assert.True(t, ok)
+		_, _ = io.WriteString(w, "hello")
+	}), WithMiddleware(server)))
+	defer srv.Close()
+
+	var gotReplyHeader string
+	client := func(next middleware.Endpoint) middleware.Endpoint {
+		return func(ctx context.Context, req interface{}) (interface{}, error) {
+			tr, ok := trace.FromClientTransportContext(ctx)
+			assert.True(t, ok)
+			assert.Equal(t, srv.URL, tr.Endpoint())
+			tr.RequestHeader().Set("x-token", "gkit")
+			reply, err := next(ctx, req)
+			gotReplyHeader = tr.ResponseHeader().Get("x-server")
+			return reply, err
+		}
+	}
+	cli := &http.Client{Transport: NewRoundTripper(nil, WithMiddleware(client))}
+	req, _ := http.NewRequest(http.MethodGet, srv.URL+"/hello", nil)
+	resp, err := cli.Do(req)
+	assert.NoError(t, err)
+	body, _ := io.ReadAll(resp.Body)
+	_ = resp.Body.Close()
+	assert.Equal(t, "hello", string(body))
+	assert.Equal(t, "gkit", gotReplyHeader)
+	assert.Empty(t, req.Header.Get("x-token"), "caller request must not be modified")
+
+	// 未携带 token 时中间件拒绝, 由 ErrorEncoder 写入响应
+	resp, err = http.Get(srv.URL + "/hello")
+	assert.NoError(t, err)
+	_ = resp.Body.Close()
+	assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+}
+
+func TestHandlerPanicRecovered(t *testing.T) {
+	write := false
+	h := NewHandler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if write {
+			w.WriteHeader(http.StatusAccepted)
+		}
+		panic("boom")
+	}), WithMiddleware(recovery.Recovery(recovery.WithLogger(log.NewStdLogger(io.Discard)))))
+
+	// Handler 未写入响应时由 ErrorEncoder 编码 recovery 返回的错误
+	rw := httptest.NewRecorder()
+	h.ServeHTTP(rw, httptest.NewRequest(http.MethodGet, "/", nil))
+	assert.Equal(t, http.StatusInternalServerError, rw.Code)
+	assert.Equal(t, "application/json", rw.Header().Get("Content-Type"))
+	assert.Contains(t, rw.Body.String(), recovery.ErrUnknownRequest.Reason)
+
+	// Handler 已写入响应时不再重复写入
+	write = true
+	rw = httptest.NewRecorder()
+	h.ServeHTTP(rw, httptest.NewRequest(http.MethodGet, "/", nil))
+	assert.Equal(t, http.StatusAccepted, rw.Code)
+	assert.Empty(t, rw.Body.String())
+}
+
+func TestHandlerForwardsWriterInterfaces(t *testing.T) {
+	h := NewHandler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/hijack" {
+			conn, rw, err := w.(http.Hijacker).Hijack()
+			if !assert.NoError(t, err) {
+				return
+			}
+			defer conn.Close()
+			_, _ = rw.WriteString("HTTP/1.1 200 OK\r\nContent-Length: 6\r\nConnection: close\r\n\r\nhijack")
+			_ = rw.Flush()
+			return
+		}
+		_, ok := w.(io.ReaderFrom)
+		assert.True(t, ok)
+		_, _ = io.Copy(w, strings.NewReader("copy"))
+	}))
+	srv := httptest.NewServer(h)
+	defer srv.Close()
+
+	for path, want := range map[string]string{"/hijack": "hijack", "/copy": "copy"} {
+		resp, err := http.Get(srv.URL + path)
+		assert.NoError(t, err)
+		body, err := io.ReadAll(resp.Body)
+		_ = resp.Body.Close()
+		assert.NoError(t, err)
+		assert.Equal(t, want, string(body))
+	}
+}
@@ -0,0 +1,15 @@
+package transport
+
+// package transport: 将 middleware.Endpoint 及 trace.Transporter 适配到 net/http 与 gRPC
+
+import "github.com/songzhibin97/gkit/middleware"
+
+// Chain 将 ms 串联为一个中间件, ms 为空时原样返回 Endpoint
+func Chain(ms ...middleware.MiddleWare) middleware.MiddleWare {
+	if len(ms) == 0 {
+		return func(next middleware.Endpoint) middleware.Endpoint {
+			return next
+		}
+	}
+	return middleware.Chain(ms[0], ms[1:]...)
+}
@@ -52,9 +52,25 @@ type configs struct {
 	GCHeapConfigs *typeConfig
 	CpuConfigs    *typeConfig
 	ThreadConfigs *typeConfig
+	MutexConfigs  *mutexConfigs
+	BlockConfigs  *blockConfigs
+	TraceConfigs  *traceConfigs
 
 	// profile reporter
 	rptConfigs *ReporterConfigs
+
+	ContinuousConfigs *continuousConfigs
+
+	// store for binary dumps, default a LocalStore under DumpPath
+	// with dumpRetention
+	store         ProfileStore
+	dumpRetention Retention
+
+	// duration of the cpu profile sampling
+	cpuSamplingTime time.Duration
+
+	// max duration of the on-demand cpu profile or trace
+	maxDumpDuration time.Duration
 }
 
 // DumpConfigs contains configuration about dump file.
@@ -77,7 +93,9 @@ type ShrinkThrConfigs struct {
 
 type logConfigs struct {
 	RotateEnable    bool
-	SplitLoggerSize int64 // SplitLoggerSize The size of the log split
+	SplitLoggerSize int64         // SplitLoggerSize The size of the log split
+	MaxBackups      int           // MaxBackups the number of rotated logs to keep, <= 0 keeps all
+	MaxAge          time.Duration // MaxAge the duration to keep rotated logs, <= 0 keeps all
 }
 
 type typeConfig struct {
@@ -110,6 +128,42 @@ type groupConfigs struct {
 	GoroutineTriggerNumMax int // goroutine trigger max in number
 }
 
+type mutexConfigs struct {
+	// enable the mutex dumper, the trigger is the sampled lock contention events per second
+	// since the previous collection, see the rules of groupConfigs.
+	*typeConfig
+	// ProfileFraction is set by runtime.SetMutexProfileFraction when the dumper is enabled,
+	// <= 0 keeps the fraction set by the application.
+	ProfileFraction int
+
+	// prevFraction is the fraction restored when the dumper is disabled, valid if fractionSet.
+	prevFraction int
+	fractionSet  bool
+}
+
+type blockConfigs struct {
+	// enable the block dumper, the trigger is the sampled blocking events per second
+	// since the previous collection, see the rules of groupConfigs.
+	*typeConfig
+	// ProfileRate is set by runtime.SetBlockProfileRate when the dumper is enabled,
+	// <= 0 keeps the rate set by the application.
+	ProfileRate int
+
+	// rateSet reports whether the rate is set by the dumper.
+	rateSet bool
+}
+
+type traceConfigs struct {
+	// enable the execution trace capture, the trigger is the p99 scheduling latency
+	// in microseconds since the previous collection.
+	*typeConfig
+	// Goroutine captures the trace when the goroutine number matches the rules of GroupConfigs too,
+	// no matter whether the goroutine dumper is enabled.
+	Goroutine bool
+	// Duration of the capture, default 3s
+	Duration time.Duration
+}
+
 type memConfigs struct {
 	// enable the heap dumper, should dump if one of the following requirements is matched
 	//   1. memory usage > MemTriggerPercentMin && memory usage diff > MemTriggerPercentDiff
@@ -137,6 +191,21 @@ type threadConfigs struct {
 	ThreadTriggerPercentAbs  int // thread trigger abs in number
 }
 
+type continuousConfigs struct {
+	// enable the periodic profiling, the profiles are shipped through the
+	// ProfileReporter as the baseline of the anomaly dumps
+	Enable bool
+	// Interval between two rounds, default 5m
+	Interval time.Duration
+	// Jitter is the max random delay added to every interval, default 30s
+	Jitter time.Duration
+	// CPUDuration is the cpu sampling duration of every round, default 10s, <= 0 disables cpu profiling
+	CPUDuration time.Duration
+	// Profiles are the runtime/pprof profiles snapshotted every round, default heap, allocs, mutex, block.
+	// mutex and block are empty unless runtime.SetMutexProfileFraction/runtime.SetBlockProfileRate are set.
+	Profiles []string
+}
+
 type ReporterConfigs struct {
 	reporter ProfileReporter
 	active   int32 // switch
@@ -209,6 +278,53 @@ func defaultThreadConfig() *typeConfig {
 	}
 }
 
+func defaultMutexConfigs() *mutexConfigs {
+	return &mutexConfigs{
+		typeConfig: &typeConfig{
+			Enable:      false,
+			TriggerMin:  defaultMutexTriggerMin,
+			TriggerAbs:  defaultMutexTriggerAbs,
+			TriggerDiff: defaultMutexTriggerDiff,
+		},
+		ProfileFraction: defaultMutexProfileFraction,
+	}
+}
+
+func defaultBlockConfigs() *blockConfigs {
+	return &blockConfigs{
+		typeConfig: &typeConfig{
+			Enable:      false,
+			TriggerMin:  defaultBlockTriggerMin,
+			TriggerAbs:  defaultBlockTriggerAbs,
+			TriggerDiff: defaultBlockTriggerDiff,
+		},
+		ProfileRate: defaultBlockProfileRate,
+	}
+}
+
+func defaultTraceConfigs() *traceConfigs {
+	return &traceConfigs{
+		typeConfig: &typeConfig{
+			Enable:      false,
+			TriggerMin:  defaultTraceTriggerMin,
+			TriggerAbs:  defaultTraceTriggerAbs,
+			TriggerDiff: defaultTraceTriggerDiff,
+		},
+		Goroutine: false,
+		Duration:  defaultTraceDuration,
+	}
+}
+
+func defaultContinuousConfigs() *continuousConfigs {
+	return &continuousConfigs{
+		Enable:      false,
+		Interval:    defaultContinuousInterval,
+		Jitter:      defaultContinuousJitter,
+		CPUDuration: defaultContinuousCPUDuration,
+		Profiles:    []string{"heap", "allocs", "mutex", "block"},
+	}
+}
+
 func defaultConfig() *configs {
 	return &configs{
 		logConfigs:        defaultLogConfigs(),
@@ -217,6 +333,10 @@ func defaultConfig() *configs {
 		GCHeapConfigs:     defaultGCHeapOptions(),
 		CpuConfigs:        defaultCPUConfigs(),
 		ThreadConfigs:     defaultThreadConfig(),
+		MutexConfigs:      defaultMutexConfigs(),
+		BlockConfigs:      defaultBlockConfigs(),
+		TraceConfigs:      defaultTraceConfigs(),
+		ContinuousConfigs: defaultContinuousConfigs(),
 		LogLevel:          LogLevelDebug,
 		Logger:            os.Stdout,
 		activeLog:         newLoggerRef(os.Stdout),
@@ -231,8 +351,10 @@ func defaultConfig() *configs {
 		ShrinkThrConfigs: &ShrinkThrConfigs{
 			Enable: false,
 		},
-		L:          &sync.RWMutex{},
-		rptConfigs: defaultReporterConfigs(),
+		L:               &sync.RWMutex{},
+		rptConfigs:      defaultReporterConfigs(),
+		cpuSamplingTime: defaultCPUSamplingTime,
+		maxDumpDuration: defaultMaxDumpDuration,
 	}
 }
 
@@ -289,3 +411,49 @@ func (c *configs) GetReporterConfigs() ReporterConfigs {
 	defer c.L.RUnlock()
 	return *c.rptConfigs
 }
+
+// GetMutexConfigs returns a copy of mutexConfigs.
+func (c *configs) GetMutexConfigs() mutexConfigs {
+	c.L.RLock()
+	defer c.L.RUnlock()
+	config := *c.MutexConfigs
+	typeConfigCopy := *c.MutexConfigs.typeConfig
+	config.typeConfig = &typeConfigCopy
+	return config
+}
+
+// GetBlockConfigs returns a copy of blockConfigs.
+func (c *configs) GetBlockConfigs() blockConfigs {
+	c.L.RLock()
+	defer c.L.RUnlock()
+	config := *c.BlockConfigs
+	typeConfigCopy := *c.BlockConfigs.typeConfig
+	config.typeConfig = &typeConfigCopy
+	return config
+}
+
+// GetTraceConfigs returns a copy of traceConfigs.
+func (c *configs) GetTraceConfigs() traceConfigs {
+	c.L.RLock()
+	defer c.L.RUnlock()
+	config := *c.TraceConfigs
+	typeConfigCopy := *c.TraceConfigs.typeConfig
+	config.typeConfig = &typeConfigCopy
+	return config
+}
+
+// GetDumpProfileType returns the dump profile type.
+func (c *configs) GetDumpProfileType() dumpProfileType {
+	c.L.RLock()
+	defer c.L.RUnlock()
+	return c.DumpProfileType
+}
+
+// GetContinuousConfigs returns a copy of continuousConfigs.
+func (c *configs) GetContinuousConfigs() continuousConfigs {
+	c.L.RLock()
+	defer c.L.RUnlock()
+	config := *c.ContinuousConfigs
+	config.Profiles = append([]string(nil), c.ContinuousConfigs.Profiles...)
+	return config
+}
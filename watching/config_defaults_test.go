@@ -19,6 +19,9 @@ func TestDefaultConfigs_FieldsAreNotPermuted(t *testing.T) {
 		{"cpu", defaultCPUConfigs(), defaultCPUTriggerMin, defaultCPUTriggerAbs, defaultCPUTriggerDiff},
 		{"thread", defaultThreadConfig(), defaultThreadTriggerMin, defaultThreadTriggerAbs, defaultThreadTriggerDiff},
 		{"gcheap", defaultGCHeapOptions(), defaultGCHeapTriggerMin, defaultGCHeapTriggerAbs, defaultGCHeapTriggerDiff},
+		{"mutex", defaultMutexConfigs().typeConfig, defaultMutexTriggerMin, defaultMutexTriggerAbs, defaultMutexTriggerDiff},
+		{"block", defaultBlockConfigs().typeConfig, defaultBlockTriggerMin, defaultBlockTriggerAbs, defaultBlockTriggerDiff},
+		{"trace", defaultTraceConfigs().typeConfig, defaultTraceTriggerMin, defaultTraceTriggerAbs, defaultTraceTriggerDiff},
 	}
 	for _, c := range cases {
 		t.Run(c.name, func(t *testing.T) {
@@ -15,6 +15,8 @@ const (
 	defaultCPUTriggerDiff  = 25              // 25%
 	defaultCPUSamplingTime = 5 * time.Second // collect 5s cpu profile
 
+	defaultMaxDumpDuration = time.Minute // on-demand cpu profile or trace lasts at most 1m
+
 	defaultGoroutineTriggerMin  = 3000   // 3000 goroutines
 	defaultGoroutineTriggerAbs  = 200000 // 200k goroutines
 	defaultGoroutineTriggerDiff = 20     // 20%  diff
@@ -27,11 +29,32 @@ const (
 	defaultGCHeapTriggerAbs  = 40 // 40%
 	defaultGCHeapTriggerDiff = 20 // 20%
 
+	defaultMutexTriggerMin      = 10  // 10 contention events per second
+	defaultMutexTriggerAbs      = 500 // 500 contention events per second
+	defaultMutexTriggerDiff     = 50  // 50%
+	defaultMutexProfileFraction = 10  // report 1/10 contention events
+
+	defaultBlockTriggerMin  = 10                    // 10 blocking events per second
+	defaultBlockTriggerAbs  = 500                   // 500 blocking events per second
+	defaultBlockTriggerDiff = 50                    // 50%
+	defaultBlockProfileRate = int(time.Millisecond) // sample 1 blocking event per blocked 1ms
+
+	defaultTraceTriggerMin  = 1000            // 1ms scheduling latency p99
+	defaultTraceTriggerAbs  = 50000           // 50ms scheduling latency p99
+	defaultTraceTriggerDiff = 100             // 100%
+	defaultTraceDuration    = 3 * time.Second // capture 3s execution trace
+
+	defaultContinuousInterval    = 5 * time.Minute
+	defaultContinuousJitter      = 30 * time.Second
+	defaultContinuousCPUDuration = 10 * time.Second
+	continuousReason             = "continuous"
+
 	defaultInterval        = 5 * time.Second
 	defaultCooldown        = time.Minute
 	defaultDumpProfileType = binaryDump
 	defaultDumpPath        = "./tmp"
 	defaultLoggerName      = "watching.log"
+	backupLoggerLayout     = "_20060102150405.back"
 	defaultLoggerFlags     = os.O_RDWR | os.O_CREATE | os.O_APPEND
 	defaultLoggerPerm      = 0o644
 	defaultShardLoggerSize = 52428800 // 50m
@@ -52,6 +75,9 @@ const (
 	thread
 	goroutine
 	gcHeap
+	mutex
+	block
+	traceType
 )
 
 var type2name = map[configureType]string{
@@ -60,6 +86,9 @@ var type2name = map[configureType]string{
 	thread:    "thread",
 	goroutine: "goroutine",
 	gcHeap:    "gcHeap",
+	mutex:     "mutex",
+	block:     "block",
+	traceType: "trace",
 }
 
 const (
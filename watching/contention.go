@@ -0,0 +1,307 @@
+package watching
+
+import (
+	"bytes"
+	"fmt"
+	"math"
+	"runtime"
+	"runtime/metrics"
+	"runtime/pprof"
+	"runtime/trace"
+	"time"
+)
+
+const schedLatenciesMetric = "/sched/latencies:seconds"
+
+// EnableMutexDump enables the mutex dump, and sets the mutex profile fraction.
+// The dumped mutex profile is cumulative since the process started (or since the fraction was set),
+// not limited to the collection window that fired the trigger.
+func (w *Watching) EnableMutexDump() *Watching {
+	w.config.L.Lock()
+	defer w.config.L.Unlock()
+	c := w.config.MutexConfigs
+	if c.ProfileFraction > 0 && !c.fractionSet {
+		c.prevFraction = runtime.SetMutexProfileFraction(c.ProfileFraction)
+		c.fractionSet = true
+	}
+	c.Enable = true
+	return w
+}
+
+// DisableMutexDump disables the mutex dump, and restores the mutex profile fraction
+// if it was set by EnableMutexDump.
+func (w *Watching) DisableMutexDump() *Watching {
+	w.config.L.Lock()
+	defer w.config.L.Unlock()
+	c := w.config.MutexConfigs
+	if c.fractionSet {
+		runtime.SetMutexProfileFraction(c.prevFraction)
+		c.fractionSet = false
+	}
+	c.Enable = false
+	return w
+}
+
+// EnableBlockDump enables the block dump, and sets the block profile rate.
+// The dumped block profile is cumulative since the process started (or since the rate was set),
+// not limited to the collection window that fired the trigger.
+func (w *Watching) EnableBlockDump() *Watching {
+	w.config.L.Lock()
+	defer w.config.L.Unlock()
+	c := w.config.BlockConfigs
+	if c.ProfileRate > 0 && !c.rateSet {
+		runtime.SetBlockProfileRate(c.ProfileRate)
+		c.rateSet = true
+	}
+	c.Enable = true
+	return w
+}
+
+// DisableBlockDump disables the block dump, and turns off the block profile if it was set by EnableBlockDump.
+// The runtime can't report the previous block profile rate, so use a ProfileRate <= 0
+// if the application sets its own rate.
+func (w *Watching) DisableBlockDump() *Watching {
+	w.config.L.Lock()
+	defer w.config.L.Unlock()
+	c := w.config.BlockConfigs
+	if c.rateSet {
+		runtime.SetBlockProfileRate(0)
+		c.rateSet = false
+	}
+	c.Enable = false
+	return w
+}
+
+// EnableTraceDump enables the execution trace capture.
+func (w *Watching) EnableTraceDump() *Watching {
+	return w.setDumpEnabled(w.config.TraceConfigs.typeConfig, true)
+}
+
+// DisableTraceDump disables the execution trace capture.
+func (w *Watching) DisableTraceDump() *Watching {
+	return w.setDumpEnabled(w.config.TraceConfigs.typeConfig, false)
+}
+
+// contentionStats is the cumulative contention stats, the triggers use the delta
+// between two collections.
+type contentionStats struct {
+	time          time.Time
+	mutexEvents   int64
+	mutexCaptured bool
+	blockEvents   int64
+	blockCaptured bool
+	schedLatency  []uint64
+	schedBuckets  []float64
+	schedCaptured bool
+}
+
+// readContentionStats returns the cumulative contention stats of the process,
+// the mutex and block profiles are walked only if their triggers are enabled.
+func readContentionStats(mutex, block bool) contentionStats {
+	s := contentionStats{time: time.Now()}
+	if mutex {
+		s.mutexEvents, s.mutexCaptured = profileEvents(runtime.MutexProfile), true
+	}
+	if block {
+		s.blockEvents, s.blockCaptured = profileEvents(runtime.BlockProfile), true
+	}
+	sample := []metrics.Sample{{Name: schedLatenciesMetric}}
+	metrics.Read(sample)
+	if sample[0].Value.Kind() == metrics.KindFloat64Histogram {
+		h := sample[0].Value.Float64Histogram()
+		s.schedLatency = append([]uint64(nil), h.Counts...)
+		s.schedBuckets = h.Buckets
+		s.schedCaptured = true
+	}
+	return s
+}
+
+// profileEvents returns the total events of runtime.MutexProfile or runtime.BlockProfile.
+func profileEvents(profile func([]runtime.BlockProfileRecord) (int, bool)) int64 {
+	n, _ := profile(nil)
+	for {
+		// some records may be added between the two calls
+		records := make([]runtime.BlockProfileRecord, n+50)
+		var ok bool
+		if n, ok = profile(records); ok {
+			var events int64
+			for _, r := range records[:n] {
+				events += r.Count
+			}
+			return events
+		}
+	}
+}
+
+// collectContention returns the mutex and block events per second,
+// and the p99 scheduling latency in microseconds since the previous collection.
+// The rate of a disabled trigger is 0.
+func (w *Watching) collectContention() (mutexRate int, blockRate int, schedLatency int) {
+	cur := readContentionStats(w.config.GetMutexConfigs().Enable, w.config.GetBlockConfigs().Enable)
+	prev := w.contention
+	w.contention = cur
+	if prev.time.IsZero() {
+		return 0, 0, 0
+	}
+
+	if seconds := cur.time.Sub(prev.time).Seconds(); seconds > 0 {
+		if cur.mutexCaptured && prev.mutexCaptured {
+			mutexRate = int(float64(cur.mutexEvents-prev.mutexEvents) / seconds)
+		}
+		if cur.blockCaptured && prev.blockCaptured {
+			blockRate = int(float64(cur.blockEvents-prev.blockEvents) / seconds)
+		}
+	}
+	if cur.schedCaptured && prev.schedCaptured && len(cur.schedLatency) == len(prev.schedLatency) {
+		delta := make([]uint64, len(cur.schedLatency))
+		for i := range delta {
+			delta[i] = cur.schedLatency[i] - prev.schedLatency[i]
+		}
+		schedLatency = int(histogramPercentile(delta, cur.schedBuckets, 0.99) * 1e6)
+	}
+	return mutexRate, blockRate, schedLatency
+}
+
+// histogramPercentile returns the upper bound of the bucket holding the p percentile,
+// buckets are the boundaries of the counts as runtime/metrics.Float64Histogram.
+func histogramPercentile(counts []uint64, buckets []float64, p float64) float64 {
+	var total uint64
+	for _, c := range counts {
+		total += c
+	}
+	if total == 0 {
+		return 0
+	}
+	threshold := uint64(math.Ceil(float64(total) * p))
+	var cumulative uint64
+	for i, c := range counts {
+		cumulative += c
+		if cumulative < threshold {
+			continue
+		}
+		if upper := buckets[i+1]; !math.IsInf(upper, 1) {
+			return upper
+		}
+		return buckets[i]
+	}
+	return 0
+}
+
+// mutex start.
+func (w *Watching) mutexCheckAndDump(rate int) {
+	mutexConfig := w.config.GetMutexConfigs()
+	if !mutexConfig.Enable {
+		return
+	}
+
+	if w.mutexCoolDownTime.After(time.Now()) {
+		w.logf("[Watching] mutex dump is in cooldown")
+		return
+	}
+
+	if triggered := w.contentionProfile(rate, *mutexConfig.typeConfig, mutex, w.mutexStats); triggered {
+		w.mutexCoolDownTime = time.Now().Add(w.config.CoolDown)
+		w.mutexTriggerCount++
+	}
+}
+
+// block start.
+func (w *Watching) blockCheckAndDump(rate int) {
+	blockConfig := w.config.GetBlockConfigs()
+	if !blockConfig.Enable {
+		return
+	}
+
+	if w.blockCoolDownTime.After(time.Now()) {
+		w.logf("[Watching] block dump is in cooldown")
+		return
+	}
+
+	if triggered := w.contentionProfile(rate, *blockConfig.typeConfig, block, w.blockStats); triggered {
+		w.blockCoolDownTime = time.Now().Add(w.config.CoolDown)
+		w.blockTriggerCount++
+	}
+}
+
+// contentionProfile dumps the mutex or block profile when rate matches the rule.
+// The profile is cumulative, compare it with a previous dump (pprof -base) to see the window.
+func (w *Watching) contentionProfile(rate int, c typeConfig, dumpType configureType, stats ring) bool {
+	match, reason := matchRule(stats, rate, c.TriggerMin, c.TriggerAbs, c.TriggerDiff, NotSupportTypeMaxConfig)
+	if !match {
+		// let user know why this should not dump
+		w.debugf(UniformLogFormat, "NODUMP", type2name[dumpType],
+			c.TriggerMin, c.TriggerDiff, c.TriggerAbs, NotSupportTypeMaxConfig,
+			stats.data, rate)
+		return false
+	}
+
+	w.logDumpTrigger("pprof", dumpType, c.TriggerMin, c.TriggerDiff, c.TriggerAbs,
+		NotSupportTypeMaxConfig, stats.data, rate)
+
+	var buf bytes.Buffer
+	_ = pprof.Lookup(type2name[dumpType]).WriteTo(&buf, int(w.config.GetDumpProfileType())) // nolint: errcheck
+	w.writeProfileDataToFile(buf, c, dumpType, rate, stats, "", reason)
+
+	w.reportProfile(type2name[dumpType], buf.Bytes(), reason, "")
+	return true
+}
+
+// trace start.
+func (w *Watching) traceCheckAndDump(gNum int, schedLatency int) {
+	traceConfig := w.config.GetTraceConfigs()
+	if !traceConfig.Enable {
+		return
+	}
+
+	if w.traceCoolDownTime.After(time.Now()) {
+		w.logf("[Watching] trace dump is in cooldown")
+		return
+	}
+
+	if triggered := w.traceProfile(gNum, schedLatency, traceConfig); triggered {
+		w.traceCoolDownTime = time.Now().Add(w.config.CoolDown)
+		w.traceTriggerCount++
+	}
+}
+
+// traceProfile captures the execution trace when the scheduling latency matches the rule,
+// or the goroutine number matches the rules of GroupConfigs if c.Goroutine is set.
+func (w *Watching) traceProfile(gNum int, schedLatency int, c traceConfigs) bool {
+	match, reason := matchRule(w.schedLatencyStats, schedLatency, c.TriggerMin, c.TriggerAbs, c.TriggerDiff, NotSupportTypeMaxConfig)
+	current, stats := schedLatency, w.schedLatencyStats
+	if !match && c.Goroutine {
+		gr := w.config.GetGroupConfigs()
+		if match, reason = matchRule(w.grNumStats, gNum, gr.TriggerMin, gr.TriggerAbs, gr.TriggerDiff, gr.GoroutineTriggerNumMax); match {
+			reason = "goroutine " + reason
+			current, stats = gNum, w.grNumStats
+		}
+	}
+	if !match {
+		// let user know why this should not dump
+		w.debugf(UniformLogFormat, "NODUMP", type2name[traceType],
+			c.TriggerMin, c.TriggerDiff, c.TriggerAbs, NotSupportTypeMaxConfig,
+			w.schedLatencyStats.data, schedLatency)
+		return false
+	}
+
+	w.logDumpTrigger("trace", traceType, c.TriggerMin, c.TriggerDiff, c.TriggerAbs,
+		NotSupportTypeMaxConfig, stats.data, current)
+
+	eventID := fmt.Sprintf("trace-%d", w.traceTriggerCount)
+
+	var buf bytes.Buffer
+	if err := trace.Start(&buf); err != nil {
+		w.logf("[Watching] failed to start trace: %v", err.Error())
+		return false
+	}
+	time.Sleep(c.Duration)
+	trace.Stop()
+
+	meta := w.newProfileMeta(traceType, reason, eventID, stats, current)
+	if err := w.config.store.Save(meta, buf.Bytes()); err != nil {
+		w.logf("[Watching] failed to save trace: %v", err.Error())
+	}
+
+	w.reportProfile(type2name[traceType], buf.Bytes(), reason, eventID)
+	return true
+}
@@ -0,0 +1,184 @@
+package watching
+
+import (
+	"bytes"
+	"math"
+	"runtime"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestHistogramPercentile(t *testing.T) {
+	buckets := []float64{0, 1, 2, 3, math.Inf(1)}
+	tests := []struct {
+		counts []uint64
+		p      float64
+		want   float64
+	}{
+		{[]uint64{0, 0, 0, 0}, 0.99, 0},
+		{[]uint64{100, 0, 0, 0}, 0.99, 1},
+		{[]uint64{98, 1, 1, 0}, 0.99, 2},
+		{[]uint64{90, 0, 0, 10}, 0.99, 3},
+		{[]uint64{90, 0, 0, 10}, 0.5, 1},
+	}
+	for _, tt := range tests {
+		if got := histogramPercentile(tt.counts, buckets, tt.p); got != tt.want {
+			t.Errorf("histogramPercentile(%v, %v) = %v, want %v", tt.counts, tt.p, got, tt.want)
+		}
+	}
+}
+
+func TestCollectContention(t *testing.T) {
+	defer runtime.SetMutexProfileFraction(runtime.SetMutexProfileFraction(1))
+	runtime.SetBlockProfileRate(1)
+	defer runtime.SetBlockProfileRate(0)
+
+	w := NewWatching()
+	w.config.MutexConfigs.Enable, w.config.BlockConfigs.Enable = true, true
+	if m, b, s := w.collectContention(); m != 0 || b != 0 || s != 0 {
+		t.Fatalf("first collection = %d, %d, %d, want zeros", m, b, s)
+	}
+
+	var mu sync.Mutex
+	contend := func() {
+		var wg sync.WaitGroup
+		for i := 0; i < 8; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for j := 0; j < 200; j++ {
+					mu.Lock()
+					time.Sleep(10 * time.Microsecond)
+					mu.Unlock()
+				}
+			}()
+		}
+		wg.Wait()
+	}
+	contend()
+
+	mutexRate, blockRate, schedLatency := w.collectContention()
+	if mutexRate <= 0 || blockRate <= 0 || schedLatency < 0 {
+		t.Fatalf("collection = %d, %d, %d, want positive contention", mutexRate, blockRate, schedLatency)
+	}
+
+	// the profiles of the disabled triggers are not read
+	w.config.MutexConfigs.Enable, w.config.BlockConfigs.Enable = false, false
+	contend()
+	if m, b, _ := w.collectContention(); m != 0 || b != 0 || w.contention.mutexCaptured || w.contention.blockCaptured {
+		t.Fatalf("disabled collection = %d, %d, %+v", m, b, w.contention)
+	}
+}
+
+func TestContentionProfile(t *testing.T) {
+	for _, dumpType := range []configureType{mutex, block} {
+		t.Run(type2name[dumpType], func(t *testing.T) {
+			store := &memStore{}
+			w := NewWatching(WithProfileStore(store), WithLoggerLevel(LogLevelInfo))
+			stats := newRing(3)
+			stats.push(10)
+			c := typeConfig{Enable: true, TriggerMin: 10, TriggerAbs: 500, TriggerDiff: 50}
+
+			if w.contentionProfile(12, c, dumpType, stats) {
+				t.Fatal("triggered below the diff")
+			}
+			if !w.contentionProfile(20, c, dumpType, stats) {
+				t.Fatal("not triggered above the diff")
+			}
+			if len(store.metas) != 1 || store.metas[0].Type != type2name[dumpType] || store.metas[0].Current != 20 {
+				t.Fatalf("saved metas = %+v", store.metas)
+			}
+		})
+	}
+}
+
+func TestTraceProfile(t *testing.T) {
+	c := traceConfigs{
+		typeConfig: &typeConfig{Enable: true, TriggerMin: 1000, TriggerAbs: 50000, TriggerDiff: 100},
+		Duration:   10 * time.Millisecond,
+	}
+	tests := []struct {
+		name      string
+		goroutine bool
+		latency   int
+		gNum      int
+		triggered bool
+		reason    string
+	}{
+		{"latency spike", false, 60000, 1, true, "curVal [60000] > ruleAbs [50000]"},
+		{"no spike", true, 500, 1, false, ""},
+		{"goroutine spike ignored", false, 500, 100, false, ""},
+		{"goroutine spike", true, 500, 100, true, "goroutine curVal [100] > ruleAbs [50]"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := &memStore{}
+			w := NewWatching(WithProfileStore(store), WithLoggerLevel(LogLevelInfo), WithGoroutineDump(10, 20, 50, 0))
+			w.schedLatencyStats = newRing(3)
+			w.grNumStats = newRing(3)
+			c.Goroutine = tt.goroutine
+
+			if got := w.traceProfile(tt.gNum, tt.latency, c); got != tt.triggered {
+				t.Fatalf("traceProfile = %v, want %v", got, tt.triggered)
+			}
+			if !tt.triggered {
+				if len(store.metas) != 0 {
+					t.Fatalf("saved %d traces", len(store.metas))
+				}
+				return
+			}
+			if len(store.metas) != 1 {
+				t.Fatalf("saved %d traces", len(store.metas))
+			}
+			meta := store.metas[0]
+			if meta.Type != "trace" || meta.EventID != "trace-0" || meta.Reason != tt.reason {
+				t.Fatalf("meta = %+v", meta)
+			}
+			if !bytes.HasPrefix(store.data[0], []byte("go 1.")) {
+				t.Fatalf("trace data = %q", store.data[0][:16])
+			}
+		})
+	}
+}
+
+func TestEnableMutexDumpSetsProfileFraction(t *testing.T) {
+	defer runtime.SetMutexProfileFraction(runtime.SetMutexProfileFraction(-1))
+
+	w := NewWatching(WithMutexDump(1, 2, 3, 7))
+	w.EnableMutexDump()
+	if got := runtime.SetMutexProfileFraction(-1); got != 7 || !w.config.GetMutexConfigs().Enable {
+		t.Fatalf("enabled fraction = %d", got)
+	}
+	w.DisableMutexDump()
+	if got := runtime.SetMutexProfileFraction(-1); got != 0 || w.config.GetMutexConfigs().Enable {
+		t.Fatalf("disabled fraction = %d", got)
+	}
+
+	// the fraction set by the application is kept
+	runtime.SetMutexProfileFraction(3)
+	w = NewWatching(WithMutexDump(1, 2, 3, 0))
+	w.EnableMutexDump().DisableMutexDump()
+	if got := runtime.SetMutexProfileFraction(-1); got != 3 {
+		t.Fatalf("application fraction = %d, want 3", got)
+	}
+
+	// the fraction set by the application is restored
+	w = NewWatching(WithMutexDump(1, 2, 3, 7))
+	w.EnableMutexDump().EnableMutexDump()
+	if got := runtime.SetMutexProfileFraction(-1); got != 7 {
+		t.Fatalf("enabled fraction = %d, want 7", got)
+	}
+	w.DisableMutexDump()
+	if got := runtime.SetMutexProfileFraction(-1); got != 3 {
+		t.Fatalf("restored fraction = %d, want 3", got)
+	}
+}
+
+func TestWithTraceDump(t *testing.T) {
+	w := NewWatching(WithTraceDump(1, 2, 3, true, 0))
+	c := w.config.GetTraceConfigs()
+	if c.TriggerMin != 1 || c.TriggerDiff != 2 || c.TriggerAbs != 3 || !c.Goroutine || c.Duration != defaultTraceDuration {
+		t.Fatalf("trace configs = %+v %+v", c, *c.typeConfig)
+	}
+}
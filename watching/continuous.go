@@ -0,0 +1,112 @@
+package watching
+
+import (
+	"bytes"
+	"fmt"
+	"math/rand"
+	"runtime/pprof"
+	"sync/atomic"
+	"time"
+)
+
+// EnableContinuousProfiling enables the continuous profiling.
+func (w *Watching) EnableContinuousProfiling() *Watching {
+	w.config.L.Lock()
+	w.config.ContinuousConfigs.Enable = true
+	w.config.L.Unlock()
+	return w
+}
+
+// DisableContinuousProfiling disables the continuous profiling.
+func (w *Watching) DisableContinuousProfiling() *Watching {
+	w.config.L.Lock()
+	w.config.ContinuousConfigs.Enable = false
+	w.config.L.Unlock()
+	return w
+}
+
+// nextDelay returns the interval with a random jitter in [0, Jitter).
+func (c continuousConfigs) nextDelay() time.Duration {
+	d := c.Interval
+	if c.Jitter > 0 {
+		d += time.Duration(rand.Int63n(int64(c.Jitter))) // nolint: gosec
+	}
+	return d
+}
+
+// baselineEventID returns the event id of the latest continuous profiling round,
+// anomaly dumps record it to be diffed against.
+func (w *Watching) baselineEventID() string {
+	id, _ := w.baseline.Load().(string)
+	return id
+}
+
+// startContinuousLoop runs a continuous profiling round every interval until stopCh is closed.
+// The config is read every round, so it can be enabled or disabled on the fly.
+func (w *Watching) startContinuousLoop(stopCh chan struct{}) {
+	for {
+		timer := time.NewTimer(w.config.GetContinuousConfigs().nextDelay())
+		select {
+		case <-stopCh:
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+
+		c := w.config.GetContinuousConfigs()
+		if !c.Enable {
+			continue
+		}
+		w.continuousProfile(c, stopCh)
+	}
+}
+
+// continuousProfile samples cpu and snapshots the profiles in binary format,
+// then ships them through the reporter sharing the same event id.
+func (w *Watching) continuousProfile(c continuousConfigs, stopCh chan struct{}) {
+	if conf := w.config.GetReporterConfigs(); conf.active == 0 {
+		w.debugf("[Watching] continuous profiling skipped, profile reporter is disabled")
+		return
+	}
+	// the latest cpu usage collected by the dump loop
+	if err := w.EnableDump(int(atomic.LoadInt64(&w.lastCPU))); err != nil {
+		w.logf("[Watching] continuous profiling skipped: %v", err)
+		return
+	}
+
+	w.continuousCount++
+	eventID := fmt.Sprintf("cont-%d", w.continuousCount)
+
+	if c.CPUDuration > 0 {
+		var buf bytes.Buffer
+		if err := pprof.StartCPUProfile(&buf); err != nil {
+			w.logf("[Watching] continuous profiling failed to profile cpu: %v", err)
+		} else {
+			timer := time.NewTimer(c.CPUDuration)
+			select {
+			case <-stopCh:
+				timer.Stop()
+				pprof.StopCPUProfile()
+				return
+			case <-timer.C:
+			}
+			pprof.StopCPUProfile()
+			w.reportProfile(type2name[cpu], buf.Bytes(), continuousReason, eventID)
+		}
+	}
+
+	for _, name := range c.Profiles {
+		p := pprof.Lookup(name)
+		if p == nil {
+			w.logf("[Watching] continuous profiling unknown profile: %v", name)
+			continue
+		}
+		var buf bytes.Buffer
+		// always binary, the baseline is diffed against anomaly dumps
+		_ = p.WriteTo(&buf, int(binaryDump)) // nolint: errcheck
+		w.reportProfile(name, buf.Bytes(), continuousReason, eventID)
+	}
+
+	w.baseline.Store(eventID)
+	w.debugf("[Watching] continuous profiling %v finished", eventID)
+}
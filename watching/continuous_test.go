@@ -0,0 +1,152 @@
+package watching
+
+import (
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+type nopReporter struct{}
+
+func (nopReporter) Report(string, []byte, string, string) error { return nil }
+
+func newContinuousWatching() *Watching {
+	w := NewWatching(WithLoggerLevel(LogLevelInfo), WithProfileReporter(nopReporter{}))
+	w.stopped = 0
+	w.rptEventsCh = make(chan rptEvent, 32)
+	return w
+}
+
+func drainEvents(ch chan rptEvent) []rptEvent {
+	var events []rptEvent
+	for {
+		select {
+		case e := <-ch:
+			events = append(events, e)
+		default:
+			return events
+		}
+	}
+}
+
+func TestContinuousProfileShipsThroughReporter(t *testing.T) {
+	w := newContinuousWatching()
+	WithContinuousProfiling(time.Minute, 10*time.Millisecond, "heap", "goroutine", "unknown")(w)
+
+	w.continuousProfile(w.config.GetContinuousConfigs(), make(chan struct{}))
+
+	events := drainEvents(w.rptEventsCh)
+	var types []string
+	for _, e := range events {
+		types = append(types, e.PType)
+		if e.Reason != continuousReason || e.EventID != "cont-1" || len(e.Buf) == 0 {
+			t.Fatalf("event %s = reason %q, event id %q, %d bytes", e.PType, e.Reason, e.EventID, len(e.Buf))
+		}
+	}
+	if len(types) != 3 || types[0] != "cpu" || types[1] != "heap" || types[2] != "goroutine" {
+		t.Fatalf("shipped profiles = %v, want [cpu heap goroutine]", types)
+	}
+
+	if got := w.baselineEventID(); got != "cont-1" {
+		t.Fatalf("baseline = %q, want cont-1", got)
+	}
+	if got := w.newProfileMeta(mem, "", "", newRing(1), 0).Baseline; got != "cont-1" {
+		t.Fatalf("anomaly dump baseline = %q, want cont-1", got)
+	}
+}
+
+func TestContinuousProfileBinary(t *testing.T) {
+	w := newContinuousWatching()
+	WithTextDump()(w)
+	WithContinuousProfiling(time.Minute, 0, "heap")(w)
+
+	w.continuousProfile(w.config.GetContinuousConfigs(), make(chan struct{}))
+
+	events := drainEvents(w.rptEventsCh)
+	if len(events) != 1 {
+		t.Fatalf("shipped %d profiles, want 1", len(events))
+	}
+	// binary profiles are gzip compressed protobuf
+	if buf := events[0].Buf; len(buf) < 2 || buf[0] != 0x1f || buf[1] != 0x8b {
+		t.Fatal("heap profile is not in binary format")
+	}
+}
+
+func TestContinuousProfileSkipped(t *testing.T) {
+	tests := []struct {
+		name  string
+		setup func(w *Watching)
+	}{
+		{"cpu guard", func(w *Watching) {
+			WithCPUMax(50)(w)
+			atomic.StoreInt64(&w.lastCPU, 80)
+		}},
+		{"reporter disabled", func(w *Watching) {
+			w.DisableProfileReporter()
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := newContinuousWatching()
+			WithContinuousProfiling(time.Minute, time.Millisecond, "heap")(w)
+			tt.setup(w)
+
+			w.continuousProfile(w.config.GetContinuousConfigs(), make(chan struct{}))
+			if events := drainEvents(w.rptEventsCh); len(events) != 0 {
+				t.Fatalf("shipped %d profiles, want none", len(events))
+			}
+			if got := w.baselineEventID(); got != "" {
+				t.Fatalf("baseline = %q, want empty", got)
+			}
+		})
+	}
+}
+
+func TestContinuousNextDelay(t *testing.T) {
+	c := continuousConfigs{Interval: time.Second}
+	if got := c.nextDelay(); got != time.Second {
+		t.Fatalf("nextDelay without jitter = %v", got)
+	}
+	c.Jitter = 100 * time.Millisecond
+	for i := 0; i < 100; i++ {
+		if got := c.nextDelay(); got < time.Second || got >= time.Second+c.Jitter {
+			t.Fatalf("nextDelay = %v, want in [1s, 1.1s)", got)
+		}
+	}
+}
+
+func TestContinuousLoop(t *testing.T) {
+	w := newContinuousWatching()
+	WithContinuousProfiling(10*time.Millisecond, 0, "heap")(w)
+	WithContinuousJitter(0)(w)
+
+	stopCh := make(chan struct{})
+	done := make(chan struct{})
+	go func() {
+		w.startContinuousLoop(stopCh)
+		close(done)
+	}()
+
+	// disabled by default
+	time.Sleep(50 * time.Millisecond)
+	if events := drainEvents(w.rptEventsCh); len(events) != 0 {
+		t.Fatalf("disabled loop shipped %d profiles", len(events))
+	}
+
+	w.EnableContinuousProfiling()
+	select {
+	case e := <-w.rptEventsCh:
+		if e.PType != "heap" || e.Reason != continuousReason {
+			t.Fatalf("event = %s %s", e.PType, e.Reason)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("enabled loop shipped no profile")
+	}
+
+	close(stopCh)
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("loop did not stop")
+	}
+}
@@ -0,0 +1,167 @@
+package watching
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"runtime/pprof"
+	"runtime/trace"
+	"sync/atomic"
+	"time"
+)
+
+const manualReason = "manual"
+
+var (
+	// ErrUnknownDumpType the dump type is not one of type2name.
+	ErrUnknownDumpType = errors.New("watching: unknown dump type")
+
+	// ErrDumpRejected the dump is rejected since the cpu usage is greater than CPUMaxPercent.
+	ErrDumpRejected = errors.New("watching: dump rejected")
+
+	// ErrInvalidDumpDuration the duration of the dump is greater than WithMaxDumpDuration.
+	ErrInvalidDumpDuration = errors.New("watching: invalid dump duration")
+)
+
+// type2profile is the runtime/pprof profile of the dump types.
+var type2profile = map[configureType]string{
+	mem:       "heap",
+	thread:    "threadcreate",
+	goroutine: "goroutine",
+	gcHeap:    "heap",
+	mutex:     "mutex",
+	block:     "block",
+}
+
+// parseDumpType returns the dump type named name.
+func parseDumpType(name string) (configureType, bool) {
+	for t, n := range type2name {
+		if n == name {
+			return t, true
+		}
+	}
+	return 0, false
+}
+
+// triggerConfig returns the trigger config of the dump type, must be called with c.L held.
+func (c *configs) triggerConfig(t configureType) *typeConfig {
+	switch t {
+	case mem:
+		return c.MemConfigs
+	case cpu:
+		return c.CpuConfigs
+	case thread:
+		return c.ThreadConfigs
+	case goroutine:
+		return c.GroupConfigs.typeConfig
+	case gcHeap:
+		return c.GCHeapConfigs
+	case mutex:
+		return c.MutexConfigs.typeConfig
+	case block:
+		return c.BlockConfigs.typeConfig
+	case traceType:
+		return c.TraceConfigs.typeConfig
+	}
+	return nil
+}
+
+// setTriggerEnabled enables or disables the trigger of the dump type.
+func (w *Watching) setTriggerEnabled(t configureType, enabled bool) {
+	switch {
+	case t == mutex && enabled:
+		w.EnableMutexDump()
+	case t == mutex:
+		w.DisableMutexDump()
+	case t == block && enabled:
+		w.EnableBlockDump()
+	case t == block:
+		w.DisableBlockDump()
+	default:
+		w.config.L.Lock()
+		w.config.triggerConfig(t).Enable = enabled
+		w.config.L.Unlock()
+	}
+}
+
+// Dump takes an on-demand dump of dumpType, saves it to the ProfileStore and
+// ships it through the ProfileReporter, no matter whether the trigger is enabled.
+// cpu and trace are sampled for d, <= 0 uses the duration of their triggers,
+// and d greater than WithMaxDumpDuration returns ErrInvalidDumpDuration.
+// The dump is always binary.
+func (w *Watching) Dump(dumpType string, d time.Duration) (ProfileMeta, error) {
+	return w.DumpContext(context.Background(), dumpType, d)
+}
+
+// DumpContext is Dump, the sampling of cpu and trace stops when ctx is done,
+// then the dump is dropped and ctx.Err() is returned.
+func (w *Watching) DumpContext(ctx context.Context, dumpType string, d time.Duration) (ProfileMeta, error) {
+	t, ok := parseDumpType(dumpType)
+	if !ok {
+		return ProfileMeta{}, fmt.Errorf("%w: %v", ErrUnknownDumpType, dumpType)
+	}
+	w.config.L.RLock()
+	cpuSamplingTime, traceDuration, maxDuration := w.config.cpuSamplingTime, w.config.TraceConfigs.Duration, w.config.maxDumpDuration
+	w.config.L.RUnlock()
+	if maxDuration > 0 && d > maxDuration {
+		return ProfileMeta{}, fmt.Errorf("%w: %v is greater than %v", ErrInvalidDumpDuration, d, maxDuration)
+	}
+	if err := w.EnableDump(int(atomic.LoadInt64(&w.lastCPU))); err != nil {
+		return ProfileMeta{}, fmt.Errorf("%w: %v", ErrDumpRejected, err)
+	}
+
+	var buf bytes.Buffer
+	switch t {
+	case cpu:
+		if d <= 0 {
+			d = cpuSamplingTime
+		}
+		if err := pprof.StartCPUProfile(&buf); err != nil {
+			return ProfileMeta{}, err
+		}
+		err := sleepContext(ctx, d)
+		pprof.StopCPUProfile()
+		if err != nil {
+			return ProfileMeta{}, err
+		}
+	case traceType:
+		if d <= 0 {
+			d = traceDuration
+		}
+		if err := trace.Start(&buf); err != nil {
+			return ProfileMeta{}, err
+		}
+		err := sleepContext(ctx, d)
+		trace.Stop()
+		if err != nil {
+			return ProfileMeta{}, err
+		}
+	default:
+		if err := pprof.Lookup(type2profile[t]).WriteTo(&buf, int(binaryDump)); err != nil {
+			return ProfileMeta{}, err
+		}
+	}
+
+	eventID := fmt.Sprintf("manual-%d", atomic.AddInt64(&w.manualDumpCount, 1))
+	meta := w.newProfileMeta(t, manualReason, eventID, ring{}, 0)
+	w.logf("[Watching] %v dump %v on demand", type2name[t], eventID)
+	if err := w.config.store.Save(meta, buf.Bytes()); err != nil {
+		return meta, err
+	}
+
+	w.reportProfile(type2name[t], buf.Bytes(), manualReason, eventID)
+	return meta, nil
+}
+
+// sleepContext sleeps for d, returns ctx.Err() if ctx is done first.
+func sleepContext(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,269 @@
+package watching
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"path"
+	"strconv"
+	"sync/atomic"
+	"time"
+
+	"github.com/songzhibin97/gkit/options"
+)
+
+// triggerState is the state of a trigger owned by the dump loops,
+// they publish it after every collection, so the handler never races with them.
+type triggerState struct {
+	Stats         []int     `json:"stats"`
+	CoolDownUntil time.Time `json:"cooldown_until"`
+	TriggerCount  int       `json:"trigger_count"`
+}
+
+type loopState struct {
+	collectCount int
+	triggers     map[configureType]triggerState
+}
+
+// publishDumpLoopState publishes the state of the triggers checked by startDumpLoop.
+func (w *Watching) publishDumpLoopState() {
+	w.dumpLoopState.Store(&loopState{
+		collectCount: w.collectCount,
+		triggers: map[configureType]triggerState{
+			mem:       {w.memStats.snapshot(), w.memCoolDownTime, w.memTriggerCount},
+			cpu:       {w.cpuStats.snapshot(), w.cpuCoolDownTime, w.cpuTriggerCount},
+			thread:    {w.threadStats.snapshot(), w.threadCoolDownTime, w.threadTriggerCount},
+			goroutine: {w.grNumStats.snapshot(), w.grCoolDownTime, w.grTriggerCount},
+			mutex:     {w.mutexStats.snapshot(), w.mutexCoolDownTime, w.mutexTriggerCount},
+			block:     {w.blockStats.snapshot(), w.blockCoolDownTime, w.blockTriggerCount},
+			traceType: {w.schedLatencyStats.snapshot(), w.traceCoolDownTime, w.traceTriggerCount},
+		},
+	})
+}
+
+// publishGCHeapState publishes the state of the gc heap trigger checked by gcHeapCheckLoop.
+func (w *Watching) publishGCHeapState() {
+	w.gcHeapState.Store(&triggerState{w.gcHeapStats.snapshot(), w.gcHeapCoolDownTime, w.gcHeapTriggerCount})
+}
+
+type handlerConfig struct {
+	// authorizer: returns whether the request is allowed,
+	// nil allows reading the state only
+	authorizer func(r *http.Request) bool
+}
+
+// WithHandlerAuthorizer set the authorizer of the debug handler.
+func WithHandlerAuthorizer(authorizer func(r *http.Request) bool) options.Option {
+	return func(o interface{}) {
+		o.(*handlerConfig).authorizer = authorizer
+	}
+}
+
+// WithHandlerToken authorizes the requests carrying "Authorization: Bearer {token}".
+func WithHandlerToken(token string) options.Option {
+	return WithHandlerAuthorizer(func(r *http.Request) bool {
+		expected := []byte("Bearer " + token)
+		return subtle.ConstantTimeCompare([]byte(r.Header.Get("Authorization")), expected) == 1
+	})
+}
+
+type handler struct {
+	w    *Watching
+	conf *handlerConfig
+}
+
+// NewHandler returns the debug handler of w, mount it with http.StripPrefix or at any path:
+//
+//	GET  {path}                           the stats rings, thresholds and cooldowns of every trigger
+//	POST {path}/dump?type=cpu&seconds=10  take an on-demand dump, see Watching.DumpContext,
+//	                                      seconds is at most WithMaxDumpDuration
+//	POST {path}/config?type=mem           hot-update the trigger with a json body,
+//	                                      eg. {"enable": true, "min": 10, "diff": 25, "abs": 80}, max is for goroutine only
+//
+// Without an authorizer the handler is read-only, with an authorizer every request is authorized.
+func NewHandler(w *Watching, opts ...options.Option) http.Handler {
+	conf := &handlerConfig{}
+	for _, opt := range opts {
+		opt(conf)
+	}
+	return &handler{w: w, conf: conf}
+}
+
+func (h *handler) ServeHTTP(rw http.ResponseWriter, r *http.Request) {
+	readOnly := r.Method == http.MethodGet || r.Method == http.MethodHead
+	switch {
+	case h.conf.authorizer != nil && !h.conf.authorizer(r):
+		http.Error(rw, http.StatusText(http.StatusUnauthorized), http.StatusUnauthorized)
+		return
+	case h.conf.authorizer == nil && !readOnly:
+		http.Error(rw, "watching: debug handler is read-only without an authorizer", http.StatusForbidden)
+		return
+	}
+
+	switch path.Base(r.URL.Path) {
+	case "dump":
+		if r.Method != http.MethodPost {
+			http.Error(rw, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+			return
+		}
+		h.dump(rw, r)
+	case "config":
+		if r.Method != http.MethodPost && r.Method != http.MethodPut {
+			http.Error(rw, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+			return
+		}
+		h.updateConfig(rw, r)
+	default:
+		if !readOnly {
+			http.Error(rw, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+			return
+		}
+		writeJSON(rw, h.state())
+	}
+}
+
+type debugState struct {
+	Started       bool                    `json:"started"`
+	CollectCount  int                     `json:"collect_count"`
+	CPU           int                     `json:"cpu"`
+	CPUMaxPercent int                     `json:"cpu_max_percent"`
+	CoolDown      string                  `json:"cooldown"`
+	Baseline      string                  `json:"baseline,omitempty"`
+	Triggers      map[string]debugTrigger `json:"triggers"`
+}
+
+type debugTrigger struct {
+	Enable bool `json:"enable"`
+	Min    int  `json:"min"`
+	Diff   int  `json:"diff"`
+	Abs    int  `json:"abs"`
+	Max    int  `json:"max,omitempty"`
+	triggerState
+}
+
+func (h *handler) state() debugState {
+	w := h.w
+	state := debugState{
+		Started:  atomic.LoadInt64(&w.stopped) == 0,
+		CPU:      int(atomic.LoadInt64(&w.lastCPU)),
+		Baseline: w.baselineEventID(),
+		Triggers: make(map[string]debugTrigger, len(type2name)),
+	}
+
+	published := map[configureType]triggerState{}
+	if s, ok := w.dumpLoopState.Load().(*loopState); ok {
+		state.CollectCount = s.collectCount
+		for t, ts := range s.triggers {
+			published[t] = ts
+		}
+	}
+	if s, ok := w.gcHeapState.Load().(*triggerState); ok {
+		published[gcHeap] = *s
+	}
+
+	w.config.L.RLock()
+	defer w.config.L.RUnlock()
+	state.CPUMaxPercent = w.config.CPUMaxPercent
+	state.CoolDown = w.config.CoolDown.String()
+	for t, name := range type2name {
+		c := w.config.triggerConfig(t)
+		trigger := debugTrigger{
+			Enable:       c.Enable,
+			Min:          c.TriggerMin,
+			Diff:         c.TriggerDiff,
+			Abs:          c.TriggerAbs,
+			triggerState: published[t],
+		}
+		if t == goroutine {
+			trigger.Max = w.config.GroupConfigs.GoroutineTriggerNumMax
+		}
+		state.Triggers[name] = trigger
+	}
+	return state
+}
+
+func (h *handler) dump(rw http.ResponseWriter, r *http.Request) {
+	var d time.Duration
+	if s := r.URL.Query().Get("seconds"); s != "" {
+		seconds, err := strconv.ParseFloat(s, 64)
+		if err != nil || seconds <= 0 {
+			http.Error(rw, "watching: invalid seconds "+s, http.StatusBadRequest)
+			return
+		}
+		d = time.Duration(seconds * float64(time.Second))
+	}
+
+	meta, err := h.w.DumpContext(r.Context(), r.URL.Query().Get("type"), d)
+	switch {
+	case errors.Is(err, ErrUnknownDumpType), errors.Is(err, ErrInvalidDumpDuration):
+		http.Error(rw, err.Error(), http.StatusBadRequest)
+	case errors.Is(err, ErrDumpRejected):
+		http.Error(rw, err.Error(), http.StatusServiceUnavailable)
+	case err != nil:
+		http.Error(rw, err.Error(), http.StatusInternalServerError)
+	default:
+		writeJSON(rw, meta)
+	}
+}
+
+// triggerUpdate is the body of the config request, nil fields are kept.
+type triggerUpdate struct {
+	Enable *bool `json:"enable"`
+	Min    *int  `json:"min"`
+	Diff   *int  `json:"diff"`
+	Abs    *int  `json:"abs"`
+	Max    *int  `json:"max"`
+}
+
+func (h *handler) updateConfig(rw http.ResponseWriter, r *http.Request) {
+	name := r.URL.Query().Get("type")
+	t, ok := parseDumpType(name)
+	if !ok {
+		http.Error(rw, ErrUnknownDumpType.Error()+": "+name, http.StatusBadRequest)
+		return
+	}
+	var update triggerUpdate
+	if err := json.NewDecoder(http.MaxBytesReader(rw, r.Body, 1<<10)).Decode(&update); err != nil {
+		http.Error(rw, "watching: invalid config: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	for _, v := range []*int{update.Min, update.Diff, update.Abs, update.Max} {
+		if v != nil && *v < 0 {
+			http.Error(rw, "watching: invalid config: negative threshold", http.StatusBadRequest)
+			return
+		}
+	}
+	if update.Max != nil && t != goroutine {
+		http.Error(rw, "watching: invalid config: max is for goroutine only", http.StatusBadRequest)
+		return
+	}
+
+	w := h.w
+	w.config.L.Lock()
+	c := w.config.triggerConfig(t)
+	if update.Min != nil {
+		c.TriggerMin = *update.Min
+	}
+	if update.Diff != nil {
+		c.TriggerDiff = *update.Diff
+	}
+	if update.Abs != nil {
+		c.TriggerAbs = *update.Abs
+	}
+	if update.Max != nil {
+		w.config.GroupConfigs.GoroutineTriggerNumMax = *update.Max
+	}
+	w.config.L.Unlock()
+	if update.Enable != nil {
+		w.setTriggerEnabled(t, *update.Enable)
+	}
+	w.logf("[Watching] %v trigger is updated through the debug handler", name)
+
+	writeJSON(rw, h.state().Triggers[name])
+}
+
+func writeJSON(rw http.ResponseWriter, v interface{}) {
+	rw.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(rw).Encode(v) // nolint: errcheck
+}
@@ -0,0 +1,209 @@
+package watching
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"runtime"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func serveDebug(h http.Handler, method, target, token, body string) *httptest.ResponseRecorder {
+	r := httptest.NewRequest(method, target, strings.NewReader(body))
+	if token != "" {
+		r.Header.Set("Authorization", "Bearer "+token)
+	}
+	rw := httptest.NewRecorder()
+	h.ServeHTTP(rw, r)
+	return rw
+}
+
+func TestHandlerState(t *testing.T) {
+	w := NewWatching(WithLoggerLevel(LogLevelInfo), WithMemDump(11, 22, 33), WithGoroutineDump(1, 2, 3, 4))
+	w.EnableMemDump()
+	w.memStats = newRing(3)
+	for _, v := range []int{1, 2, 3, 4} {
+		w.memStats.push(v)
+	}
+	w.collectCount = 4
+	w.memTriggerCount = 2
+	w.publishDumpLoopState()
+	w.gcHeapStats = newRing(2)
+	w.gcHeapStats.push(7)
+	w.publishGCHeapState()
+
+	rw := serveDebug(NewHandler(w), http.MethodGet, "/debug/watching", "", "")
+	if rw.Code != http.StatusOK {
+		t.Fatalf("status = %d, body %s", rw.Code, rw.Body)
+	}
+	var state debugState
+	if err := json.Unmarshal(rw.Body.Bytes(), &state); err != nil {
+		t.Fatal(err)
+	}
+	if state.Started || state.CollectCount != 4 || len(state.Triggers) != len(type2name) {
+		t.Fatalf("state = %+v", state)
+	}
+	memState := state.Triggers["mem"]
+	if !memState.Enable || memState.Min != 11 || memState.Diff != 22 || memState.Abs != 33 ||
+		memState.TriggerCount != 2 || len(memState.Stats) != 3 || memState.Stats[2] != 4 {
+		t.Fatalf("mem state = %+v", memState)
+	}
+	if gr := state.Triggers["goroutine"]; gr.Max != 4 {
+		t.Fatalf("goroutine state = %+v", gr)
+	}
+	if gc := state.Triggers["gcHeap"]; len(gc.Stats) != 1 || gc.Stats[0] != 7 {
+		t.Fatalf("gcHeap state = %+v", gc)
+	}
+}
+
+func TestHandlerAuthorization(t *testing.T) {
+	w := NewWatching(WithLoggerLevel(LogLevelInfo), WithProfileStore(&memStore{}))
+
+	readOnly := NewHandler(w)
+	if rw := serveDebug(readOnly, http.MethodGet, "/", "", ""); rw.Code != http.StatusOK {
+		t.Fatalf("read-only GET status = %d", rw.Code)
+	}
+	if rw := serveDebug(readOnly, http.MethodPost, "/dump?type=mem", "", ""); rw.Code != http.StatusForbidden {
+		t.Fatalf("read-only POST status = %d", rw.Code)
+	}
+
+	h := NewHandler(w, WithHandlerToken("secret"))
+	for _, token := range []string{"", "wrong"} {
+		if rw := serveDebug(h, http.MethodGet, "/", token, ""); rw.Code != http.StatusUnauthorized {
+			t.Fatalf("token %q status = %d", token, rw.Code)
+		}
+	}
+	if rw := serveDebug(h, http.MethodGet, "/", "secret", ""); rw.Code != http.StatusOK {
+		t.Fatalf("authorized status = %d", rw.Code)
+	}
+}
+
+func TestHandlerDump(t *testing.T) {
+	store := &memStore{}
+	w := NewWatching(WithLoggerLevel(LogLevelInfo), WithProfileStore(store), WithCPUMax(50))
+	h := NewHandler(w, WithHandlerToken("secret"))
+
+	for _, typ := range []string{"mem", "goroutine", "mutex", "cpu", "trace"} {
+		rw := serveDebug(h, http.MethodPost, "/debug/watching/dump?type="+typ+"&seconds=0.01", "secret", "")
+		if rw.Code != http.StatusOK {
+			t.Fatalf("dump %s status = %d, body %s", typ, rw.Code, rw.Body)
+		}
+		var meta ProfileMeta
+		if err := json.Unmarshal(rw.Body.Bytes(), &meta); err != nil {
+			t.Fatal(err)
+		}
+		if meta.Type != typ || meta.Reason != manualReason || !strings.HasPrefix(meta.EventID, "manual-") {
+			t.Fatalf("dump %s meta = %+v", typ, meta)
+		}
+	}
+	if len(store.metas) != 5 || store.metas[4].EventID != "manual-5" {
+		t.Fatalf("saved metas = %+v", store.metas)
+	}
+	for i, data := range store.data {
+		if len(data) == 0 {
+			t.Fatalf("dump %s is empty", store.metas[i].Type)
+		}
+	}
+
+	tests := []struct {
+		target string
+		method string
+		code   int
+	}{
+		{"/dump?type=unknown", http.MethodPost, http.StatusBadRequest},
+		{"/dump?type=cpu&seconds=-1", http.MethodPost, http.StatusBadRequest},
+		{"/dump?type=trace&seconds=86400", http.MethodPost, http.StatusBadRequest},
+		{"/dump?type=mem", http.MethodGet, http.StatusMethodNotAllowed},
+	}
+	for _, tt := range tests {
+		if rw := serveDebug(h, tt.method, tt.target, "secret", ""); rw.Code != tt.code {
+			t.Fatalf("%s %s status = %d, want %d", tt.method, tt.target, rw.Code, tt.code)
+		}
+	}
+
+	atomic.StoreInt64(&w.lastCPU, 90)
+	if rw := serveDebug(h, http.MethodPost, "/dump?type=mem", "secret", ""); rw.Code != http.StatusServiceUnavailable {
+		t.Fatalf("overloaded dump status = %d", rw.Code)
+	}
+}
+
+func TestDumpContextCanceled(t *testing.T) {
+	store := &memStore{}
+	w := NewWatching(WithLoggerLevel(LogLevelInfo), WithProfileStore(store), WithMaxDumpDuration(time.Hour))
+
+	for _, typ := range []string{"cpu", "trace"} {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+		start := time.Now()
+		_, err := w.DumpContext(ctx, typ, time.Hour)
+		cancel()
+		if !errors.Is(err, context.DeadlineExceeded) {
+			t.Fatalf("dump %s err = %v", typ, err)
+		}
+		if time.Since(start) > time.Second {
+			t.Fatalf("dump %s is not canceled", typ)
+		}
+	}
+	if len(store.metas) != 0 {
+		t.Fatalf("canceled dumps are saved: %+v", store.metas)
+	}
+
+	if _, err := w.Dump("cpu", 2*time.Hour); !errors.Is(err, ErrInvalidDumpDuration) {
+		t.Fatalf("dump over the max err = %v", err)
+	}
+}
+
+func TestHandlerUpdateConfig(t *testing.T) {
+	defer runtime.SetMutexProfileFraction(runtime.SetMutexProfileFraction(-1))
+
+	w := NewWatching(WithLoggerLevel(LogLevelInfo), WithMemDump(11, 22, 33))
+	h := NewHandler(w, WithHandlerToken("secret"))
+
+	rw := serveDebug(h, http.MethodPost, "/config?type=mem", "secret", `{"enable": true, "min": 5}`)
+	if rw.Code != http.StatusOK {
+		t.Fatalf("status = %d, body %s", rw.Code, rw.Body)
+	}
+	if c := w.config.GetMemConfigs(); !c.Enable || c.TriggerMin != 5 || c.TriggerDiff != 22 || c.TriggerAbs != 33 {
+		t.Fatalf("mem configs = %+v", c)
+	}
+	var trigger debugTrigger
+	if err := json.Unmarshal(rw.Body.Bytes(), &trigger); err != nil || trigger.Min != 5 || !trigger.Enable {
+		t.Fatalf("response = %s, err %v", rw.Body, err)
+	}
+
+	if rw = serveDebug(h, http.MethodPut, "/config?type=goroutine", "secret", `{"max": 100}`); rw.Code != http.StatusOK {
+		t.Fatalf("goroutine status = %d", rw.Code)
+	}
+	if c := w.config.GetGroupConfigs(); c.GoroutineTriggerNumMax != 100 {
+		t.Fatalf("goroutine configs = %+v", c)
+	}
+
+	if rw = serveDebug(h, http.MethodPost, "/config?type=mutex", "secret", `{"enable": true}`); rw.Code != http.StatusOK {
+		t.Fatalf("mutex status = %d", rw.Code)
+	}
+	if got := runtime.SetMutexProfileFraction(-1); got != defaultMutexProfileFraction || !w.config.GetMutexConfigs().Enable {
+		t.Fatalf("mutex fraction = %d", got)
+	}
+
+	tests := []struct {
+		target string
+		body   string
+	}{
+		{"/config?type=unknown", `{}`},
+		{"/config?type=mem", `{"max": 1}`},
+		{"/config?type=mem", `{"min": -1}`},
+		{"/config?type=mem", `not json`},
+	}
+	for _, tt := range tests {
+		if rw = serveDebug(h, http.MethodPost, tt.target, "secret", tt.body); rw.Code != http.StatusBadRequest {
+			t.Fatalf("%s %s status = %d", tt.target, tt.body, rw.Code)
+		}
+	}
+	if c := w.config.GetMemConfigs(); c.TriggerMin != 5 {
+		t.Fatalf("invalid update modified mem configs: %+v", c)
+	}
+}
@@ -6,6 +6,8 @@ import (
 	"path/filepath"
 	"sync/atomic"
 	"time"
+
+	"github.com/songzhibin97/gkit/log"
 )
 
 // log write content to log file.
@@ -127,6 +129,7 @@ func (w *Watching) writeString(content string) {
 // again off a retired handle's size would rotate the new (possibly nearly
 // empty) active file and, with the second-granularity suffix, collide with a
 // same-second backup. Only the holder of the still-current handle rotates.
+// Backups beyond MaxBackups/MaxAge are pruned after the swap.
 func (w *Watching) rotate(ref *loggerRef) {
 	w.config.L.RLock()
 	stale := w.config.activeLog != ref
@@ -135,12 +138,8 @@ func (w *Watching) rotate(ref *loggerRef) {
 		return
 	}
 
-	dumpPath := w.config.DumpPath
-	suffix := time.Now().Format("20060102150405")
-	srcPath := filepath.Clean(filepath.Join(dumpPath, defaultLoggerName))
-	dstPath := srcPath + "_" + suffix + ".back"
-
-	if err := os.Rename(srcPath, dstPath); err != nil {
+	srcPath := filepath.Clean(filepath.Join(w.config.DumpPath, defaultLoggerName))
+	if _, err := log.RotateFile(srcPath, backupLoggerLayout, time.Now()); err != nil {
 		w.disableRotate()
 		//nolint
 		fmt.Println("rename err:", err, "from now on, it will be disabled split log")
@@ -156,4 +155,12 @@ func (w *Watching) rotate(ref *loggerRef) {
 	}
 
 	w.setLogger(newLogger)
+
+	w.config.L.RLock()
+	maxBackups, maxAge := w.config.logConfigs.MaxBackups, w.config.logConfigs.MaxAge
+	w.config.L.RUnlock()
+	if err = log.RemoveBackups(srcPath, backupLoggerLayout, maxBackups, maxAge); err != nil {
+		//nolint
+		fmt.Println("remove backups err:", err)
+	}
 }
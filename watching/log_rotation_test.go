@@ -158,3 +158,24 @@ func TestWriteString_ConcurrentRotationNoUseAfterClose(t *testing.T) {
 		t.Fatalf("active logger unusable after concurrent rotation: %v", err)
 	}
 }
+
+// TestRotate_PrunesBackups checks that rotation honours MaxBackups through
+// the shared log.RemoveBackups helper.
+func TestRotate_PrunesBackups(t *testing.T) {
+	dir := t.TempDir()
+	w, _ := newRotatingWatching(t, dir, defaultShardLoggerSize)
+	w.config.logConfigs.MaxBackups = 1
+
+	for i := 0; i < 3; i++ {
+		old := filepath.Join(dir, defaultLoggerName+"_2020010100000"+string(rune('0'+i))+".back")
+		if err := os.WriteFile(old, []byte("old\n"), defaultLoggerPerm); err != nil {
+			t.Fatalf("write backup: %v", err)
+		}
+	}
+	ref := w.acquireLogger()
+	defer ref.release()
+	w.rotate(ref)
+	if got := countBackups(t, dir); got != 1 {
+		t.Fatalf("backups after prune = %d, want 1", got)
+	}
+}
@@ -117,6 +117,16 @@ func withDumpProfileType(profileType dumpProfileType) options.Option {
 	}
 }
 
+// WithLoggerBackups set the retention of rotated logs,
+// keep at most maxBackups files no older than maxAge, <= 0 means unlimited.
+func WithLoggerBackups(maxBackups int, maxAge time.Duration) options.Option {
+	return func(o interface{}) {
+		opts := o.(*Watching)
+		opts.config.logConfigs.MaxBackups = maxBackups
+		opts.config.logConfigs.MaxAge = maxAge
+	}
+}
+
 // WithLoggerSplit set the split log options.
 // eg. "b/B", "k/K" "kb/Kb" "mb/Mb", "gb/Gb" "tb/Tb" "pb/Pb".
 func WithLoggerSplit(enable bool, shardLoggerSize string) options.Option {
@@ -173,6 +183,45 @@ func WithCPUDump(min int, diff int, abs int) options.Option {
 	}
 }
 
+// WithMutexDump set the mutex dump options, the trigger is in sampled contention events per second,
+// fraction is set by runtime.SetMutexProfileFraction when the dumper is enabled, <= 0 means not set.
+func WithMutexDump(min, diff, abs, fraction int) options.Option {
+	return func(o interface{}) {
+		opts := o.(*Watching)
+		opts.config.MutexConfigs.TriggerMin = min
+		opts.config.MutexConfigs.TriggerDiff = diff
+		opts.config.MutexConfigs.TriggerAbs = abs
+		opts.config.MutexConfigs.ProfileFraction = fraction
+	}
+}
+
+// WithBlockDump set the block dump options, the trigger is in sampled blocking events per second,
+// rate is set by runtime.SetBlockProfileRate when the dumper is enabled, <= 0 means not set.
+func WithBlockDump(min, diff, abs, rate int) options.Option {
+	return func(o interface{}) {
+		opts := o.(*Watching)
+		opts.config.BlockConfigs.TriggerMin = min
+		opts.config.BlockConfigs.TriggerDiff = diff
+		opts.config.BlockConfigs.TriggerAbs = abs
+		opts.config.BlockConfigs.ProfileRate = rate
+	}
+}
+
+// WithTraceDump set the execution trace options, the trigger is the p99 scheduling latency in microseconds,
+// capture the trace on goroutine spikes too when goroutine is true, duration <= 0 keeps the default 3s.
+func WithTraceDump(min, diff, abs int, goroutine bool, duration time.Duration) options.Option {
+	return func(o interface{}) {
+		opts := o.(*Watching)
+		opts.config.TraceConfigs.TriggerMin = min
+		opts.config.TraceConfigs.TriggerDiff = diff
+		opts.config.TraceConfigs.TriggerAbs = abs
+		opts.config.TraceConfigs.Goroutine = goroutine
+		if duration > 0 {
+			opts.config.TraceConfigs.Duration = duration
+		}
+	}
+}
+
 // WithGoProcAsCPUCore set Watching use cgroup or not.
 func WithGoProcAsCPUCore(enabled bool) options.Option {
 	return func(o interface{}) {
@@ -254,6 +303,65 @@ func WithShrinkThread(enable bool, threshold int, delay time.Duration) options.O
 	}
 }
 
+// WithContinuousProfiling set the continuous profiling options,
+// sample cpu for cpuDuration and snapshot the profiles every interval.
+// cpuDuration <= 0 disables cpu profiling, empty profiles keeps the default.
+func WithContinuousProfiling(interval, cpuDuration time.Duration, profiles ...string) options.Option {
+	return func(o interface{}) {
+		opts := o.(*Watching)
+		if interval > 0 {
+			opts.config.ContinuousConfigs.Interval = interval
+		}
+		opts.config.ContinuousConfigs.CPUDuration = cpuDuration
+		if len(profiles) > 0 {
+			opts.config.ContinuousConfigs.Profiles = profiles
+		}
+	}
+}
+
+// WithContinuousJitter set the max random delay added to every continuous profiling interval,
+// avoid all instances profiling at the same time.
+func WithContinuousJitter(jitter time.Duration) options.Option {
+	return func(o interface{}) {
+		opts := o.(*Watching)
+		opts.config.ContinuousConfigs.Jitter = jitter
+	}
+}
+
+// WithProfileStore set the store for binary dumps,
+// a LocalStore under DumpPath is used by default.
+func WithProfileStore(store ProfileStore) options.Option {
+	return func(o interface{}) {
+		opts := o.(*Watching)
+		if store == nil {
+			return
+		}
+		opts.config.store = store
+	}
+}
+
+// WithMaxDumpDuration set the max duration of the on-demand cpu profile or trace
+// taken by Watching.Dump and the debug handler, default 1m.
+func WithMaxDumpDuration(d time.Duration) options.Option {
+	return func(o interface{}) {
+		opts := o.(*Watching)
+		opts.config.maxDumpDuration = d
+	}
+}
+
+// WithDumpRetention set the retention of the default LocalStore,
+// keep at most maxFiles dumps, maxBytes in total, no older than maxAge, <= 0 means unlimited.
+func WithDumpRetention(maxFiles int, maxBytes int64, maxAge time.Duration) options.Option {
+	return func(o interface{}) {
+		opts := o.(*Watching)
+		opts.config.dumpRetention = Retention{
+			MaxFiles: maxFiles,
+			MaxBytes: maxBytes,
+			MaxAge:   maxAge,
+		}
+	}
+}
+
 // WithProfileReporter will enable reporter
 // reopens profile reporter through WithProfileReporter(h.opts.rptOpts.reporter)
 func WithProfileReporter(r ProfileReporter) options.Option {
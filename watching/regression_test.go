@@ -8,6 +8,7 @@ import (
 	"strings"
 	"sync"
 	"testing"
+	"time"
 )
 
 func TestTriggeredDumpLogUsesUniformFormat(t *testing.T) {
@@ -59,9 +60,9 @@ func TestTriggeredDumpLogUsesUniformFormat(t *testing.T) {
 			action:   "pprof dump",
 			previous: func(w *Watching) interface{} { return w.cpuStats.data },
 			trigger: func(w *Watching) {
-				// Fail file creation after the trigger log, avoiding the real CPU
-				// sampling sleep while still exercising cpuProfile's call site.
-				w.config.DumpPath = filepath.Join(w.config.Logger.Name(), "not-a-directory")
+				// Shorten the sampling to avoid the real CPU sampling sleep
+				// while still exercising cpuProfile's call site.
+				w.config.cpuSamplingTime = time.Millisecond
 				w.cpuProfile(currentValue, config)
 			},
 		},
@@ -128,10 +129,10 @@ func TestTriggeredDumpLogUsesUniformFormat(t *testing.T) {
 func TestWriteFilePreservesPercentText(t *testing.T) {
 	var profile bytes.Buffer
 	profile.WriteString("profile: 50% of total\nnext: %s is literal")
-	err := writeFile(profile, goroutine, &DumpConfigs{
+	err := writeFile(profile, nil, &DumpConfigs{
 		DumpProfileType: textDump,
 		DumpFullStack:   true,
-	}, "")
+	}, ProfileMeta{Type: type2name[goroutine]})
 	if err == nil {
 		t.Fatal("writeFile text mode returned nil error")
 	}
@@ -34,6 +34,13 @@ func (r *ring) push(i int) {
 	r.idx = (r.idx + 1) % r.maxLen
 }
 
+// snapshot returns a copy of the data from oldest to newest.
+func (r *ring) snapshot() []int {
+	data := make([]int, 0, len(r.data))
+	data = append(data, r.data[r.idx:]...)
+	return append(data, r.data[:r.idx]...)
+}
+
 func (r *ring) avg() int {
 	// Check if the len(r.data) is zero before dividing
 	if r.maxLen == 0 || len(r.data) == 0 {
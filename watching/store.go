@@ -0,0 +1,144 @@
+package watching
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	profileSuffix = ".bin"
+	sidecarSuffix = ".meta.json"
+)
+
+// ProfileStore persists binary dumps.
+// Save is called from the dump loops and must be safe for concurrent use.
+type ProfileStore interface {
+	Save(meta ProfileMeta, data []byte) error
+}
+
+// ProfileMeta describes a dump, stores write it as a json sidecar next to the
+// profile so dumps can be indexed and garbage-collected without parsing them.
+type ProfileMeta struct {
+	Type    string    `json:"type"`
+	EventID string    `json:"event_id,omitempty"`
+	Reason  string    `json:"reason,omitempty"`
+	Time    time.Time `json:"time"`
+	// Stats is the stats ring when the dump triggered, from oldest to newest.
+	Stats   []int `json:"stats,omitempty"`
+	Current int   `json:"current"`
+	// Baseline is the event id of the latest continuous profiling round,
+	// whose profiles were shipped through the ProfileReporter.
+	Baseline string `json:"baseline,omitempty"`
+}
+
+// profileFileName returns the file name of the profile described by meta,
+// the format is kept the same as the dumps written before ProfileStore.
+func profileFileName(meta ProfileMeta) string {
+	return meta.Type + "." + meta.EventID + "." + meta.Time.Format("20060102150405.000") + profileSuffix
+}
+
+// sidecarFileName returns the metadata file name of the profile.
+func sidecarFileName(profile string) string {
+	return strings.TrimSuffix(profile, profileSuffix) + sidecarSuffix
+}
+
+// Retention limits the dumps kept by LocalStore, <= 0 means unlimited.
+type Retention struct {
+	MaxFiles int           // MaxFiles the number of dumps to keep
+	MaxBytes int64         // MaxBytes the total size of dumps to keep
+	MaxAge   time.Duration // MaxAge the duration to keep dumps
+}
+
+// LocalStore saves dumps to a local directory, and removes the oldest dumps
+// together with their sidecars once the retention is exceeded.
+// The newest dump is always kept.
+type LocalStore struct {
+	dir       string
+	retention Retention
+
+	mu sync.Mutex
+}
+
+// NewLocalStore returns a LocalStore saving dumps under dir.
+func NewLocalStore(dir string, retention Retention) *LocalStore {
+	return &LocalStore{dir: dir, retention: retention}
+}
+
+// Save writes the profile and its sidecar, then applies the retention.
+func (s *LocalStore) Save(meta ProfileMeta, data []byte) error {
+	sidecar, err := json.Marshal(meta)
+	if err != nil {
+		return fmt.Errorf("[Watching] encode %v profile meta failed : %w", meta.Type, err)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err = os.MkdirAll(s.dir, 0o755); err != nil {
+		return fmt.Errorf("[Watching] pprof %v write to file failed : %w", meta.Type, err)
+	}
+	name := profileFileName(meta)
+	if err = os.WriteFile(filepath.Join(s.dir, name), data, defaultLoggerPerm); err != nil {
+		return fmt.Errorf("[Watching] pprof %v write to file failed : %w", meta.Type, err)
+	}
+	if err = os.WriteFile(filepath.Join(s.dir, sidecarFileName(name)), sidecar, defaultLoggerPerm); err != nil {
+		return fmt.Errorf("[Watching] pprof %v write meta to file failed : %w", meta.Type, err)
+	}
+	return s.prune(time.Now())
+}
+
+// prune removes the dumps exceeding the retention.
+func (s *LocalStore) prune(now time.Time) error {
+	r := s.retention
+	if r.MaxFiles <= 0 && r.MaxBytes <= 0 && r.MaxAge <= 0 {
+		return nil
+	}
+
+	entries, err := os.ReadDir(s.dir)
+	if err != nil {
+		return err
+	}
+	profiles := make([]os.FileInfo, 0, len(entries))
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasSuffix(entry.Name(), profileSuffix) {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil {
+			// removed concurrently
+			continue
+		}
+		profiles = append(profiles, info)
+	}
+	// newest first
+	sort.Slice(profiles, func(i, j int) bool {
+		if !profiles[i].ModTime().Equal(profiles[j].ModTime()) {
+			return profiles[i].ModTime().After(profiles[j].ModTime())
+		}
+		return profiles[i].Name() > profiles[j].Name()
+	})
+
+	var total int64
+	for i, info := range profiles {
+		total += info.Size()
+		if i == 0 {
+			continue
+		}
+		if (r.MaxFiles > 0 && i >= r.MaxFiles) ||
+			(r.MaxBytes > 0 && total > r.MaxBytes) ||
+			(r.MaxAge > 0 && now.Sub(info.ModTime()) > r.MaxAge) {
+			for _, name := range []string{info.Name(), sidecarFileName(info.Name())} {
+				if err := os.Remove(filepath.Join(s.dir, name)); err != nil && !os.IsNotExist(err) {
+					return err
+				}
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,209 @@
+package watching
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	defaultS3Region  = "us-east-1"
+	s3SignAlgorithm  = "AWS4-HMAC-SHA256"
+	s3AmzDateLayout  = "20060102T150405Z"
+	s3ErrorBodyLimit = 1 << 10
+)
+
+// S3Config contains configuration about S3Store.
+type S3Config struct {
+	// Endpoint of the S3 compatible service,
+	// eg. "https://s3.us-east-1.amazonaws.com", "http://127.0.0.1:9000".
+	Endpoint string
+	// Region used to sign the requests, default us-east-1
+	Region string
+	Bucket string
+	// Prefix of the object keys, eg. "watching/order-service/"
+	Prefix string
+
+	AccessKeyID     string
+	SecretAccessKey string
+	// SessionToken of the temporary credentials, optional
+	SessionToken string
+
+	// Timeout bounds every upload, default 30s
+	Timeout time.Duration
+}
+
+// S3Store uploads dumps and their sidecars to an S3 compatible object storage,
+// the objects are addressed in path style: {Endpoint}/{Bucket}/{Prefix}{name}.
+// Garbage collection is left to the bucket lifecycle rules.
+type S3Store struct {
+	conf   S3Config
+	client *http.Client
+
+	// now is replaced in tests
+	now func() time.Time
+}
+
+// NewS3Store returns a S3Store.
+func NewS3Store(conf S3Config) *S3Store {
+	if conf.Region == "" {
+		conf.Region = defaultS3Region
+	}
+	if conf.Timeout <= 0 {
+		conf.Timeout = defaultReporterTimeout
+	}
+	conf.Endpoint = strings.TrimRight(conf.Endpoint, "/")
+	return &S3Store{
+		conf:   conf,
+		client: &http.Client{Timeout: conf.Timeout},
+		now:    time.Now,
+	}
+}
+
+// Save uploads the profile, then its sidecar.
+func (s *S3Store) Save(meta ProfileMeta, data []byte) error {
+	sidecar, err := json.Marshal(meta)
+	if err != nil {
+		return fmt.Errorf("[Watching] encode %v profile meta failed : %w", meta.Type, err)
+	}
+	name := profileFileName(meta)
+	if err = s.put(name, "application/octet-stream", data); err != nil {
+		return err
+	}
+	return s.put(sidecarFileName(name), "application/json", sidecar)
+}
+
+func (s *S3Store) put(name string, contentType string, body []byte) error {
+	key := s.conf.Prefix + name
+	request, err := http.NewRequest(http.MethodPut, s.conf.Endpoint, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("[Watching] s3 put %s failed : %w", key, err)
+	}
+	request.URL.Path = "/" + s.conf.Bucket + "/" + key
+	request.URL.RawPath = s3Escape(request.URL.Path)
+	request.Header.Set("Content-Type", contentType)
+	signV4(request, body, s.conf, s.now())
+
+	response, err := s.client.Do(request)
+	if err != nil {
+		return fmt.Errorf("[Watching] s3 put %s failed : %w", key, err)
+	}
+	defer response.Body.Close() // nolint: errcheck
+
+	if response.StatusCode < 200 || response.StatusCode > 299 {
+		msg, _ := io.ReadAll(io.LimitReader(response.Body, s3ErrorBodyLimit))
+		return fmt.Errorf("[Watching] s3 put %s failed : %s %s", key, response.Status, bytes.TrimSpace(msg))
+	}
+	_, _ = io.Copy(io.Discard, response.Body)
+	return nil
+}
+
+// signV4 signs the request with AWS Signature Version 4,
+// every header already set on the request is signed together with host.
+func signV4(request *http.Request, payload []byte, conf S3Config, t time.Time) {
+	amzDate := t.UTC().Format(s3AmzDateLayout)
+	date := amzDate[:8]
+	payloadHash := sha256Hex(payload)
+
+	request.Header.Set("X-Amz-Date", amzDate)
+	request.Header.Set("X-Amz-Content-Sha256", payloadHash)
+	if conf.SessionToken != "" {
+		request.Header.Set("X-Amz-Security-Token", conf.SessionToken)
+	}
+
+	headers := map[string]string{"host": request.URL.Host}
+	for k, v := range request.Header {
+		headers[strings.ToLower(k)] = strings.TrimSpace(strings.Join(v, ","))
+	}
+	names := make([]string, 0, len(headers))
+	for k := range headers {
+		names = append(names, k)
+	}
+	sort.Strings(names)
+	var canonicalHeaders strings.Builder
+	for _, k := range names {
+		canonicalHeaders.WriteString(k + ":" + headers[k] + "\n")
+	}
+	signedHeaders := strings.Join(names, ";")
+
+	canonicalRequest := strings.Join([]string{
+		request.Method,
+		s3Escape(request.URL.Path),
+		canonicalQuery(request.URL.Query()),
+		canonicalHeaders.String(),
+		signedHeaders,
+		payloadHash,
+	}, "\n")
+
+	scope := date + "/" + conf.Region + "/s3/aws4_request"
+	stringToSign := strings.Join([]string{
+		s3SignAlgorithm,
+		amzDate,
+		scope,
+		sha256Hex([]byte(canonicalRequest)),
+	}, "\n")
+
+	key := hmacSHA256([]byte("AWS4"+conf.SecretAccessKey), date)
+	key = hmacSHA256(key, conf.Region)
+	key = hmacSHA256(key, "s3")
+	key = hmacSHA256(key, "aws4_request")
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+
+	request.Header.Set("Authorization", fmt.Sprintf("%s Credential=%s/%s,SignedHeaders=%s,Signature=%s",
+		s3SignAlgorithm, conf.AccessKeyID, scope, signedHeaders, signature))
+}
+
+func canonicalQuery(values url.Values) string {
+	pairs := make([]string, 0, len(values))
+	for k, vs := range values {
+		for _, v := range vs {
+			pairs = append(pairs, s3EscapeComponent(k)+"="+s3EscapeComponent(v))
+		}
+	}
+	sort.Strings(pairs)
+	return strings.Join(pairs, "&")
+}
+
+// s3Escape escapes the path as S3 expects, every byte except the unreserved
+// characters and '/' is percent-encoded.
+func s3Escape(path string) string {
+	var b strings.Builder
+	for i := 0; i < len(path); i++ {
+		c := path[i]
+		if c == '/' || isUnreserved(c) {
+			b.WriteByte(c)
+			continue
+		}
+		fmt.Fprintf(&b, "%%%02X", c)
+	}
+	return b.String()
+}
+
+func s3EscapeComponent(s string) string {
+	return strings.ReplaceAll(s3Escape(s), "/", "%2F")
+}
+
+func isUnreserved(c byte) bool {
+	return 'a' <= c && c <= 'z' || 'A' <= c && c <= 'Z' || '0' <= c && c <= '9' ||
+		c == '-' || c == '_' || c == '.' || c == '~'
+}
+
+func sha256Hex(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	h := hmac.New(sha256.New, key)
+	h.Write([]byte(data)) // nolint: errcheck
+	return h.Sum(nil)
+}
@@ -7,7 +7,6 @@ import (
 	"io/ioutil"
 	"math"
 	"os"
-	"path"
 	"runtime"
 	"runtime/pprof"
 	"strconv"
@@ -165,29 +164,9 @@ func matchRule(history ring, curVal, ruleMin, ruleAbs, ruleDiff, ruleMax int) (b
 	return false, ""
 }
 
-func getBinaryFileName(filePath string, dumpType configureType, eventID string) string {
-	binarySuffix := time.Now().Format("20060102150405.000") + ".bin"
-
-	return path.Join(filePath, type2name[dumpType]+"."+eventID+"."+binarySuffix)
-}
-
-// getBinaryFileNameAndCreate 获取文件路径并创建
-func getBinaryFileNameAndCreate(dump string, dumpType configureType, eventID string) (*os.File, string, error) {
-	filepath := getBinaryFileName(dump, dumpType, eventID)
-	f, err := os.OpenFile(filepath, defaultLoggerFlags, defaultLoggerPerm)
-	if err != nil && os.IsNotExist(err) {
-		if err = os.MkdirAll(dump, 0o755); err != nil {
-			return nil, filepath, err
-		}
-		f, err = os.OpenFile(filepath, defaultLoggerFlags, defaultLoggerPerm)
-		if err != nil {
-			return nil, filepath, err
-		}
-	}
-	return f, filepath, err
-}
-
-func writeFile(data bytes.Buffer, dumpType configureType, dumpConfigs *DumpConfigs, eventID string) error {
+// writeFile writes the text profile to log by returning it as error,
+// or saves the binary profile to store.
+func writeFile(data bytes.Buffer, store ProfileStore, dumpConfigs *DumpConfigs, meta ProfileMeta) error {
 	if dumpConfigs.DumpProfileType == textDump {
 		// write to log
 		if dumpConfigs.DumpFullStack {
@@ -197,14 +176,5 @@ func writeFile(data bytes.Buffer, dumpType configureType, dumpConfigs *DumpConfi
 		return errors.New(data.String())
 	}
 
-	bf, _, err := getBinaryFileNameAndCreate(dumpConfigs.DumpPath, dumpType, eventID) // nolint:gosec
-	if err != nil {
-		return fmt.Errorf("[Watching] pprof %v write to file failed : %w", type2name[dumpType], err)
-	}
-	defer bf.Close() //nolint:errcheck,gosec
-
-	if _, err = bf.Write(data.Bytes()); err != nil {
-		return fmt.Errorf("[Watching] pprof %v write to file failed : %w", type2name[dumpType], err)
-	}
-	return nil
+	return store.Save(meta, data.Bytes())
 }
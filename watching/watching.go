@@ -3,7 +3,6 @@ package watching
 import (
 	"bytes"
 	"fmt"
-	"io/ioutil"
 	"os"
 	"runtime"
 	"runtime/pprof"
@@ -26,7 +25,17 @@ type Watching struct {
 	memTriggerCount          int
 	grTriggerCount           int
 	gcHeapTriggerCount       int
+	mutexTriggerCount        int
+	blockTriggerCount        int
+	traceTriggerCount        int
 	shrinkThreadTriggerCount int
+	continuousCount          int
+	manualDumpCount          int64 // atomic
+
+	// the latest cpu usage collected by the dump loop, atomic
+	lastCPU int64
+	// event id of the latest continuous profiling round
+	baseline atomic.Value
 
 	// cooldown
 	threadCoolDownTime    time.Time
@@ -35,6 +44,9 @@ type Watching struct {
 	gcHeapCoolDownTime    time.Time
 	grCoolDownTime        time.Time
 	shrinkThrCoolDownTime time.Time
+	mutexCoolDownTime     time.Time
+	blockCoolDownTime     time.Time
+	traceCoolDownTime     time.Time
 
 	// GC heap triggered, need to dump next time.
 	gcHeapTriggered bool
@@ -45,6 +57,17 @@ type Watching struct {
 	grNumStats  ring
 	threadStats ring
 	gcHeapStats ring
+	mutexStats  ring
+	blockStats  ring
+	// p99 scheduling latency in microseconds
+	schedLatencyStats ring
+
+	// cumulative contention stats of the previous collection
+	contention contentionStats
+
+	// states published by the dump loops for the debug handler
+	dumpLoopState atomic.Value // *loopState
+	gcHeapState   atomic.Value // *triggerState
 
 	// switch
 	stopped int64
@@ -55,6 +78,8 @@ type Watching struct {
 	gcEventsCh chan struct{}
 	// profiler reporter channels
 	rptEventsCh chan rptEvent
+	// closed to stop the continuous profiling loop
+	continuousStopCh chan struct{}
 }
 
 // rptEvent stands of the args of report event
@@ -188,12 +213,15 @@ func (w *Watching) Start() {
 
 	gcEventsCh := make(chan struct{}, 1)
 	rptCh := make(chan rptEvent, 32)
+	continuousStopCh := make(chan struct{})
 	w.gcEventsCh = gcEventsCh
 	w.rptEventsCh = rptCh
+	w.continuousStopCh = continuousStopCh
 
 	w.initEnvironment()
 	go w.startDumpLoop()
 	go w.startReporter(rptCh)
+	go w.startContinuousLoop(continuousStopCh)
 	w.startGCCycleLoop(gcEventsCh)
 }
 
@@ -216,6 +244,10 @@ func (w *Watching) Stop() {
 		w.rptEventsCh = nil
 		close(rptEventsCh)
 	}
+	if continuousStopCh := w.continuousStopCh; continuousStopCh != nil {
+		w.continuousStopCh = nil
+		close(continuousStopCh)
+	}
 }
 
 func (w *Watching) startDumpLoop() {
@@ -230,6 +262,12 @@ func (w *Watching) startDumpLoop() {
 	w.memStats = newRing(minCollectCyclesBeforeDumpStart)
 	w.grNumStats = newRing(minCollectCyclesBeforeDumpStart)
 	w.threadStats = newRing(minCollectCyclesBeforeDumpStart)
+	w.mutexStats = newRing(minCollectCyclesBeforeDumpStart)
+	w.blockStats = newRing(minCollectCyclesBeforeDumpStart)
+	w.schedLatencyStats = newRing(minCollectCyclesBeforeDumpStart)
+
+	// init the cumulative contention stats
+	w.collectContention()
 
 	// dump loop
 	ticker := time.NewTicker(w.config.CollectInterval)
@@ -268,12 +306,19 @@ func (w *Watching) startDumpLoop() {
 				continue
 			}
 
+			mutexRate, blockRate, schedLatency := w.collectContention()
+
+			atomic.StoreInt64(&w.lastCPU, int64(cpu))
 			w.cpuStats.push(cpu)
 			w.memStats.push(mem)
 			w.grNumStats.push(gNum)
 			w.threadStats.push(tNum)
+			w.mutexStats.push(mutexRate)
+			w.blockStats.push(blockRate)
+			w.schedLatencyStats.push(schedLatency)
 
 			w.collectCount++
+			w.publishDumpLoopState()
 
 			if w.collectCount < minCollectCyclesBeforeDumpStart {
 				// at least collect some cycles
@@ -291,6 +336,10 @@ func (w *Watching) startDumpLoop() {
 			w.cpuCheckAndDump(cpu)
 			w.threadCheckAndDump(tNum)
 			w.threadCheckAndShrink(tNum)
+			w.mutexCheckAndDump(mutexRate)
+			w.blockCheckAndDump(blockRate)
+			w.traceCheckAndDump(gNum, schedLatency)
+			w.publishDumpLoopState()
 		}
 	}
 }
@@ -351,8 +400,8 @@ func (w *Watching) goroutineProfile(gNum int, c groupConfigs) bool {
 		c.GoroutineTriggerNumMax, w.grNumStats.data, gNum)
 
 	var buf bytes.Buffer
-	_ = pprof.Lookup("goroutine").WriteTo(&buf, int(w.config.DumpProfileType)) // nolint: errcheck
-	w.writeGrProfileDataToFile(buf, c, goroutine, gNum)
+	_ = pprof.Lookup("goroutine").WriteTo(&buf, int(w.config.GetDumpProfileType())) // nolint: errcheck
+	w.writeGrProfileDataToFile(buf, c, goroutine, gNum, reason)
 
 	w.reportProfile(type2name[goroutine], buf.Bytes(), reason, "")
 	return true
@@ -392,8 +441,8 @@ func (w *Watching) memProfile(rss int, c typeConfig) bool {
 		NotSupportTypeMaxConfig, w.memStats.data, rss)
 
 	var buf bytes.Buffer
-	_ = pprof.Lookup("heap").WriteTo(&buf, int(w.config.DumpProfileType)) // nolint: errcheck
-	w.writeProfileDataToFile(buf, c, mem, rss, w.memStats, "")
+	_ = pprof.Lookup("heap").WriteTo(&buf, int(w.config.GetDumpProfileType())) // nolint: errcheck
+	w.writeProfileDataToFile(buf, c, mem, rss, w.memStats, "", reason)
 
 	w.reportProfile(type2name[mem], buf.Bytes(), reason, "")
 	return true
@@ -495,14 +544,14 @@ func (w *Watching) threadProfile(curThreadNum int, c typeConfig) bool {
 	eventID := fmt.Sprintf("thr-%d", w.threadTriggerCount)
 
 	var buf bytes.Buffer
-	_ = pprof.Lookup("threadcreate").WriteTo(&buf, int(w.config.DumpProfileType)) // nolint: errcheck
-	w.writeProfileDataToFile(buf, c, thread, curThreadNum, w.threadStats, eventID)
+	_ = pprof.Lookup("threadcreate").WriteTo(&buf, int(w.config.GetDumpProfileType())) // nolint: errcheck
+	w.writeProfileDataToFile(buf, c, thread, curThreadNum, w.threadStats, eventID, reason)
 
 	w.reportProfile(type2name[thread], buf.Bytes(), reason, eventID)
 
 	buf.Reset()
-	_ = pprof.Lookup("goroutine").WriteTo(&buf, int(w.config.DumpProfileType)) // nolint: errcheck
-	w.writeProfileDataToFile(buf, c, goroutine, curThreadNum, w.threadStats, eventID)
+	_ = pprof.Lookup("goroutine").WriteTo(&buf, int(w.config.GetDumpProfileType())) // nolint: errcheck
+	w.writeProfileDataToFile(buf, c, goroutine, curThreadNum, w.threadStats, eventID, reason)
 
 	w.reportProfile("goroutine", buf.Bytes(), reason, eventID)
 	return true
@@ -569,34 +618,25 @@ func (w *Watching) cpuProfile(curCPUUsage int, c typeConfig) bool {
 	w.logDumpTrigger("pprof dump", cpu, c.TriggerMin, c.TriggerDiff, c.TriggerAbs,
 		NotSupportTypeMaxConfig, w.cpuStats.data, curCPUUsage)
 
-	bf, binFileName, err := getBinaryFileNameAndCreate(w.config.DumpPath, cpu, "")
-	if err != nil {
-		w.logf("[Watching] failed to create cpu profile file: %v", err.Error())
-		return false
-	}
-	defer bf.Close()
-
-	err = pprof.StartCPUProfile(bf)
-	if err != nil {
+	var buf bytes.Buffer
+	if err := pprof.StartCPUProfile(&buf); err != nil {
 		w.logf("[Watching] failed to profile cpu: %v", err.Error())
 		return false
 	}
 
-	time.Sleep(defaultCPUSamplingTime)
+	time.Sleep(w.config.cpuSamplingTime)
 	pprof.StopCPUProfile()
 
-	w.logf(UniformLogFormat, "pprof dump to log dir", type2name[cpu],
-		c.TriggerMin, c.TriggerDiff, c.TriggerAbs, NotSupportTypeMaxConfig,
-		w.cpuStats.data, curCPUUsage)
-
-	if conf := w.config.GetReporterConfigs(); conf.active == 1 {
-		bfCpy, err := ioutil.ReadFile(binFileName)
-		if err != nil {
-			w.logf("fail to build copy of bf, err %v", err)
-			return true
-		}
-		w.reportProfile(type2name[cpu], bfCpy, reason, "")
+	meta := w.newProfileMeta(cpu, reason, "", w.cpuStats, curCPUUsage)
+	if err := w.config.store.Save(meta, buf.Bytes()); err != nil {
+		w.logf("[Watching] failed to save cpu profile: %v", err.Error())
+	} else {
+		w.logf(UniformLogFormat, "pprof dump to log dir", type2name[cpu],
+			c.TriggerMin, c.TriggerDiff, c.TriggerAbs, NotSupportTypeMaxConfig,
+			w.cpuStats.data, curCPUUsage)
 	}
+
+	w.reportProfile(type2name[cpu], buf.Bytes(), reason, "")
 	return true
 }
 
@@ -629,6 +669,7 @@ func (w *Watching) gcHeapCheckAndDump() {
 
 	ratio := int(100 * float64(prevGC) / float64(memoryLimit))
 	w.gcHeapStats.push(ratio)
+	defer w.publishGCHeapState()
 
 	w.gcCycleCount++
 	if w.gcCycleCount < minCollectCyclesBeforeDumpStart {
@@ -692,8 +733,8 @@ func (w *Watching) gcHeapProfile(gc int, force bool, c typeConfig) bool {
 	eventID := fmt.Sprintf("heap-%d", w.grTriggerCount)
 
 	var buf bytes.Buffer
-	_ = pprof.Lookup("heap").WriteTo(&buf, int(w.config.DumpProfileType)) // nolint: errcheck
-	w.writeProfileDataToFile(buf, c, gcHeap, gc, w.gcHeapStats, eventID)
+	_ = pprof.Lookup("heap").WriteTo(&buf, int(w.config.GetDumpProfileType())) // nolint: errcheck
+	w.writeProfileDataToFile(buf, c, gcHeap, gc, w.gcHeapStats, eventID, reason)
 
 	w.reportProfile(type2name[gcHeap], buf.Bytes(), reason, eventID)
 
@@ -722,27 +763,43 @@ func (w *Watching) EnableDump(curCPU int) (err error) {
 	return nil
 }
 
-func (w *Watching) writeGrProfileDataToFile(data bytes.Buffer, config groupConfigs, dumpType configureType, currentStat int) {
+func (w *Watching) writeGrProfileDataToFile(data bytes.Buffer, config groupConfigs, dumpType configureType, currentStat int, reason string) {
 	w.logf(UniformLogFormat, "pprof", type2name[dumpType],
 		config.TriggerMin, config.TriggerDiff, config.TriggerAbs,
 		config.GoroutineTriggerNumMax,
 		w.grNumStats.data, currentStat)
 
-	if err := writeFile(data, dumpType, w.config.DumpConfigs, ""); err != nil {
+	meta := w.newProfileMeta(dumpType, reason, "", w.grNumStats, currentStat)
+	if err := writeFile(data, w.config.store, w.config.DumpConfigs, meta); err != nil {
 		w.logf("%s", err.Error())
 	}
 }
 
-func (w *Watching) writeProfileDataToFile(data bytes.Buffer, opts typeConfig, dumpType configureType, currentStat int, ringStats ring, eventID string) {
+func (w *Watching) writeProfileDataToFile(data bytes.Buffer, opts typeConfig, dumpType configureType, currentStat int, ringStats ring, eventID string, reason string) {
 	w.logf(UniformLogFormat, "pprof", type2name[dumpType],
 		opts.TriggerMin, opts.TriggerDiff, opts.TriggerAbs,
 		NotSupportTypeMaxConfig, ringStats, currentStat)
 
-	if err := writeFile(data, dumpType, w.config.DumpConfigs, eventID); err != nil {
+	meta := w.newProfileMeta(dumpType, reason, eventID, ringStats, currentStat)
+	if err := writeFile(data, w.config.store, w.config.DumpConfigs, meta); err != nil {
 		w.logf("%s", err.Error())
 	}
 }
 
+// newProfileMeta returns the metadata of a dump triggered now,
+// with the latest continuous profiling round as its baseline.
+func (w *Watching) newProfileMeta(dumpType configureType, reason string, eventID string, ringStats ring, currentStat int) ProfileMeta {
+	return ProfileMeta{
+		Type:     type2name[dumpType],
+		EventID:  eventID,
+		Reason:   reason,
+		Time:     time.Now(),
+		Stats:    ringStats.snapshot(),
+		Current:  currentStat,
+		Baseline: w.baselineEventID(),
+	}
+}
+
 func (w *Watching) getMemoryLimit() (uint64, error) {
 	if w.config.memoryLimit > 0 {
 		return w.config.memoryLimit, nil
@@ -759,5 +816,8 @@ func NewWatching(opts ...options.Option) *Watching {
 	for _, opt := range opts {
 		opt(watching)
 	}
+	if watching.config.store == nil {
+		watching.config.store = NewLocalStore(watching.config.DumpPath, watching.config.dumpRetention)
+	}
 	return watching
 }